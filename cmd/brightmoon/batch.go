@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// gameIDPattern はファイル名・ディレクトリ名からゲーム ID (th08, th135 など) を取り出す正規表現
+var gameIDPattern = regexp.MustCompile(`^th[0-9]{2,3}`)
+
+// batchTarget は一括抽出の対象となるアーカイブ1件分の状態と結果
+// 結果のカウンタは共有ワーカープールから同時に更新されます
+type batchTarget struct {
+	path       string
+	gameID     string
+	format     string
+	discovered bool // ディレクトリの走査で見つけたファイル (アーカイブでなければ失敗にしない)
+
+	archive pbgarc.PBGArchive // 開いている間のみ設定 (同時に開くアーカイブの数はワーカー数までに抑える)
+	opened  bool              // 一度でも開けた (閉じた後も true のまま)
+	paths   *outputPaths
+	policy  *writePolicy
+	filter  *entryFilter
+	openErr error
+	result  *outcome.ArchiveResult // エントリごとの結果 (--report)
+
+	extracted atomic.Int64
+	bytes     atomic.Int64
+	failures  atomic.Int64
+	errOnce   sync.Once
+	firstErr  error // 最初に失敗したエントリのエラー
+
+	pending sync.WaitGroup // ワーカープールに投入して未完了のジョブ
+}
+
+// openArchive はアーカイブを開きます
+// 初めて開いた場合は検出した形式を記録・表示し、開けなかった場合は失敗 (走査で見つけたファイルはスキップ) として記録します
+func (t *batchTarget) openArchive(archiveOpts *archiveOptions) bool {
+	archive, err := openArchive(t.path, archiveOpts)
+	if err != nil {
+		t.openErr = err
+		if t.discovered {
+			t.result.Skip(err)
+		} else {
+			t.result.Fail(err)
+			i18n.Fprintf(os.Stderr, "アーカイブを開けません %s: %v\n", t.path, err)
+		}
+		return false
+	}
+	t.archive = archive
+	if !t.opened {
+		t.opened = true
+		var subType string
+		t.format, subType = describeArchive(archive)
+		t.result.SetFormat(t.format, subType)
+		fmt.Printf("%s: %s (%s)\n", t.path, t.gameID, t.format)
+	}
+	return true
+}
+
+// closeArchive はアーカイブを閉じます (開いていない場合は何もしません)
+func (t *batchTarget) closeArchive() {
+	if t.archive == nil {
+		return
+	}
+	t.archive.Close()
+	t.archive = nil
+}
+
+// batchJob は共有ワーカープールに投入する抽出ジョブ
+type batchJob struct {
+	extractJob
+	target *batchTarget
+}
+
+// setupBatch は batch サブコマンドを設定します
+func setupBatch(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	archiveOpts := &archiveOptions{}
+	archiveOpts.register(fs)
+	opts := &extractOptions{}
+	fs.StringVar(&opts.outputDir, "o", ".", "output directory (each archive is extracted into <dir>/<game-id>/)")
+	fs.IntVar(&opts.workerCount, "w", 4, "number of worker threads shared by all archives")
+	fs.Var(&opts.includes, "include", "extract only entries matching the glob `pattern` (repeatable)")
+	fs.Var(&opts.excludes, "exclude", "skip entries matching the glob `pattern` (repeatable)")
+	fs.BoolVar(&opts.useRegex, "regex", false, "treat --include/--exclude patterns as regular expressions")
+	fs.BoolVar(&opts.rawNames, "raw-names", false, "use entry names as-is without sanitization (unsafe: names may point outside the output directory)")
+	fs.StringVar(&opts.overwrite, "overwrite", overwriteAlways, "policy for existing files: always, never, newer (archive is newer than the file), if-different")
+	fs.BoolVar(&opts.resume, "resume", false, "skip entries whose output already exists with the same size")
+	fs.BoolVar(&opts.progress, "progress", false, "show a progress bar across all archives; prints periodic log lines when stdout is not a terminal")
+	opts.registerReport(fs)
+
+	return func(ctx context.Context, args []string) (err error) {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		rep := newReport(opts.reportPath, "batch")
+		defer func() { err = finishReport(rep, opts.reportPath, err) }()
+		return runBatch(ctx, args, archiveOpts, opts, rep)
+	}
+}
+
+// runBatch はディレクトリ・glob・ファイルで指定された全アーカイブを <出力先>/<ゲーム ID>/ に抽出し、
+// アーカイブごとの結果を表示します
+// 1つのアーカイブの失敗で処理全体を止めることはせず、失敗があった場合は最後にエラーを返します
+// 全てのアーカイブで失敗した場合は最初の失敗の種類、一部のみの場合は outcome.KindPartial のエラーです
+func runBatch(ctx context.Context, inputs []string, archiveOpts *archiveOptions, opts *extractOptions, rep *outcome.Report) error {
+	targets, err := collectBatchTargets(inputs)
+	if err != nil {
+		return err
+	}
+
+	// 同じゲーム ID のアーカイブは出力先を共有するため、名前の重複判定も共有する
+	sharedPaths := make(map[string]*outputPaths)
+	for _, t := range targets {
+		t.result = rep.AddArchive(t.path)
+		if t.filter, err = newEntryFilter(nil, opts.includes, opts.excludes, opts.useRegex); err != nil {
+			return outcome.Default(outcome.KindUsage, err)
+		}
+		if t.policy, err = newWritePolicy(t.path, opts); err != nil {
+			return outcome.Default(outcome.KindUsage, err)
+		}
+		if sharedPaths[t.gameID] == nil {
+			sharedPaths[t.gameID] = newOutputPaths(filepath.Join(opts.outputDir, t.gameID), opts.rawNames)
+		}
+		t.paths = sharedPaths[t.gameID]
+	}
+
+	// 形式の自動検出の詳細はアーカイブごとに表示しない
+	if !debugMode {
+		statusOut = io.Discard
+	}
+
+	// 進捗の合計を求めるため、抽出の前に1つずつ開いて対象のエントリを数える
+	var prog *progress
+	if opts.progress {
+		var totalEntries int
+		var totalBytes int64
+		for _, t := range targets {
+			if !t.openArchive(archiveOpts) {
+				continue
+			}
+			entries, bytes := countTargets(t.archive, t.filter)
+			totalEntries += entries
+			totalBytes += bytes
+			t.closeArchive()
+		}
+		prog = newProgress(os.Stdout, totalEntries, totalBytes)
+	}
+	i18n.Printf("\n%d 個のアーカイブを抽出中...\n", countPending(targets))
+
+	prog.begin()
+	extractBatch(ctx, targets, archiveOpts, opts.workerCount, prog)
+	prog.finish()
+
+	// 中断したために開かなかったアーカイブはスキップとして記録する
+	if err := ctx.Err(); err != nil {
+		for _, t := range targets {
+			if t.openErr == nil && !t.opened {
+				t.result.Skip(err)
+			}
+		}
+	}
+
+	fmt.Println()
+	writeBatchReport(os.Stdout, targets)
+
+	if err := ctx.Err(); err != nil {
+		i18n.Printf("\n処理を中断しました (書き込み途中のファイルは削除しました)\n")
+		return err
+	}
+	return batchError(targets)
+}
+
+// batchError は一括抽出の結果をエラーにまとめます (失敗がない場合は nil)
+// アーカイブでないためスキップしたファイルは数えません
+func batchError(targets []*batchTarget) error {
+	var firstErr error
+	partial := false // 一部でも抽出できたアーカイブがある
+	for _, t := range targets {
+		if (t.openErr != nil && t.discovered) || (t.openErr == nil && !t.opened) {
+			continue
+		}
+		switch {
+		case t.openErr != nil:
+			if firstErr == nil {
+				firstErr = t.openErr
+			}
+		case t.failures.Load() == 0:
+			partial = true
+		default:
+			if firstErr == nil {
+				firstErr = t.firstErr
+			}
+			if t.extracted.Load() > 0 || t.policy.skipped.Load() > 0 {
+				partial = true
+			}
+		}
+	}
+	switch {
+	case firstErr == nil:
+		return nil
+	case partial:
+		return outcome.Wrap(outcome.KindPartial, errors.New(i18n.T("一部のアーカイブで抽出に失敗しました")))
+	default:
+		return i18n.Errorf("全てのアーカイブで抽出に失敗しました: %w", firstErr)
+	}
+}
+
+// collectBatchTargets は引数をアーカイブファイルの一覧に展開します
+// ディレクトリは再帰的に走査して .dat と .pak を、glob パターン (~/touhou/*/th*.dat など) は一致したファイルを対象にします
+func collectBatchTargets(inputs []string) ([]*batchTarget, error) {
+	var targets []*batchTarget
+	seen := make(map[string]bool)
+	add := func(path string, discovered bool) {
+		if abs, err := filepath.Abs(path); err == nil {
+			if seen[abs] {
+				return
+			}
+			seen[abs] = true
+		}
+		targets = append(targets, &batchTarget{path: path, gameID: gameIDFromPath(path), discovered: discovered})
+	}
+
+	for _, input := range inputs {
+		input = expandHome(input)
+		matches := []string{input}
+		if strings.ContainsAny(input, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(input); err != nil {
+				return nil, outcome.Wrap(outcome.KindUsage, i18n.Errorf("glob パターンが不正です %q: %v", input, err))
+			}
+			if len(matches) == 0 {
+				i18n.Fprintf(os.Stderr, "警告: パターンに一致するファイルがありません: %s\n", input)
+			}
+		}
+
+		for _, match := range matches {
+			fileInfo, err := os.Stat(match)
+			if err != nil {
+				return nil, i18n.Errorf("ファイルにアクセスできません: %w", err)
+			}
+			if !fileInfo.IsDir() {
+				add(match, false)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && isArchiveCandidate(path) {
+					add(path, true)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, i18n.Errorf("ディレクトリを走査できません: %w", err)
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, outcome.Wrap(outcome.KindUsage, errors.New(i18n.T("抽出するアーカイブが見つかりませんでした")))
+	}
+	return targets, nil
+}
+
+// expandHome は先頭の ~/ をホームディレクトリに展開します (引用符で囲まれてシェルが展開しなかった場合のため)
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// isArchiveCandidate はディレクトリの走査時にアーカイブとして扱う拡張子かを判定します
+func isArchiveCandidate(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dat", ".pak":
+		return true
+	}
+	return false
+}
+
+// gameIDFromPath はアーカイブのパスから出力先に使うゲーム ID を決定します
+// ファイル名 (th08.dat, th135b.pak) から判定できない場合は親ディレクトリ名、
+// それも判定できない場合は拡張子を除いたファイル名を使います
+func gameIDFromPath(path string) string {
+	if g, ok := catalog.FromFileName(path); ok {
+		return g.ID
+	}
+	if id := gameIDPattern.FindString(strings.ToLower(filepath.Base(filepath.Dir(path)))); id != "" {
+		return id
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// countPending は抽出するアーカイブの数 (開けなかったことが分かっているものを除く) を返します
+func countPending(targets []*batchTarget) int {
+	n := 0
+	for _, t := range targets {
+		if t.openErr == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// extractBatch は全アーカイブのエントリを1つのワーカープールで抽出します
+// アーカイブはジョブを投入する直前に開き、そのジョブが全て終わった時点で閉じます
+// 同時に開くアーカイブの数はワーカー数までに制限し、アーカイブが多くてもファイルやメモリを使い切らないようにします
+// エントリの列挙とジョブの投入は呼び出し元の goroutine で順に行います
+func extractBatch(ctx context.Context, targets []*batchTarget, archiveOpts *archiveOptions, numWorkers int, prog *progress) {
+	if numWorkers <= 0 {
+		numWorkers = 4 // デフォルトのワーカー数
+	}
+
+	var mu sync.Mutex // 出力用のミューテックス
+	report := func(t *batchTarget, entryName string, err error) {
+		t.failures.Add(1)
+		t.errOnce.Do(func() { t.firstErr = err })
+		t.result.AddEntry(entryName, outcome.StatusFailed, 0, err)
+		mu.Lock()
+		i18n.Fprintf(os.Stderr, "[%s] 抽出に失敗しました: %s - %v\n", filepath.Base(t.path), entryName, err)
+		mu.Unlock()
+	}
+
+	jobs := make(chan batchJob, numWorkers*2)
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				t := job.target
+				if ctx.Err() != nil {
+					t.pending.Done()
+					continue // キャンセル後に残っているジョブは処理しない
+				}
+				d, written, err := t.policy.writeEntryFile(ctx, job.entry, job.outPath, job.relName, nil, prog)
+				t.pending.Done()
+				if errors.Is(err, context.Canceled) {
+					continue
+				}
+				prog.entryDone(job.entry, false)
+				if err != nil {
+					report(t, job.entry.GetEntryName(), err)
+					continue
+				}
+				if written {
+					t.extracted.Add(1)
+					t.bytes.Add(d.Size)
+					t.result.AddEntry(job.entry.GetEntryName(), outcome.StatusOK, d.Size, nil)
+				} else {
+					t.result.AddEntry(job.entry.GetEntryName(), outcome.StatusSkipped, d.Size, nil)
+				}
+			}
+		}()
+	}
+
+	open := make(chan struct{}, numWorkers) // 開いているアーカイブの数を制限するセマフォ
+	var closers sync.WaitGroup
+	for _, t := range targets {
+		if t.openErr != nil {
+			continue
+		}
+		select {
+		case open <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break // 中断した場合、残りのアーカイブは開かない
+		}
+		if !t.openArchive(archiveOpts) {
+			<-open
+			continue
+		}
+		enqueueBatchJobs(ctx, t, jobs, report, &mu, prog)
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			t.pending.Wait()
+			t.closeArchive()
+			<-open
+		}()
+	}
+	close(jobs)
+	wg.Wait()
+	closers.Wait()
+}
+
+// enqueueBatchJobs はアーカイブのエントリを列挙し、抽出するエントリのジョブを投入します
+func enqueueBatchJobs(ctx context.Context, t *batchTarget, jobs chan<- batchJob, report func(*batchTarget, string, error), mu *sync.Mutex, prog *progress) {
+	if ctx.Err() != nil || !t.archive.EnumFirst() {
+		return
+	}
+	do := true
+	for do && ctx.Err() == nil {
+		entry := t.archive.GetEntry()
+		entryName := entry.GetEntryName()
+		if !t.filter.match(entryName) {
+			do = t.archive.EnumNext()
+			continue
+		}
+
+		// 出力先のパスを決定 (安全でない名前は失敗として記録)
+		outPath, relName, err := t.paths.resolve(entryName)
+		if err != nil {
+			report(t, entryName, outcome.Wrap(outcome.KindFormat, err))
+			prog.entryDone(entry, false)
+			do = t.archive.EnumNext()
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+			report(t, entryName, err)
+			prog.entryDone(entry, false)
+			do = t.archive.EnumNext()
+			continue
+		}
+
+		// 既存のファイルを確認 (上書き方針・再開)
+		skip, err := t.policy.skip(outPath, relName, entry, nil)
+		if err != nil {
+			mu.Lock()
+			i18n.Fprintf(os.Stderr, "既存のファイルを確認できません %s: %v\n", outPath, err)
+			mu.Unlock()
+		}
+		if skip {
+			t.result.AddEntry(entryName, outcome.StatusSkipped, 0, nil)
+			prog.entryDone(entry, true)
+			do = t.archive.EnumNext()
+			continue
+		}
+
+		t.pending.Add(1)
+		select {
+		case jobs <- batchJob{extractJob{entry: entry, outPath: outPath, relName: relName}, t}:
+		case <-ctx.Done():
+			t.pending.Done()
+		}
+		do = t.archive.EnumNext()
+	}
+}
+
+// writeBatchReport はアーカイブごとの抽出結果 (エントリ数・バイト数・スキップ・失敗) を表示します
+func writeBatchReport(w io.Writer, targets []*batchTarget) {
+	sorted := append([]*batchTarget(nil), targets...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].gameID < sorted[j].gameID })
+
+	nameWidth := 24
+	for _, t := range sorted {
+		nameWidth = max(nameWidth, displayWidth(t.path))
+	}
+	rule := strings.Repeat("-", nameWidth+62)
+
+	i18n.Fprintln(w, "抽出結果:")
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "%s %s %s %s %s %s %s\n", padRight(i18n.T("アーカイブ"), nameWidth), padRight(i18n.T("ゲーム"), 7), padRight(i18n.T("形式"), 9),
+		padLeft(i18n.T("エントリ"), 8), padLeft(i18n.T("バイト数"), 12), padLeft(i18n.T("スキップ"), 8), padLeft(i18n.T("失敗"), 6))
+	fmt.Fprintln(w, rule)
+
+	var entries, bytes, skipped, failures int64
+	for _, t := range sorted {
+		if !t.opened {
+			status := i18n.T("形式を検出できませんでした")
+			switch {
+			case t.openErr == nil:
+				status = i18n.T("中断したため開いていません")
+			case t.discovered:
+				status = i18n.T("アーカイブではないためスキップしました")
+			}
+			fmt.Fprintf(w, "%s %s %s\n", padRight(t.path, nameWidth), padRight(t.gameID, 7), status)
+			continue
+		}
+		s := t.policy.skipped.Load()
+		fmt.Fprintf(w, "%s %s %s %8d %12d %8d %6d\n", padRight(t.path, nameWidth), padRight(t.gameID, 7), padRight(t.format, 9),
+			t.extracted.Load(), t.bytes.Load(), s, t.failures.Load())
+		entries += t.extracted.Load()
+		bytes += t.bytes.Load()
+		skipped += s
+		failures += t.failures.Load()
+	}
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "%s %s %s %8d %12d %8d %6d\n", padRight(i18n.T("合計"), nameWidth), padRight("", 7), padRight("", 9), entries, bytes, skipped, failures)
+}
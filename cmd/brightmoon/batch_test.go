@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+)
+
+func TestBatch(t *testing.T) {
+	root := t.TempDir()
+	writePBG3Archive(t, root, "games/th06/th06.dat", testEntries)
+	writePBG3Archive(t, root, "games/th06/紅魔郷MD.DAT", []testEntry{{name: "A.txt", data: []byte("music\n")}})
+	writePBG3Archive(t, root, "games/th07/custom.dat", []testEntry{{name: "x.txt", data: []byte("x\n")}})
+	writePBG3Archive(t, root, "games/misc/other.dat", []testEntry{{name: "y.txt", data: []byte("y\n")}})
+	corrupt := writePBG3Archive(t, root, "broken/th08.dat", []testEntry{
+		{name: "ok.txt", data: []byte("ok\n")},
+		{name: "ng.txt", data: []byte("ng\n"), corrupt: true},
+	})
+	if err := os.WriteFile(filepath.Join(root, "games/readme.dat"), []byte("not an archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	junk := filepath.Join(root, "junk.dat")
+	if err := os.WriteFile(junk, []byte("not an archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		inputs []string
+		args   []string
+		code   int
+		want   map[string]string
+	}{
+		{
+			name:   "ディレクトリの走査",
+			inputs: []string{filepath.Join(root, "games")},
+			want: map[string]string{
+				"th06/a.txt":     "alpha\n",
+				"th06/dir/b.txt": "bravo\n",
+				"th06/c.bin":     string(testEntries[2].data),
+				"th06/A~1.txt":   "music\n", // 同じゲーム ID のアーカイブは出力先を共有するため、a.txt と重複しない名前にする
+				"th07/x.txt":     "x\n",
+				"other/y.txt":    "y\n",
+			},
+		},
+		{
+			name:   "glob とフィルタ",
+			inputs: []string{filepath.Join(root, "games", "th0*", "*.dat")}, // glob は大文字小文字を区別するため 紅魔郷MD.DAT は対象外
+			args:   []string{"--exclude", "*.bin", "--include", "*.txt", "-w", "1"},
+			want: map[string]string{
+				"th06/a.txt":     "alpha\n",
+				"th06/dir/b.txt": "bravo\n",
+				"th07/x.txt":     "x\n",
+			},
+		},
+		{
+			name:   "一部のアーカイブのみ失敗",
+			inputs: []string{filepath.Join(root, "games", "misc"), corrupt},
+			code:   4,
+			want:   map[string]string{"other/y.txt": "y\n", "th08/ok.txt": "ok\n"},
+		},
+		{
+			name:   "指定したファイルがアーカイブではない",
+			inputs: []string{junk},
+			code:   3,
+			want:   map[string]string{},
+		},
+		{
+			name:   "一致するファイルがない",
+			inputs: []string{filepath.Join(root, "nothing", "*.dat")},
+			code:   2,
+			want:   map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := t.TempDir()
+			args := append([]string{"batch", "-o", out}, tt.args...)
+			res := runCLI(t, context.Background(), append(args, tt.inputs...)...)
+			if res.code != tt.code {
+				t.Fatalf("exit code = %d, want %d\nstdout: %s\nstderr: %s", res.code, tt.code, res.stdout, res.stderr)
+			}
+			got := readTree(t, out)
+			if len(got) != len(tt.want) {
+				t.Errorf("extracted %d files, want %d: %v", len(got), len(tt.want), got)
+			}
+			for name, data := range tt.want {
+				if got[name] != data {
+					t.Errorf("%s = %q, want %q", name, got[name], data)
+				}
+			}
+		})
+	}
+}
+
+func TestBatch_Report(t *testing.T) {
+	root := t.TempDir()
+	writePBG3Archive(t, root, "th06.dat", testEntries)
+	writePBG3Archive(t, root, "th08.dat", []testEntry{{name: "ng.txt", data: []byte("ng\n"), corrupt: true}})
+	if err := os.WriteFile(filepath.Join(root, "readme.dat"), []byte("not an archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	res := runCLI(t, context.Background(), "batch", "-o", t.TempDir(), "--report", reportPath, root)
+	if res.code != 4 {
+		t.Fatalf("exit code = %d, want 4 (stderr: %s)", res.code, res.stderr)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep outcome.Report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, data)
+	}
+	if rep.Command != "batch" || rep.ExitCode != 4 || len(rep.Archives) != 3 {
+		t.Fatalf("report = %s", data)
+	}
+	want := map[string]struct {
+		status outcome.Status
+		ok     int
+		failed int
+	}{
+		"th06.dat":   {outcome.StatusOK, 3, 0},
+		"th08.dat":   {outcome.StatusFailed, 0, 1},
+		"readme.dat": {outcome.StatusSkipped, 0, 0},
+	}
+	for _, a := range rep.Archives {
+		w := want[filepath.Base(a.Path)]
+		if a.Status != w.status || a.Summary.OK != w.ok || a.Summary.Failed != w.failed {
+			t.Errorf("%s: status %s, summary %+v, want %s ok=%d failed=%d", a.Path, a.Status, a.Summary, w.status, w.ok, w.failed)
+		}
+	}
+}
+
+func TestBatch_MoreArchivesThanWorkers(t *testing.T) {
+	root := t.TempDir()
+	want := make(map[string]string)
+	for i := 10; i < 16; i++ {
+		id := fmt.Sprintf("th%d", i)
+		writePBG3Archive(t, root, id+"/"+id+".dat", []testEntry{{name: "a.txt", data: []byte(id + "\n")}})
+		want[id+"/a.txt"] = id + "\n"
+	}
+
+	for _, args := range [][]string{{"-w", "2"}, {"-w", "2", "--progress"}} {
+		out := t.TempDir()
+		res := runCLI(t, context.Background(), append(append([]string{"batch", "-o", out}, args...), root)...)
+		if res.code != 0 {
+			t.Fatalf("%v: exit code = %d (stderr: %s)", args, res.code, res.stderr)
+		}
+		got := readTree(t, out)
+		if len(got) != len(want) {
+			t.Errorf("%v: extracted %d files, want %d: %v", args, len(got), len(want), got)
+		}
+		for name, data := range want {
+			if got[name] != data {
+				t.Errorf("%v: %s = %q, want %q", args, name, got[name], data)
+			}
+		}
+	}
+}
+
+func TestBatch_Cancelled(t *testing.T) {
+	root := t.TempDir()
+	writePBG3Archive(t, root, "th06.dat", testEntries)
+	writePBG3Archive(t, root, "th07/th07.dat", testEntries)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out := t.TempDir()
+	res := runCLI(t, ctx, "batch", "--progress", "-o", out, root)
+	if res.code != 130 {
+		t.Fatalf("exit code = %d, want 130 (stderr: %s)", res.code, res.stderr)
+	}
+	if files := readTree(t, out); len(files) != 0 {
+		t.Errorf("cancelled batch left files: %v", files)
+	}
+}
+
+func TestGameIDFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/games/th08.dat", "th08"},
+		{"/games/紅魔郷ST.DAT", "th06"},
+		{"/games/th135/th135b.pak", "th135"},
+		{"/games/th10/custom.dat", "th10"},
+		{"/games/misc/custom.dat", "custom"},
+	}
+	for _, tt := range tests {
+		if got := gameIDFromPath(tt.path); got != tt.want {
+			t.Errorf("gameIDFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
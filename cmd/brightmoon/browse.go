@@ -0,0 +1,662 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// browseNode はエントリのツリーの1要素 (ディレクトリまたはエントリ)
+type browseNode struct {
+	name     string // ディレクトリ内での名前
+	path     string // アーカイブ内のパス ('/' 区切り)
+	depth    int
+	parent   *browseNode
+	children []*browseNode // ディレクトリの場合のみ
+	entry    pbgarc.PBGArchiveEntry
+	expanded bool
+	marked   bool
+}
+
+func (n *browseNode) isDir() bool {
+	return n.entry == nil
+}
+
+// size はエントリの元サイズと圧縮サイズ (ディレクトリは配下の合計) を返します
+func (n *browseNode) size() (original, compressed int64) {
+	if !n.isDir() {
+		return int64(n.entry.GetOriginalSize()), int64(n.entry.GetCompressedSize())
+	}
+	for _, c := range n.children {
+		o, z := c.size()
+		original += o
+		compressed += z
+	}
+	return original, compressed
+}
+
+// setMarked はエントリ (ディレクトリの場合は配下の全エントリ) の選択状態を設定します
+func (n *browseNode) setMarked(marked bool) {
+	n.marked = marked
+	for _, c := range n.children {
+		c.setMarked(marked)
+	}
+}
+
+// 画面の状態
+const (
+	browseModeTree = iota
+	browseModeText
+	browseModeHex
+)
+
+// browser は browse サブコマンドの画面の状態
+type browser struct {
+	filename string
+	archive  pbgarc.PBGArchive
+	format   string
+	root     *browseNode
+	outDir   string
+
+	visible []*browseNode // 展開されているノードを表示順に並べたもの
+	cursor  int
+	top     int // 表示している先頭の行
+
+	mode        int
+	preview     []string // プレビューの各行
+	previewNode *browseNode
+	previewTop  int
+
+	message string // ステータス行に表示するメッセージ
+	cols    int
+	rows    int
+}
+
+// setupBrowse は browse サブコマンドを設定します
+func setupBrowse(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	opts := &archiveOptions{}
+	opts.register(fs)
+	outputDir := fs.String("o", ".", "output directory for extracting marked entries")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			return errors.New(i18n.T("browse は端末から実行してください"))
+		}
+
+		archive, err := openArchive(args[0], opts)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		b := newBrowser(args[0], archive, *outputDir)
+		return b.run(ctx)
+	}
+}
+
+// newBrowser はアーカイブのエントリを列挙してツリーを作成します
+func newBrowser(filename string, archive pbgarc.PBGArchive, outDir string) *browser {
+	b := &browser{
+		filename: filename,
+		archive:  archive,
+		outDir:   outDir,
+		root:     &browseNode{expanded: true, depth: -1},
+	}
+	b.format, _ = describeArchive(archive)
+
+	// ディレクトリは末尾に '/' を付けたパスで管理する (path.Dir はルートを "." で返すため "./" もルートとして扱う)
+	dirs := map[string]*browseNode{"": b.root, "./": b.root}
+	var dirFor func(p string) *browseNode
+	dirFor = func(p string) *browseNode {
+		if n, ok := dirs[p]; ok {
+			return n
+		}
+		parent := dirFor(path.Dir(strings.TrimSuffix(p, "/")) + "/")
+		n := &browseNode{name: path.Base(p), path: p, depth: parent.depth + 1, parent: parent}
+		parent.children = append(parent.children, n)
+		dirs[p] = n
+		return n
+	}
+
+	if archive.EnumFirst() {
+		do := true
+		for do {
+			name := entryKey(archive.GetEntryName())
+			dir := b.root
+			if i := strings.LastIndexByte(name, '/'); i >= 0 {
+				dir = dirFor(name[:i+1])
+			}
+			dir.children = append(dir.children, &browseNode{
+				name:   path.Base(name),
+				path:   name,
+				depth:  dir.depth + 1,
+				parent: dir,
+				entry:  archive.GetEntry(),
+			})
+			do = archive.EnumNext()
+		}
+	}
+	sortBrowseNodes(b.root)
+	b.refresh()
+	return b
+}
+
+// sortBrowseNodes はディレクトリを先に、それぞれを名前順に並べます
+func sortBrowseNodes(n *browseNode) {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		a, c := n.children[i], n.children[j]
+		if a.isDir() != c.isDir() {
+			return a.isDir()
+		}
+		return a.name < c.name
+	})
+	for _, c := range n.children {
+		sortBrowseNodes(c)
+	}
+}
+
+// refresh は展開状態に合わせて表示する行を作り直します
+func (b *browser) refresh() {
+	b.visible = b.visible[:0]
+	var walk func(n *browseNode)
+	walk = func(n *browseNode) {
+		for _, c := range n.children {
+			b.visible = append(b.visible, c)
+			if c.isDir() && c.expanded {
+				walk(c)
+			}
+		}
+	}
+	walk(b.root)
+	b.cursor = min(b.cursor, max(len(b.visible)-1, 0))
+}
+
+// run は端末を raw モードにして、キー入力を処理します
+func (b *browser) run(ctx context.Context) error {
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return i18n.Errorf("端末を設定できません: %v", err)
+	}
+	// 代替画面に切り替え、カーソルを隠す
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		restore()
+	}()
+
+	keys := make(chan string)
+	go readKeys(keys)
+
+	for {
+		b.draw()
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			if !ok || !b.handleKey(key) {
+				return nil
+			}
+		}
+	}
+}
+
+// readKeys は標準入力からキーを読み取り、エスケープシーケンス単位で送ります
+func readKeys(keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range splitKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+// splitKeys は1回の読み取り結果をキーごとに分割します
+// 矢印キーなどのエスケープシーケンス (ESC [ ... または ESC O x) はまとめて1つのキーとして扱います
+func splitKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		n := 1
+		switch {
+		case data[0] == 0x1b && len(data) >= 3 && data[1] == '[':
+			n = 2
+			for n < len(data) && (data[n] < 0x40 || data[n] > 0x7e) {
+				n++
+			}
+			n = min(n+1, len(data))
+		case data[0] == 0x1b && len(data) >= 3 && data[1] == 'O':
+			n = 3
+		case data[0] >= 0x80:
+			_, n = utf8.DecodeRune(data)
+		}
+		keys = append(keys, string(data[:n]))
+		data = data[n:]
+	}
+	return keys
+}
+
+// キーの名前
+const (
+	keyUp       = "\x1b[A"
+	keyDown     = "\x1b[B"
+	keyRight    = "\x1b[C"
+	keyLeft     = "\x1b[D"
+	keyPageUp   = "\x1b[5~"
+	keyPageDown = "\x1b[6~"
+	keyHome     = "\x1b[H"
+	keyEnd      = "\x1b[F"
+	keyEscape   = "\x1b"
+	keyCtrlC    = "\x03"
+	keyEnter    = "\r"
+)
+
+// handleKey はキー入力を処理します。終了する場合は false を返します
+func (b *browser) handleKey(key string) bool {
+	b.message = ""
+	if key == keyCtrlC {
+		return false
+	}
+	if b.mode != browseModeTree {
+		b.handlePreviewKey(key)
+		return true
+	}
+
+	page := max(b.listRows()-1, 1)
+	switch key {
+	case "q":
+		return false
+	case keyUp, "k":
+		b.cursor--
+	case keyDown, "j":
+		b.cursor++
+	case keyPageUp:
+		b.cursor -= page
+	case keyPageDown:
+		b.cursor += page
+	case " ":
+		b.toggleMark()
+		b.cursor++
+	case keyHome, "g", "\x1b[1~", "\x1bOH":
+		b.cursor = 0
+	case keyEnd, "G", "\x1b[4~", "\x1bOF":
+		b.cursor = len(b.visible) - 1
+	case keyRight, "l", keyEnter, "\n":
+		if n := b.current(); n != nil {
+			if n.isDir() {
+				n.expanded = true
+				b.refresh()
+			} else if isTextEntry(n.path) {
+				b.openPreview(n, browseModeText)
+			} else {
+				b.openPreview(n, browseModeHex)
+			}
+		}
+	case keyLeft, "h":
+		if n := b.current(); n != nil {
+			if n.isDir() && n.expanded {
+				n.expanded = false
+				b.refresh()
+			} else if n.parent != b.root {
+				n.parent.expanded = false
+				b.refresh()
+				b.cursor = b.indexOf(n.parent)
+			}
+		}
+	case "t":
+		if n := b.current(); n != nil && !n.isDir() {
+			b.openPreview(n, browseModeText)
+		}
+	case "x":
+		if n := b.current(); n != nil && !n.isDir() {
+			b.openPreview(n, browseModeHex)
+		}
+	case "a":
+		b.root.setMarked(!b.allMarked())
+	case "e":
+		b.extractMarked()
+	}
+	b.cursor = max(min(b.cursor, len(b.visible)-1), 0)
+	return true
+}
+
+// handlePreviewKey はプレビュー表示中のキー入力を処理します
+func (b *browser) handlePreviewKey(key string) {
+	page := max(b.rows-2, 1)
+	switch key {
+	case "q", keyEscape, keyLeft, "h":
+		b.mode = browseModeTree
+		b.preview = nil
+		return
+	case keyUp, "k":
+		b.previewTop--
+	case keyDown, "j", keyEnter:
+		b.previewTop++
+	case keyPageUp:
+		b.previewTop -= page
+	case keyPageDown, " ":
+		b.previewTop += page
+	case keyHome, "g", "\x1b[1~", "\x1bOH":
+		b.previewTop = 0
+	case keyEnd, "G", "\x1b[4~", "\x1bOF":
+		b.previewTop = len(b.preview) - page
+	case "t":
+		b.openPreview(b.previewNode, browseModeText)
+	case "x":
+		b.openPreview(b.previewNode, browseModeHex)
+	}
+	b.previewTop = max(min(b.previewTop, len(b.preview)-page), 0)
+}
+
+func (b *browser) current() *browseNode {
+	if b.cursor < 0 || b.cursor >= len(b.visible) {
+		return nil
+	}
+	return b.visible[b.cursor]
+}
+
+func (b *browser) indexOf(n *browseNode) int {
+	for i, v := range b.visible {
+		if v == n {
+			return i
+		}
+	}
+	return 0
+}
+
+func (b *browser) toggleMark() {
+	if n := b.current(); n != nil {
+		n.setMarked(!n.marked)
+	}
+}
+
+func (b *browser) allMarked() bool {
+	all := true
+	b.walkEntries(func(n *browseNode) {
+		all = all && n.marked
+	})
+	return all
+}
+
+// walkEntries はツリーの全エントリ (ディレクトリを除く) を表示順に処理します
+func (b *browser) walkEntries(fn func(n *browseNode)) {
+	var walk func(n *browseNode)
+	walk = func(n *browseNode) {
+		for _, c := range n.children {
+			if c.isDir() {
+				walk(c)
+			} else {
+				fn(c)
+			}
+		}
+	}
+	walk(b.root)
+}
+
+// openPreview はエントリを展開してテキストまたは16進ダンプで表示します
+// テキスト表示では、テキストのエントリは全体を、それ以外は埋め込まれた文字列を Shift-JIS から変換します
+func (b *browser) openPreview(n *browseNode, mode int) {
+	var buf bytes.Buffer
+	if !n.entry.Extract(&buf, nil, nil) {
+		b.message = i18n.Sprintf("抽出に失敗しました: %s", n.path)
+		return
+	}
+
+	var text bytes.Buffer
+	switch {
+	case mode == browseModeHex:
+		writeHexDump(&text, buf.Bytes())
+	case isTextEntry(n.path):
+		tw := writeShiftJISText(&text)
+		tw.Write(buf.Bytes())
+		tw.Close()
+	default:
+		writeEmbeddedText(&text, buf.Bytes())
+	}
+
+	b.mode = mode
+	b.previewNode = n
+	b.previewTop = 0
+	b.preview = strings.Split(strings.TrimRight(strings.ReplaceAll(text.String(), "\r\n", "\n"), "\n"), "\n")
+}
+
+// writeHexDump は hexdump -C と同じ形式で16進ダンプを書き出します
+func writeHexDump(w *bytes.Buffer, data []byte) {
+	for off := 0; off < len(data); off += 16 {
+		line := data[off:min(off+16, len(data))]
+		fmt.Fprintf(w, "%08x ", off)
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				w.WriteByte(' ')
+			}
+			if i < len(line) {
+				fmt.Fprintf(w, " %02x", line[i])
+			} else {
+				w.WriteString("   ")
+			}
+		}
+		w.WriteString("  |")
+		for _, c := range line {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			w.WriteByte(c)
+		}
+		w.WriteString("|\n")
+	}
+}
+
+// extractMarked は選択したエントリを出力先ディレクトリに抽出します
+// 書き出しは extract と同じく一時ファイル経由で行います
+func (b *browser) extractMarked() {
+	var marked []*browseNode
+	b.walkEntries(func(n *browseNode) {
+		if n.marked {
+			marked = append(marked, n)
+		}
+	})
+	if len(marked) == 0 {
+		b.message = i18n.T("抽出するエントリを Space で選択してください")
+		return
+	}
+
+	policy, err := newWritePolicy(b.filename, &extractOptions{overwrite: overwriteAlways})
+	if err != nil {
+		b.message = err.Error()
+		return
+	}
+	paths := newOutputPaths(b.outDir, false)
+	count, failed := 0, 0
+	for _, n := range marked {
+		outPath, relName, err := paths.resolve(n.entry.GetEntryName())
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(outPath), 0755)
+		}
+		if err == nil {
+			_, _, err = policy.writeEntryFile(context.Background(), n.entry, outPath, relName, nil, nil)
+		}
+		if err != nil {
+			failed++
+			continue
+		}
+		count++
+	}
+	b.message = i18n.Sprintf("%d 個のファイルを %s に抽出しました", count, b.outDir)
+	if failed > 0 {
+		b.message += i18n.Sprintf(" (%d 個は失敗しました)", failed)
+	}
+}
+
+// listRows はツリーの表示に使える行数 (見出しとステータス行を除く)
+func (b *browser) listRows() int {
+	return max(b.rows-3, 1)
+}
+
+// draw は画面全体を描画します
+func (b *browser) draw() {
+	b.cols, b.rows = 80, 24
+	if cols, rows, err := terminalSize(os.Stdout); err == nil && cols > 0 && rows > 0 {
+		b.cols, b.rows = cols, rows
+	}
+
+	var out bytes.Buffer
+	out.WriteString("\x1b[H")
+	if b.mode == browseModeTree {
+		b.drawTree(&out)
+	} else {
+		b.drawPreview(&out)
+	}
+	os.Stdout.Write(out.Bytes())
+}
+
+func (b *browser) drawTree(out *bytes.Buffer) {
+	rows := b.listRows()
+	if b.cursor < b.top {
+		b.top = b.cursor
+	} else if b.cursor >= b.top+rows {
+		b.top = b.cursor - rows + 1
+	}
+
+	nameWidth := max(b.cols-32, 16)
+	writeLine(out, i18n.Sprintf("%s (%s, %d エントリ)", b.filename, b.format, len(b.root.flatten())), b.cols, "\x1b[1m")
+	writeLine(out, fmt.Sprintf("   %s %s %s %s", padRight(i18n.T("名前"), nameWidth), padLeft(i18n.T("元サイズ"), 10), padLeft(i18n.T("圧縮サイズ"), 10), padLeft(i18n.T("圧縮率"), 6)), b.cols, "\x1b[4m")
+	for i := 0; i < rows; i++ {
+		idx := b.top + i
+		if idx >= len(b.visible) {
+			writeLine(out, "", b.cols, "")
+			continue
+		}
+		n := b.visible[idx]
+		mark := " "
+		if n.marked {
+			mark = "*"
+		}
+		name := strings.Repeat("  ", n.depth) + n.name
+		if n.isDir() {
+			if n.expanded {
+				name = strings.Repeat("  ", n.depth) + "▾ " + n.name + "/"
+			} else {
+				name = strings.Repeat("  ", n.depth) + "▸ " + n.name + "/"
+			}
+		}
+		original, compressed := n.size()
+		ratio := ""
+		if original > 0 {
+			ratio = fmt.Sprintf("%5.1f%%", float64(compressed)*100/float64(original))
+		}
+		line := fmt.Sprintf(" %s %s %10d %10d %6s", mark, padRight(truncateWidth(name, nameWidth), nameWidth), original, compressed, ratio)
+		style := ""
+		if idx == b.cursor {
+			style = "\x1b[7m"
+		}
+		writeLine(out, line, b.cols, style)
+	}
+
+	status := b.message
+	if status == "" {
+		status = i18n.T("↑↓:移動 Enter/→:開く ←:閉じる Space:選択 a:全選択 t:テキスト x:16進 e:選択を抽出 q:終了")
+	}
+	writeStatus(out, status, b.cols)
+}
+
+func (b *browser) drawPreview(out *bytes.Buffer) {
+	rows := max(b.rows-2, 1)
+	kind := i18n.T("テキスト (Shift-JIS)")
+	if b.mode == browseModeHex {
+		kind = i18n.T("16進ダンプ")
+	}
+	original, _ := b.previewNode.size()
+	writeLine(out, i18n.Sprintf("%s  %s  %d バイト", b.previewNode.path, kind, original), b.cols, "\x1b[1m")
+	for i := 0; i < rows; i++ {
+		line := ""
+		if idx := b.previewTop + i; idx < len(b.preview) {
+			line = strings.ReplaceAll(b.preview[idx], "\t", "    ")
+		}
+		writeLine(out, line, b.cols, "")
+	}
+
+	status := b.message
+	if status == "" {
+		status = i18n.Sprintf("%d-%d/%d 行  ↑↓/PgUp/PgDn:スクロール t:テキスト x:16進 q/Esc:戻る",
+			min(b.previewTop+1, len(b.preview)), min(b.previewTop+rows, len(b.preview)), len(b.preview))
+	}
+	writeStatus(out, status, b.cols)
+}
+
+// flatten はノード配下の全エントリを返します
+func (n *browseNode) flatten() []*browseNode {
+	var result []*browseNode
+	for _, c := range n.children {
+		if c.isDir() {
+			result = append(result, c.flatten()...)
+		} else {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// writeLine は1行を端末の幅に切り詰めて書き出し、行末までを消去します
+func writeLine(out *bytes.Buffer, line string, cols int, style string) {
+	out.WriteString(style)
+	out.WriteString(truncateWidth(sanitizeControl(line), cols))
+	out.WriteString("\x1b[K")
+	if style != "" {
+		out.WriteString("\x1b[m")
+	}
+	out.WriteString("\n")
+}
+
+// writeStatus は最下行に反転表示でステータスを書き出します
+// 改行せず、最後の桁にも書き込まないことで画面がスクロールしないようにします
+func writeStatus(out *bytes.Buffer, status string, cols int) {
+	out.WriteString("\x1b[7m")
+	out.WriteString(padRight(truncateWidth(status, cols-1), cols-1))
+	out.WriteString("\x1b[m")
+}
+
+// sanitizeControl は端末の表示を崩す制御文字を '.' に置き換えます
+func sanitizeControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return '.'
+		}
+		return r
+	}, s)
+}
+
+// truncateWidth は表示幅が w を超えないように文字列を切り詰めます
+func truncateWidth(s string, w int) string {
+	n := 0
+	for i, r := range s {
+		rw := 1
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			rw = 2
+		}
+		if n+rw > w {
+			return s[:i]
+		}
+		n += rw
+	}
+	return s
+}
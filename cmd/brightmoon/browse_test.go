@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// openTestArchive はアーカイブを形式の自動判別で開きます
+func openTestArchive(t *testing.T, filename string) pbgarc.PBGArchive {
+	t.Helper()
+	orig := statusOut
+	statusOut = io.Discard
+	defer func() { statusOut = orig }()
+
+	archive, err := openArchive(filename, &archiveOptions{archiveType: -1})
+	if err != nil {
+		t.Fatalf("openArchive() error = %v", err)
+	}
+	t.Cleanup(func() { archive.Close() })
+	return archive
+}
+
+// newTestBrowser は testEntries を格納したアーカイブの browser を作成します
+func newTestBrowser(t *testing.T) *browser {
+	t.Helper()
+	arc := buildTestArchive(t)
+	b := newBrowser(arc, openTestArchive(t, arc), t.TempDir())
+	b.cols, b.rows = 80, 24
+	return b
+}
+
+// visiblePaths は表示されている行のパスを返します
+func visiblePaths(b *browser) []string {
+	var paths []string
+	for _, n := range b.visible {
+		paths = append(paths, n.path)
+	}
+	return paths
+}
+
+func TestBrowser_Tree(t *testing.T) {
+	b := newTestBrowser(t)
+	if b.format != "Remilia" {
+		t.Errorf("format = %q, want Remilia", b.format)
+	}
+	// ディレクトリを先に、それぞれ名前順に並べ、ディレクトリは閉じた状態で表示する
+	if got, want := visiblePaths(b), []string{"dir/", "a.txt", "c.bin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("visible = %q, want %q", got, want)
+	}
+	if original, compressed := b.root.size(); original != 140 || compressed != 164 {
+		t.Errorf("size() = %d, %d, want 140, 164", original, compressed)
+	}
+	if n := len(b.root.flatten()); n != 3 {
+		t.Errorf("flatten() = %d entries, want 3", n)
+	}
+}
+
+func TestBrowser_HandleKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		wantVisible []string
+		wantCursor  string // カーソル位置のパス
+		wantMode    int
+		wantQuit    bool
+	}{
+		{"下へ移動", []string{"j", keyDown}, []string{"dir/", "a.txt", "c.bin"}, "c.bin", browseModeTree, false},
+		{"末尾で止まる", []string{"G", "j", "j"}, []string{"dir/", "a.txt", "c.bin"}, "c.bin", browseModeTree, false},
+		{"先頭で止まる", []string{"k", keyUp}, []string{"dir/", "a.txt", "c.bin"}, "dir/", browseModeTree, false},
+		{"ディレクトリを開く", []string{keyRight}, []string{"dir/", "dir/b.txt", "a.txt", "c.bin"}, "dir/", browseModeTree, false},
+		{"子から親を閉じる", []string{keyEnter, "j", "h"}, []string{"dir/", "a.txt", "c.bin"}, "dir/", browseModeTree, false},
+		{"テキストのプレビュー", []string{"j", "l"}, []string{"dir/", "a.txt", "c.bin"}, "a.txt", browseModeText, false},
+		{"16進ダンプのプレビュー", []string{"G", keyEnter}, []string{"dir/", "a.txt", "c.bin"}, "c.bin", browseModeHex, false},
+		{"プレビューの切り替え", []string{"j", "l", "x"}, []string{"dir/", "a.txt", "c.bin"}, "a.txt", browseModeHex, false},
+		{"プレビューを閉じる", []string{"j", "l", keyEscape}, []string{"dir/", "a.txt", "c.bin"}, "a.txt", browseModeTree, false},
+		{"終了", []string{"q"}, []string{"dir/", "a.txt", "c.bin"}, "dir/", browseModeTree, true},
+		{"プレビュー中の Ctrl+C", []string{"j", "l", keyCtrlC}, []string{"dir/", "a.txt", "c.bin"}, "a.txt", browseModeText, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBrowser(t)
+			quit := false
+			for _, key := range tt.keys {
+				if !b.handleKey(key) {
+					quit = true
+					break
+				}
+			}
+			if quit != tt.wantQuit {
+				t.Errorf("quit = %v, want %v", quit, tt.wantQuit)
+			}
+			if got := visiblePaths(b); !reflect.DeepEqual(got, tt.wantVisible) {
+				t.Errorf("visible = %q, want %q", got, tt.wantVisible)
+			}
+			if got := b.current().path; got != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", got, tt.wantCursor)
+			}
+			if b.mode != tt.wantMode {
+				t.Errorf("mode = %d, want %d", b.mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestBrowser_Preview(t *testing.T) {
+	b := newTestBrowser(t)
+	b.handleKey("j")
+	b.handleKey("t")
+	if want := []string{"alpha"}; !reflect.DeepEqual(b.preview, want) {
+		t.Errorf("text preview = %q, want %q", b.preview, want)
+	}
+	b.handleKey("x")
+	if want := []string{"00000000  61 6c 70 68 61 0a                                 |alpha.|"}; !reflect.DeepEqual(b.preview, want) {
+		t.Errorf("hex preview = %q, want %q", b.preview, want)
+	}
+
+	var out bytes.Buffer
+	b.drawPreview(&out)
+	if !strings.Contains(out.String(), "a.txt  16進ダンプ  6 バイト") {
+		t.Errorf("drawPreview() = %q", out.String())
+	}
+}
+
+func TestBrowser_ExtractMarked(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want map[string]string
+	}{
+		{"選択なし", nil, map[string]string{}},
+		{"ディレクトリを選択", []string{" "}, map[string]string{"dir/b.txt": "bravo\n"}},
+		{"個別に選択", []string{"j", " ", " "}, map[string]string{"a.txt": "alpha\n", "c.bin": string(testEntries[2].data)}},
+		{"全選択", []string{"a"}, map[string]string{"a.txt": "alpha\n", "dir/b.txt": "bravo\n", "c.bin": string(testEntries[2].data)}},
+		{"全選択の解除", []string{"a", "a"}, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBrowser(t)
+			for _, key := range tt.keys {
+				b.handleKey(key)
+			}
+			b.handleKey("e")
+			if got := readTree(t, b.outDir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+			if b.message == "" {
+				t.Error("no status message")
+			}
+		})
+	}
+}
+
+func TestBrowse_NotTerminal(t *testing.T) {
+	arc := buildTestArchive(t)
+	res := runCLI(t, context.Background(), "browse", arc)
+	if res.code != 1 || !strings.Contains(res.stderr, "browse は端末から実行してください") {
+		t.Errorf("exit code = %d, stderr = %q", res.code, res.stderr)
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"通常の文字", "jk", []string{"j", "k"}},
+		{"矢印キー", "\x1b[A\x1b[B", []string{keyUp, keyDown}},
+		{"PageDown", "\x1b[6~q", []string{keyPageDown, "q"}},
+		{"SS3 形式の Home", "\x1bOH", []string{"\x1bOH"}},
+		{"Esc 単体", "\x1b", []string{keyEscape}},
+		{"マルチバイト文字", "あj", []string{"あ", "j"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitKeys([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitKeys(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want string
+	}{
+		{"abcdef", 4, "abcd"},
+		{"abc", 4, "abc"},
+		{"紅魔郷", 4, "紅魔"},
+		{"紅魔郷", 5, "紅魔"},
+		{"a紅", 2, "a"},
+	}
+	for _, tt := range tests {
+		if got := truncateWidth(tt.s, tt.w); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.s, tt.w, got, tt.want)
+		}
+	}
+	if got := sanitizeControl("a\x1b[2Jb\x7f"); got != "a.[2Jb." {
+		t.Errorf("sanitizeControl() = %q", got)
+	}
+}
+
+func TestWriteHexDump(t *testing.T) {
+	var buf bytes.Buffer
+	writeHexDump(&buf, []byte("0123456789abcdef\x00\xff"))
+	want := "00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+		"00000010  00 ff                                             |..|\n"
+	if buf.String() != want {
+		t.Errorf("writeHexDump() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
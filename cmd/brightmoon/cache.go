@@ -0,0 +1,86 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// entryCache は展開済みのエントリを保持する LRU キャッシュ
+// 同じエントリへの要求が同時に届いた場合は、最初の要求の展開結果を共有します
+type entryCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	ll       *list.List // 先頭ほど最近使われたエントリ
+	items    map[string]*list.Element
+	loading  map[string]*cacheLoad
+}
+
+type cacheItem struct {
+	key  string
+	data []byte
+}
+
+// cacheLoad は展開中のエントリ (完了すると done が閉じられます)
+type cacheLoad struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// newEntryCache は合計 maxBytes バイトまで保持するキャッシュを作成します
+// maxBytes が 0 以下の場合はキャッシュしません
+func newEntryCache(maxBytes int64) *entryCache {
+	return &entryCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		loading:  make(map[string]*cacheLoad),
+	}
+}
+
+// get はキャッシュからエントリを返し、なければ load で展開して保存します
+func (c *entryCache) get(key string, load func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*cacheItem).data, nil
+	}
+	if l, ok := c.loading[key]; ok {
+		c.mu.Unlock()
+		<-l.done
+		return l.data, l.err
+	}
+	l := &cacheLoad{done: make(chan struct{})}
+	c.loading[key] = l
+	c.mu.Unlock()
+
+	l.data, l.err = load()
+
+	c.mu.Lock()
+	delete(c.loading, key)
+	if l.err == nil {
+		c.add(key, l.data)
+	}
+	c.mu.Unlock()
+	close(l.done)
+	return l.data, l.err
+}
+
+// add はエントリを保存し、上限を超えた分を古い順に破棄します (c.mu を保持して呼び出します)
+func (c *entryCache) add(key string, data []byte) {
+	n := int64(len(data))
+	if n > c.maxBytes {
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheItem{key: key, data: data})
+	c.size += n
+	for c.size > c.maxBytes {
+		oldest := c.ll.Back()
+		item := oldest.Value.(*cacheItem)
+		c.ll.Remove(oldest)
+		delete(c.items, item.key)
+		c.size -= int64(len(item.data))
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// textExtensions は全体を Shift-JIS のテキストとして変換する拡張子
+var textExtensions = map[string]bool{
+	".txt": true,
+	".csv": true,
+	".ini": true,
+	".def": true,
+	".nut": true,
+}
+
+// minEmbeddedTextLen はバイナリ中から取り出す文字列の最小バイト数
+const minEmbeddedTextLen = 4
+
+// isTextEntry はエントリ全体をテキストとして扱うかを判定します
+func isTextEntry(name string) bool {
+	return textExtensions[strings.ToLower(path.Ext(entryKey(name)))]
+}
+
+// writeShiftJISText は Shift-JIS のテキストを UTF-8 に変換しながら書き出すライターを返します
+// fileutil.FromShiftJIS と同じデコーダを使用します
+func writeShiftJISText(w io.Writer) io.WriteCloser {
+	return transform.NewWriter(w, japanese.ShiftJIS.NewDecoder())
+}
+
+// writeEmbeddedText はバイナリデータ (.msg など) に埋め込まれた Shift-JIS の文字列を
+// strings コマンドのように1行ずつ UTF-8 に変換して書き出します
+func writeEmbeddedText(w io.Writer, data []byte) error {
+	decoder := japanese.ShiftJIS.NewDecoder()
+	flush := func(run []byte) error {
+		if len(run) < minEmbeddedTextLen {
+			return nil
+		}
+		text, err := decoder.Bytes(run)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", text)
+		return err
+	}
+
+	start := 0
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c >= 0x20 && c <= 0x7e, c >= 0xa1 && c <= 0xdf: // ASCII・半角カナ
+			i++
+			continue
+		case (c >= 0x81 && c <= 0x9f || c >= 0xe0 && c <= 0xfc) && i+1 < len(data): // 2バイト文字
+			if t := data[i+1]; t >= 0x40 && t <= 0xfc && t != 0x7f {
+				i += 2
+				continue
+			}
+		}
+		if err := flush(data[start:i]); err != nil {
+			return err
+		}
+		i++
+		start = i
+	}
+	return flush(data[start:])
+}
+
+// catEntry はエントリを展開して w に書き出します
+// utf8 が true の場合、テキストのエントリは全体を、それ以外は埋め込まれた文字列を UTF-8 に変換します
+func catEntry(w io.Writer, entry pbgarc.PBGArchiveEntry, utf8 bool) error {
+	if !utf8 {
+		if !entry.Extract(w, nil, nil) {
+			return i18n.Errorf("抽出に失敗しました: %s", entry.GetEntryName())
+		}
+		return nil
+	}
+
+	if isTextEntry(entry.GetEntryName()) {
+		tw := writeShiftJISText(w)
+		if !entry.Extract(tw, nil, nil) {
+			return i18n.Errorf("抽出に失敗しました: %s", entry.GetEntryName())
+		}
+		return tw.Close()
+	}
+
+	var buf bytes.Buffer
+	if !entry.Extract(&buf, nil, nil) {
+		return i18n.Errorf("抽出に失敗しました: %s", entry.GetEntryName())
+	}
+	return writeEmbeddedText(w, buf.Bytes())
+}
+
+// findEntries はアーカイブから名前に一致するエントリを探します
+// 区切り文字は '/' と '\' のどちらで指定しても一致します
+func findEntries(archive pbgarc.PBGArchive, names []string) map[string]pbgarc.PBGArchiveEntry {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[entryKey(name)] = true
+	}
+
+	found := make(map[string]pbgarc.PBGArchiveEntry)
+	if !archive.EnumFirst() {
+		return found
+	}
+	do := true
+	for do {
+		if key := entryKey(archive.GetEntryName()); wanted[key] {
+			if _, dup := found[key]; !dup {
+				found[key] = archive.GetEntry()
+			}
+		}
+		do = archive.EnumNext()
+	}
+	return found
+}
+
+// setupCat は cat サブコマンドを設定します
+func setupCat(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	opts := &archiveOptions{}
+	opts.register(fs)
+	utf8 := fs.Bool("utf8", false, "convert Shift-JIS text (whole .txt entries, embedded strings in other entries) to UTF-8")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 2); err != nil {
+			return err
+		}
+		// 標準出力はエントリの内容のみにする
+		statusOut = os.Stderr
+
+		archive, err := openArchive(args[0], opts)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		found := findEntries(archive, args[1:])
+		out := bufio.NewWriter(os.Stdout)
+		defer out.Flush()
+
+		var failed bool
+		for _, name := range args[1:] {
+			entry, ok := found[entryKey(name)]
+			if !ok {
+				i18n.Fprintf(os.Stderr, "エントリが見つかりません: %s\n", name)
+				failed = true
+				continue
+			}
+			if err := catEntry(out, entry, *utf8); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				failed = true
+			}
+		}
+		if failed {
+			return errors.New(i18n.T("一部のエントリを出力できませんでした"))
+		}
+		return nil
+	}
+}
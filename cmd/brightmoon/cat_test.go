@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCat(t *testing.T) {
+	sjis := []byte{0x93, 0x8c, 0x95, 0xfb, '\n'} // "東方\n" (Shift-JIS)
+	arc := writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: `dir\b.txt`, data: []byte("bravo\n")},
+		{name: "msg.txt", data: sjis},
+		{name: "msg.dat", data: append([]byte{0, 1, 'a', 'b', 'c', 'd', 0, 'x', 0}, sjis[:4]...)},
+		{name: "bad.txt", data: []byte("broken\n"), corrupt: true},
+	})
+
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		want    string
+		wantErr string
+	}{
+		{"1件", []string{arc, "a.txt"}, 0, "alpha\n", ""},
+		{"区切り文字の違い", []string{arc, "dir/b.txt"}, 0, "bravo\n", ""},
+		{"複数件を順に出力", []string{arc, "dir/b.txt", "a.txt"}, 0, "bravo\nalpha\n", ""},
+		{"変換なし", []string{arc, "msg.txt"}, 0, string(sjis), ""},
+		{"テキストを UTF-8 に変換", []string{"--utf8", arc, "msg.txt"}, 0, "東方\n", ""},
+		{"埋め込まれた文字列を UTF-8 に変換", []string{"--utf8", arc, "msg.dat"}, 0, "abcd\n東方\n", ""},
+		{"見つからないエントリ", []string{arc, "missing.txt", "a.txt"}, 1, "alpha\n", "missing.txt"},
+		{"展開の失敗", []string{arc, "bad.txt"}, 1, "", "bad.txt"},
+		{"引数が足りない", []string{arc}, 2, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, context.Background(), append([]string{"cat"}, tt.args...)...)
+			if res.code != tt.code {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", res.code, tt.code, res.stderr)
+			}
+			if tt.code != 2 && res.stdout != tt.want {
+				t.Errorf("stdout = %q, want %q", res.stdout, tt.want)
+			}
+			if !strings.Contains(res.stderr, tt.wantErr) {
+				t.Errorf("stderr does not contain %q:\n%s", tt.wantErr, res.stderr)
+			}
+		})
+	}
+}
+
+func TestWriteEmbeddedText(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"ASCII", []byte("\x00hello\x00"), "hello\n"},
+		{"短い文字列は出力しない", []byte("\x00abc\x00defg"), "defg\n"},
+		{"Shift-JIS", []byte{0x00, 0x93, 0x8c, 0x95, 0xfb, 0x00}, "東方\n"},
+		{"途中で途切れた2バイト文字", []byte{'a', 'b', 'c', 'd', 0x93}, "abcd\n"},
+		{"空", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := writeEmbeddedText(&sb, tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("writeEmbeddedText() = %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
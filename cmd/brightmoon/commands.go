@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+)
+
+// errUsage は引数が不正な場合のエラー (使用方法は表示済み)
+var errUsage = outcome.Wrap(outcome.KindUsage, errors.New("invalid usage"))
+
+// command はサブコマンドを表します
+type command struct {
+	name    string
+	args    string // 使用方法に表示する引数の書式
+	summary string
+
+	// setup はフラグを登録し、解析後の位置引数を受け取って処理を行う関数を返します
+	setup func(fs *flag.FlagSet) func(ctx context.Context, args []string) error
+}
+
+// commands は利用可能なサブコマンドの一覧 (init で初期化)
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"list", "[オプション] <アーカイブファイル>", "アーカイブ内のファイル一覧を表示します", setupList},
+		{"extract", "[オプション] <アーカイブファイル> [抽出ファイル...]", "アーカイブからファイルを抽出します", setupExtract},
+		{"batch", "[オプション] <ディレクトリ|glob|アーカイブファイル...>", "複数のアーカイブを <出力先>/<ゲーム ID>/ に一括で抽出します", setupBatch},
+		{"info", "[オプション] <アーカイブファイル>", "アーカイブの形式やエントリ数などの情報を表示します", setupInfo},
+		{"identify", "[オプション] <アーカイブファイル...>", "アーカイブの内容から作品・形式 (製品版/体験版) を判定します", setupIdentify},
+		{"crypt-scan", "[オプション] <アーカイブファイル>", "未知の Kanako (THA1) アーカイブの暗号化パラメータを総当たりで探索し、パラメータ表を出力します", setupCryptScan},
+		{"browse", "[オプション] <アーカイブファイル>", "アーカイブの内容を端末上で閲覧・プレビューし、選択したエントリを抽出します", setupBrowse},
+		{"cat", "[オプション] <アーカイブファイル> <エントリ名...>", "指定したエントリの内容を標準出力に書き出します", setupCat},
+		{"export", "[オプション] <アーカイブファイル> [エントリ名...]", "アーカイブの内容を tar または zip 形式で書き出します", setupExport},
+		{"create", "-o <アーカイブファイル> [オプション] <ファイル|ディレクトリ...>", "ファイルから PBG3 形式 (東方紅魔郷) のアーカイブを作成します", setupCreate},
+		{"verify", "[オプション] <アーカイブファイル...>", "アーカイブ内の全エントリを展開して破損がないか検証します", setupVerify},
+		{"diff", "[オプション] <比較元アーカイブ> <比較先アーカイブ>", "2つのアーカイブのエントリの追加・削除・変更を表示します", setupDiff},
+		{"serve", "[オプション] <アーカイブファイル...>", "アーカイブを HTTP (とオプションで WebDAV) で公開し、ブラウザから閲覧・ダウンロードできるようにします", setupServe},
+		{"version", "", "バージョン情報を表示します", setupVersion},
+		{"help", "[サブコマンド]", "サブコマンドの使用方法を表示します", setupHelp},
+	}
+}
+
+// findCommand は名前に一致するサブコマンドを返します
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// newFlagSet はサブコマンド用のフラグセットを作成します
+func (c *command) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		i18n.Fprintf(out, "使用方法: brightmoon %s %s\n\n%s\n", c.name, i18n.T(c.args), i18n.T(c.summary))
+		i18n.Fprintln(out, "\nオプション:")
+		fs.PrintDefaults()
+	}
+	registerLangFlag(fs)
+	registerLogFlags(fs)
+	return fs
+}
+
+// runCommand はサブコマンドを実行し、終了コードを返します
+// 終了コードはエラーの種類で決まります (exitStatus を参照)
+func runCommand(ctx context.Context, cmd *command, args []string) int {
+	fs := cmd.newFlagSet()
+	exec := cmd.setup(fs)
+	loader := registerConfigFlags(cmd.name, fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return outcome.ExitUsage
+	}
+	args, err := applyUserConfig(cmd.name, fs, loader)
+	if err != nil {
+		i18n.PrintError(os.Stderr, err)
+		return outcome.ExitUsage
+	}
+	if err := setupLogger(); err != nil {
+		i18n.PrintError(os.Stderr, err)
+		return outcome.ExitUsage
+	}
+
+	return exitStatus(exec(ctx, args))
+}
+
+// exitStatus はエラーを表示し、エラーの種類に対応する終了コードを返します
+// 使用方法の誤り 2、形式を認識できない 3、一部の失敗 4、読み書きの失敗 5、中断 130、それ以外は 1 です
+func exitStatus(err error) int {
+	switch {
+	case err == nil:
+		return outcome.ExitOK
+	case errors.Is(err, errUsage):
+		// 使用方法は表示済み
+	case errors.Is(err, context.Canceled):
+		i18n.Fprintf(os.Stderr, "\n処理がキャンセルされました\n")
+	default:
+		i18n.PrintError(os.Stderr, err)
+	}
+	return outcome.ExitCode(err)
+}
+
+// requireArgs は位置引数が min 個以上あることを確認し、不足していれば使用方法を表示します
+func requireArgs(fs *flag.FlagSet, args []string, min int) error {
+	if len(args) < min {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// printCommandList はサブコマンドの一覧を表示します
+func printCommandList() {
+	i18n.Println("サブコマンド:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-10s %s\n", cmd.name, i18n.T(cmd.summary))
+	}
+	fmt.Println()
+	i18n.Println("各サブコマンドの詳細は 'brightmoon help <サブコマンド>' を参照してください。")
+}
+
+// setupVersion は version サブコマンドを設定します
+func setupVersion(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		fmt.Printf("brightmoon version %s\n", version)
+		return nil
+	}
+}
+
+// setupHelp は help サブコマンドを設定します
+func setupHelp(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		if len(args) == 0 {
+			i18n.Println("使用方法: brightmoon <サブコマンド> [オプション] <アーカイブファイル>")
+			fmt.Println()
+			printCommandList()
+			return nil
+		}
+		target := findCommand(args[0])
+		if target == nil {
+			return i18n.Errorf("不明なサブコマンドです: %s", args[0])
+		}
+		targetFS := target.newFlagSet()
+		targetFS.SetOutput(os.Stdout)
+		target.setup(targetFS)
+		registerConfigFlags(target.name, targetFS)
+		targetFS.Usage()
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"slices"
+
+	"github.com/shiroemons/go-brightmoon/internal/userconfig"
+)
+
+// legacyCommandName は従来のフラグ形式で実行する場合の設定の適用先の名前
+const legacyCommandName = "brightmoon"
+
+// configBinding は設定のキーとフラグの対応
+type configBinding struct {
+	userconfig.Binding
+	commands []string // 適用するサブコマンド (nil の場合は対応するフラグを持つ全てのサブコマンド)
+}
+
+// configBindings は設定ファイル・環境変数で指定できる brightmoon のフラグ
+// -o と -w はサブコマンドによって意味が異なる (export の出力ファイル、crypt-scan のスロット数) ため、適用先を限定します
+var configBindings = []configBinding{
+	{userconfig.Binding{Key: "out", Flags: []string{"o"}}, []string{"extract", "batch", "browse", legacyCommandName}},
+	{userconfig.Binding{Key: "workers", Flags: []string{"w"}}, []string{"extract", "batch", legacyCommandName}},
+	{userconfig.Binding{Key: "parallel", Flags: []string{"p"}}, nil},
+	{userconfig.Binding{Key: "overwrite", Flags: []string{"overwrite"}}, nil},
+	{userconfig.Binding{Key: "game", Flags: []string{"game"}}, nil},
+	{userconfig.Binding{Key: "archive_format", Flags: []string{"archive-format"}}, nil},
+	{userconfig.Binding{Key: "subtype", Flags: []string{"subtype"}}, nil},
+	{userconfig.Binding{Key: "type", Flags: []string{"t"}}, nil},
+	{userconfig.Binding{Key: "crypt_def", Flags: []string{"crypt-def"}}, nil},
+	{userconfig.Binding{Key: "key_file", Flags: []string{"k"}}, nil},
+	{userconfig.Binding{Key: "name_list", Flags: []string{"n"}}, nil},
+	{userconfig.Binding{Key: "debug", Flags: []string{"d"}}, nil},
+	{userconfig.Binding{Key: "log_level", Flags: []string{"log-level"}}, nil},
+	{userconfig.Binding{Key: "log_format", Flags: []string{"log-format"}}, nil},
+}
+
+// configArchiveCommands は位置引数を省略した場合に設定の archive を使用するサブコマンド
+var configArchiveCommands = map[string]bool{
+	"list": true, "extract": true, "batch": true, "info": true, "identify": true, "crypt-scan": true,
+	"browse": true, "export": true, "verify": true, "serve": true, legacyCommandName: true,
+}
+
+// registerConfigFlags は --config と --profile を登録します (設定を使用しないサブコマンドは nil)
+func registerConfigFlags(name string, fs *flag.FlagSet) *userconfig.Loader {
+	if name == "version" || name == "help" {
+		return nil
+	}
+	loader := &userconfig.Loader{Tool: "brightmoon", EnvPrefix: "BRIGHTMOON_"}
+	loader.RegisterFlags(fs)
+	return loader
+}
+
+// applyUserConfig はコマンドラインで指定されていないフラグに環境変数・設定ファイルの値を適用し、位置引数を返します
+// 優先順位はコマンドラインのフラグ > 環境変数 > 設定ファイルです
+func applyUserConfig(name string, fs *flag.FlagSet, loader *userconfig.Loader) ([]string, error) {
+	if loader == nil {
+		return fs.Args(), nil
+	}
+	file, env, err := loader.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []userconfig.Binding
+	for _, b := range configBindings {
+		if b.commands == nil || slices.Contains(b.commands, name) {
+			bindings = append(bindings, b.Binding)
+		}
+	}
+	if err := userconfig.Apply(fs, bindings, file, env); err != nil {
+		return nil, err
+	}
+
+	args := fs.Args()
+	if len(args) == 0 && configArchiveCommands[name] {
+		if v, ok := userconfig.Lookup("archive", file, env); ok {
+			args = v.Items
+		}
+	}
+	return args, nil
+}
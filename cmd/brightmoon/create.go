@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// createSource はアーカイブに追加するファイルとそのエントリ名
+type createSource struct {
+	path string
+	name string
+}
+
+// setupCreate は create サブコマンドを設定します
+func setupCreate(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	output := fs.String("o", "", "output archive `file` (required; written in the PBG3 format of th06)")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		if *output == "" {
+			fs.Usage()
+			return errUsage
+		}
+		sources, err := collectCreateSources(args, *output)
+		if err != nil {
+			return err
+		}
+		return createArchive(ctx, *output, sources)
+	}
+}
+
+// collectCreateSources は引数のファイル・ディレクトリをアーカイブに追加するファイルの一覧に展開します
+// ディレクトリは再帰的に走査してディレクトリからの相対パス ('/' 区切り) を、ファイルはファイル名をエントリ名にします
+// 作成するアーカイブ自身は追加しません
+func collectCreateSources(inputs []string, output string) ([]createSource, error) {
+	outInfo, _ := os.Stat(output)
+	var sources []createSource
+	seen := make(map[string]string)
+	add := func(path, name string, info fs.FileInfo) error {
+		if outInfo != nil && os.SameFile(info, outInfo) {
+			return nil
+		}
+		if prev, ok := seen[name]; ok {
+			return outcome.Wrap(outcome.KindUsage, i18n.Errorf("エントリ名が重複しています: %s (%s, %s)", name, prev, path))
+		}
+		seen[name] = path
+		sources = append(sources, createSource{path: path, name: name})
+		return nil
+	}
+
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, i18n.Errorf("ファイルにアクセスできません: %w", err)
+		}
+		if !info.IsDir() {
+			if err := add(input, filepath.Base(input), info); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(input, path)
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return add(path, filepath.ToSlash(rel), info)
+		})
+		if err != nil {
+			if outcome.KindOf(err) == outcome.KindUsage {
+				return nil, err
+			}
+			return nil, i18n.Errorf("ディレクトリを走査できません: %w", err)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, outcome.Wrap(outcome.KindUsage, errors.New(i18n.T("アーカイブに追加するファイルがありません")))
+	}
+	return sources, nil
+}
+
+// createArchive は sources を格納した PBG3 形式のアーカイブを output に作成します
+// 同じディレクトリの一時ファイルに書き込み、完了後にリネームするため、失敗・中断しても書きかけのファイルは残りません
+func createArchive(ctx context.Context, output string, sources []createSource) (err error) {
+	tmp, err := createOutputTemp(output)
+	if err != nil {
+		return i18n.Errorf("出力ファイルを作成できません: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	rw, err := pbgarc.NewRemiliaWriter(tmp)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, src := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(src.path)
+		if err != nil {
+			return i18n.Errorf("ファイルを読み込めません: %w", err)
+		}
+		if err := rw.Add(src.name, data); err != nil {
+			return i18n.Errorf("%s を追加できません: %v", src.name, err)
+		}
+		logger.Debug("entry added", pbgarc.LogKeyEntry, src.name, pbgarc.LogKeySize, len(data))
+		total += int64(len(data))
+	}
+	if err := rw.Close(); err != nil {
+		return err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return i18n.Errorf("ファイル書き込み(Close)に失敗しました: %w", err)
+	}
+	if err := os.Rename(tmpPath, output); err != nil {
+		return i18n.Errorf("出力ファイルを作成できません: %v", err)
+	}
+	i18n.Printf("%d 個のファイルから %s を作成しました (%d バイト → %d バイト)\n", len(sources), output, total, info.Size())
+	return nil
+}
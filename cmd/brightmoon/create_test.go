@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	src := t.TempDir()
+	for _, e := range testEntries {
+		path := filepath.Join(src, filepath.FromSlash(e.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, e.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	single := filepath.Join(t.TempDir(), "single.txt")
+	if err := os.WriteFile(single, []byte("single\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		output string // 空の場合は一時ディレクトリの th06.dat
+		inputs []string
+		want   map[string]string
+	}{
+		{
+			name:   "ディレクトリとファイル",
+			inputs: []string{src, single},
+			want: map[string]string{
+				"a.txt":      "alpha\n",
+				"dir/b.txt":  "bravo\n",
+				"c.bin":      string(testEntries[2].data),
+				"single.txt": "single\n",
+			},
+		},
+		{
+			// 作成するアーカイブ自身 (既存のファイルを置き換える) は追加しない
+			name:   "入力ディレクトリ内に作成",
+			output: filepath.Join(src, "th06.dat"),
+			inputs: []string{src},
+			want: map[string]string{
+				"a.txt":     "alpha\n",
+				"dir/b.txt": "bravo\n",
+				"c.bin":     string(testEntries[2].data),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := tt.output
+			if output == "" {
+				output = filepath.Join(t.TempDir(), "th06.dat")
+			} else {
+				if err := os.WriteFile(output, []byte("old archive"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.Remove(output) })
+			}
+			res := runCLI(t, context.Background(), append([]string{"create", "-o", output}, tt.inputs...)...)
+			if res.code != 0 {
+				t.Fatalf("create exit code = %d (stderr: %s)", res.code, res.stderr)
+			}
+
+			if res := runCLI(t, context.Background(), "verify", output); res.code != 0 {
+				t.Errorf("verify exit code = %d (stdout: %s, stderr: %s)", res.code, res.stdout, res.stderr)
+			}
+			out := t.TempDir()
+			if res := runCLI(t, context.Background(), "extract", "-o", out, output); res.code != 0 {
+				t.Fatalf("extract exit code = %d (stderr: %s)", res.code, res.stderr)
+			}
+			got := readTree(t, out)
+			if len(got) != len(tt.want) {
+				t.Errorf("extracted %d files, want %d: %v", len(got), len(tt.want), got)
+			}
+			for name, data := range tt.want {
+				if got[name] != data {
+					t.Errorf("%s = %q, want %q", name, got[name], data)
+				}
+			}
+		})
+	}
+}
+
+func TestCreate_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"x/a.txt", "y/a.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	empty := t.TempDir()
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"出力先の指定がない", []string{filepath.Join(dir, "x")}, 2},
+		{"エントリ名の重複", []string{"-o", filepath.Join(t.TempDir(), "out.dat"), filepath.Join(dir, "x", "a.txt"), filepath.Join(dir, "y", "a.txt")}, 2},
+		{"追加するファイルがない", []string{"-o", filepath.Join(t.TempDir(), "out.dat"), empty}, 2},
+		{"存在しないファイル", []string{"-o", filepath.Join(t.TempDir(), "out.dat"), filepath.Join(dir, "missing.txt")}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, context.Background(), append([]string{"create"}, tt.args...)...)
+			if res.code != tt.code {
+				t.Errorf("exit code = %d, want %d (stderr: %s)", res.code, tt.code, res.stderr)
+			}
+			// 失敗した場合は出力先にファイルを残さない
+			if len(tt.args) > 1 && tt.args[0] == "-o" {
+				if files, _ := os.ReadDir(filepath.Dir(tt.args[1])); len(files) != 0 {
+					t.Errorf("create left files: %v", files)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// loadedCryptDefs は読み込み済みの定義ファイル (絶対パス -> 定義)
+// 複数のアーカイブを処理する場合でも、同じ定義を二重に登録しないようにします
+var loadedCryptDefs = make(map[string]*pbgarc.CryptDefinition)
+
+// loadCryptDefinitions は --crypt-def で指定された定義ファイルを読み込み、pbgarc に登録します
+func loadCryptDefinitions(paths []string) error {
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if _, ok := loadedCryptDefs[abs]; ok {
+			continue
+		}
+		def, err := pbgarc.LoadCryptDefinition(path)
+		if err != nil {
+			return i18n.Errorf("暗号化パラメータの定義を読み込めません: %w", err)
+		}
+		if _, err := pbgarc.RegisterCryptDefinition(def); err != nil {
+			return i18n.Errorf("暗号化パラメータの定義を登録できません (%s): %w", path, err)
+		}
+		loadedCryptDefs[abs] = def
+		logger.Debug("crypt definition registered", "name", def.Name, pbgarc.LogKeyFormat, def.Format,
+			pbgarc.LogKeySubType, def.ArchiveType, "path", path)
+	}
+	return nil
+}
+
+// cryptDefSelection は作品 (ゲームID) に対応する登録済みの定義を検索します
+func cryptDefSelection(gameID string) (*archiveSelection, *pbgarc.CryptDefinition, bool) {
+	if gameID == "" {
+		return nil, nil, false
+	}
+	for _, def := range pbgarc.CryptDefinitions() {
+		for _, g := range def.Games {
+			if strings.EqualFold(g, gameID) {
+				return &archiveSelection{format: archiveFormats[strings.ToLower(def.Format)], subType: def.ArchiveType}, def, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// registeredSubTypeName は --crypt-def で登録した定義のサブタイプ名を返します (組み込みのサブタイプは空文字列)
+func registeredSubTypeName(format string, subType int) string {
+	if subType < len(primarySubTypes[format]) {
+		return ""
+	}
+	return pbgarc.CryptTableName(format, subType)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// setupCryptScan は crypt-scan サブコマンドを設定します
+func setupCryptScan(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	workers := fs.Int("w", 4, "number of slots to search in parallel")
+	maxLimit := fs.Int("max-limit", 0x10000, "upper bound of the limit parameter to search")
+	format := fs.String("format", "text", "output format (text, json, toml); json/toml can be loaded with --crypt-def")
+	name := fs.String("name", "", "definition name for json/toml output (default: archive file name)")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		filename := args[0]
+		if *maxLimit < 0x100 {
+			return i18n.Errorf("--max-limit には 0x100 以上の値を指定してください: %#x", *maxLimit)
+		}
+		if *format != "text" && *format != "json" && *format != "toml" {
+			return i18n.Errorf("不明な出力形式です: %s (text, json, toml のいずれかを指定してください)", *format)
+		}
+
+		i18n.Fprintf(os.Stderr, "%s の暗号化パラメータを探索しています...\n", filename)
+		result, err := pbgarc.DiscoverKanakoCryptParams(ctx, filename, &pbgarc.KanakoDiscoveryOptions{
+			MaxLimit: *maxLimit,
+			Workers:  *workers,
+			Logger:   logger,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return i18n.Errorf("Kanako (THA1) アーカイブとして開けません: %w", err)
+		}
+
+		if *format == "text" {
+			writeCryptScan(os.Stdout, filename, result)
+		} else if err := writeCryptDefinition(os.Stdout, *format, cryptScanDefinition(filename, *name, result)); err != nil {
+			return err
+		}
+		if !result.Complete() {
+			return i18n.Errorf("一部のスロットの暗号化パラメータを特定できませんでした")
+		}
+		return nil
+	}
+}
+
+// writeCryptScan は探索結果とパラメータ表を書き出します
+func writeCryptScan(w io.Writer, filename string, result *pbgarc.KanakoDiscovery) {
+	i18n.Fprintf(w, "ファイル: %s\n", filename)
+	for _, slot := range result.Slots {
+		i18n.Fprintf(w, "スロット %d: ", slot.Index)
+		switch {
+		case slot.Entries == 0:
+			i18n.Fprintln(w, "エントリなし")
+			continue
+		case !slot.Found:
+			i18n.Fprintf(w, "見つかりません (エントリ %d)\n", slot.Entries)
+			continue
+		}
+		fmt.Fprintf(w, "key=%#02x step=%#02x block=%#04x ", slot.Param.Key, slot.Param.Step, slot.Param.Block)
+		switch {
+		case slot.Param.Limit == 0:
+			i18n.Fprintf(w, "limit=不明")
+		case !slot.LimitConfirmed:
+			i18n.Fprintf(w, "limit>=%#04x (未確定)", slot.Param.Limit)
+		default:
+			fmt.Fprintf(w, "limit=%#04x", slot.Param.Limit)
+		}
+		i18n.Fprintf(w, " 得点 %d", slot.Score)
+		if len(slot.Contents) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(slot.Contents, ", "))
+		}
+		i18n.Fprintf(w, " 展開できたエントリ %d/%d\n", slot.Verified, slot.Compressed)
+	}
+
+	fmt.Fprintln(w)
+	if result.MatchedType >= 0 {
+		i18n.Fprintf(w, "既知のパラメータ表 (--format kanako --subtype %s) と一致します。\n", subTypeName("Kanako", result.MatchedType))
+	} else {
+		i18n.Fprintln(w, "既知のパラメータ表とは一致しません。--format json の出力を --crypt-def で読み込むか、以下を pkg/pbgarc/kanako.go に追加してください:")
+	}
+
+	fmt.Fprintln(w)
+	i18n.Fprintf(w, "// %s から推定した暗号化パラメータ\n", filepath.Base(filename))
+	fmt.Fprintln(w, "var kanakoCryprmNew = []KanakoCryptParam{")
+	for i, p := range result.Params() {
+		slot := result.Slots[i]
+		fmt.Fprintf(w, "\t{0x%02x, 0x%02x, 0x%04x, 0x%04x},", p.Key, p.Step, p.Block, p.Limit)
+		switch {
+		case !slot.Found:
+			i18n.Fprintf(w, " // 不明")
+		case slot.Param.Limit == 0:
+			i18n.Fprintf(w, " // limit 不明")
+		case !slot.LimitConfirmed:
+			i18n.Fprintf(w, " // limit 未確定 (この値以上)")
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// cryptScanDefinition は探索結果から --crypt-def で読み込める定義を作成します
+func cryptScanDefinition(filename, name string, result *pbgarc.KanakoDiscovery) *pbgarc.CryptDefinition {
+	base := filepath.Base(filename)
+	if name == "" {
+		name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	def := pbgarc.NewKanakoCryptDefinition(name, result.Params())
+	def.Description = i18n.Sprintf("%s から推定した暗号化パラメータ", base)
+	if game, ok := catalog.FromFileName(filename); ok {
+		def.Games = []string{game.ID}
+	}
+	return def
+}
+
+// writeCryptDefinition は定義を JSON または TOML で書き出します
+func writeCryptDefinition(w io.Writer, format string, def *pbgarc.CryptDefinition) error {
+	if format == "toml" {
+		return toml.NewEncoder(w).Encode(def)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(def)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// archiveDigests はアーカイブの全エントリのハッシュを計算します
+// 展開に失敗したエントリはエラーとして別に返し、ctx がキャンセルされた場合はその時点で ctx のエラーを返します
+func archiveDigests(ctx context.Context, archive pbgarc.PBGArchive) (map[string]entryDigest, map[string]error, error) {
+	digests := make(map[string]entryDigest)
+	failures := make(map[string]error)
+	if !archive.EnumFirst() {
+		return digests, failures, nil
+	}
+	do := true
+	for do {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		entry := archive.GetEntry()
+		key := entryKey(entry.GetEntryName())
+		if d, err := hashEntry(entry); err != nil {
+			failures[key] = err
+		} else {
+			digests[key] = d
+		}
+		do = archive.EnumNext()
+	}
+	return digests, failures, nil
+}
+
+// modifiedEntry は内容が変更されたエントリ
+type modifiedEntry struct {
+	Name      string `json:"name"`
+	OldSize   int64  `json:"old_size"`
+	NewSize   int64  `json:"new_size"`
+	OldSHA256 string `json:"old_sha256"`
+	NewSHA256 string `json:"new_sha256"`
+}
+
+// diffSide は比較対象のアーカイブの情報
+type diffSide struct {
+	Archive string `json:"archive"`
+	Format  string `json:"format"`
+	SubType string `json:"subtype,omitempty"`
+}
+
+// archiveDiff は2つのアーカイブの差分 (JSON 出力の形式)
+type archiveDiff struct {
+	Old       diffSide          `json:"old"`
+	New       diffSide          `json:"new"`
+	Added     []entryDigest     `json:"added"`
+	Removed   []entryDigest     `json:"removed"`
+	Modified  []modifiedEntry   `json:"modified"`
+	Unchanged int               `json:"unchanged"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// hasChanges は差分があるかを返します
+func (d *archiveDiff) hasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Modified) > 0
+}
+
+// diffArchives は2つのアーカイブの全エントリを展開して比較します
+func diffArchives(ctx context.Context, oldArchive, newArchive pbgarc.PBGArchive) (*archiveDiff, error) {
+	oldDigests, oldFailures, err := archiveDigests(ctx, oldArchive)
+	if err != nil {
+		return nil, err
+	}
+	newDigests, newFailures, err := archiveDigests(ctx, newArchive)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &archiveDiff{
+		Added:    []entryDigest{},
+		Removed:  []entryDigest{},
+		Modified: []modifiedEntry{},
+		Errors:   make(map[string]string),
+	}
+	for name, err := range oldFailures {
+		result.Errors["old:"+name] = err.Error()
+	}
+	for name, err := range newFailures {
+		result.Errors["new:"+name] = err.Error()
+	}
+
+	for key, o := range oldDigests {
+		n, ok := newDigests[key]
+		switch {
+		case !ok:
+			if _, failed := newFailures[key]; !failed {
+				result.Removed = append(result.Removed, o)
+			}
+		case o.SHA256 != n.SHA256 || o.Size != n.Size:
+			result.Modified = append(result.Modified, modifiedEntry{
+				Name:      n.Name,
+				OldSize:   o.Size,
+				NewSize:   n.Size,
+				OldSHA256: o.SHA256,
+				NewSHA256: n.SHA256,
+			})
+		default:
+			result.Unchanged++
+		}
+	}
+	for key, n := range newDigests {
+		if _, ok := oldDigests[key]; ok {
+			continue
+		}
+		if _, failed := oldFailures[key]; !failed {
+			result.Added = append(result.Added, n)
+		}
+	}
+
+	sort.Slice(result.Added, func(i, j int) bool { return result.Added[i].Name < result.Added[j].Name })
+	sort.Slice(result.Removed, func(i, j int) bool { return result.Removed[i].Name < result.Removed[j].Name })
+	sort.Slice(result.Modified, func(i, j int) bool { return result.Modified[i].Name < result.Modified[j].Name })
+	return result, nil
+}
+
+// writeDiffText は差分をテキスト形式で書き出します
+func writeDiffText(w io.Writer, d *archiveDiff) {
+	fmt.Fprintf(w, "--- %s (%s)\n", d.Old.Archive, describeSide(d.Old))
+	fmt.Fprintf(w, "+++ %s (%s)\n", d.New.Archive, describeSide(d.New))
+	for _, e := range d.Removed {
+		i18n.Fprintf(w, "- %s (%d バイト)\n", e.Name, e.Size)
+	}
+	for _, e := range d.Added {
+		i18n.Fprintf(w, "+ %s (%d バイト)\n", e.Name, e.Size)
+	}
+	for _, e := range d.Modified {
+		if e.OldSize != e.NewSize {
+			i18n.Fprintf(w, "M %s (%d → %d バイト)\n", e.Name, e.OldSize, e.NewSize)
+		} else {
+			i18n.Fprintf(w, "M %s (%d バイト, 内容のみ変更)\n", e.Name, e.NewSize)
+		}
+	}
+
+	keys := make([]string, 0, len(d.Errors))
+	for k := range d.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "! %s: %s\n", k, d.Errors[k])
+	}
+
+	if !d.hasChanges() {
+		i18n.Fprintln(w, "差分はありません")
+	}
+	i18n.Fprintf(w, "\n追加: %d, 削除: %d, 変更: %d, 変更なし: %d\n", len(d.Added), len(d.Removed), len(d.Modified), d.Unchanged)
+}
+
+// describeSide は形式とサブタイプを表示用の文字列にします
+func describeSide(s diffSide) string {
+	if s.SubType != "" {
+		return s.Format + ", " + s.SubType
+	}
+	return s.Format
+}
+
+// diffSideOptions は片方のアーカイブだけに適用する形式の指定 (--old-game など)
+type diffSideOptions struct {
+	game        string
+	formatName  string
+	subTypeName string
+}
+
+// register は side (old または new) のアーカイブ用のオプションを登録します
+func (s *diffSideOptions) register(fs *flag.FlagSet, side string) {
+	fs.StringVar(&s.game, side+"-game", "", "game `id` or title for the "+side+" archive (overrides --game)")
+	fs.StringVar(&s.formatName, side+"-archive-format", "", "archive `format` for the "+side+" archive (overrides --archive-format)")
+	fs.StringVar(&s.subTypeName, side+"-subtype", "", "archive subtype `name` for --"+side+"-archive-format")
+}
+
+// apply は共通のオプションに片方だけの指定を反映したオプションを返します
+// 指定がない場合は共通のオプションをそのまま返します
+func (s *diffSideOptions) apply(base *archiveOptions) *archiveOptions {
+	if s.game == "" && s.formatName == "" && s.subTypeName == "" {
+		return base
+	}
+	// 共通のオプションで解決済みの形式は引き継がず、片方だけの指定から改めて決定する
+	opts := *base
+	opts.selection, opts.resolved = nil, false
+	if s.game != "" || s.formatName != "" {
+		// 片方だけの形式の指定は、共通の --game / --archive-format / -t を置き換える
+		opts.game, opts.formatName, opts.subTypeName, opts.archiveType = s.game, s.formatName, "", -1
+	}
+	if s.subTypeName != "" {
+		opts.subTypeName = s.subTypeName
+	}
+	return &opts
+}
+
+// errDiffIncomplete は展開できなかったエントリがあり、比較が完全でない場合のエラー
+var errDiffIncomplete = outcome.New(outcome.KindPartial, "diff.incomplete", "展開できなかったエントリがあるため、一部のエントリを比較できませんでした")
+
+// setupDiff は diff サブコマンドを設定します
+// 形式は --game などで両方のアーカイブに、--old-game / --new-game などで片方だけに指定できます
+func setupDiff(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	format := fs.String("format", "text", "output format (text, json)")
+	opts := &archiveOptions{}
+	opts.register(fs)
+	oldOpts, newOpts := &diffSideOptions{}, &diffSideOptions{}
+	oldOpts.register(fs, "old")
+	newOpts.register(fs, "new")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 2); err != nil {
+			return err
+		}
+		if *format != "text" && *format != "json" {
+			return i18n.Errorf("不明な出力形式です: %s (text, json のいずれかを指定してください)", *format)
+		}
+		if *format == "json" {
+			statusOut = os.Stderr
+		}
+
+		oldArchive, err := openArchive(args[0], oldOpts.apply(opts))
+		if err != nil {
+			return err
+		}
+		defer oldArchive.Close()
+		newArchive, err := openArchive(args[1], newOpts.apply(opts))
+		if err != nil {
+			return err
+		}
+		defer newArchive.Close()
+
+		result, err := diffArchives(ctx, oldArchive, newArchive)
+		if err != nil {
+			return err
+		}
+		result.Old.Archive, result.New.Archive = args[0], args[1]
+		result.Old.Format, result.Old.SubType = describeArchive(oldArchive)
+		result.New.Format, result.New.SubType = describeArchive(newArchive)
+
+		if *format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println()
+			writeDiffText(os.Stdout, result)
+		}
+		if len(result.Errors) > 0 {
+			return errDiffIncomplete
+		}
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// diffArchivePair は比較用の旧・新アーカイブを作成します
+// a.txt は変更なし、dir/b.txt は区切り文字 (\\) と内容が変わり、c.bin は削除、d.txt は追加、e.txt はサイズが変わります
+func diffArchivePair(t *testing.T, corruptNew bool) (oldPath, newPath string) {
+	t.Helper()
+	oldPath = writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: "dir/b.txt", data: []byte("bravo\n")},
+		{name: "c.bin", data: []byte{1, 2, 3}},
+		{name: "e.txt", data: []byte("echo\n")},
+	})
+	newPath = writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: `dir\b.txt`, data: []byte("BRAVO\n")},
+		{name: "d.txt", data: []byte("delta\n"), corrupt: corruptNew},
+		{name: "e.txt", data: []byte("echo echo\n")},
+	})
+	return oldPath, newPath
+}
+
+func TestDiff_Text(t *testing.T) {
+	oldPath, newPath := diffArchivePair(t, false)
+	res := runCLI(t, context.Background(), "diff", oldPath, newPath)
+	if res.code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+	}
+	for _, want := range []string{
+		"- c.bin (3 バイト)",
+		"+ d.txt (6 バイト)",
+		`M dir\b.txt (6 バイト, 内容のみ変更)`,
+		"M e.txt (5 → 10 バイト)",
+		"追加: 1, 削除: 1, 変更: 2, 変更なし: 1",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, res.stdout)
+		}
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	oldPath, newPath := diffArchivePair(t, false)
+	res := runCLI(t, context.Background(), "diff", "--format", "json", oldPath, newPath)
+	if res.code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+	}
+
+	var d archiveDiff
+	if err := json.Unmarshal([]byte(res.stdout), &d); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, res.stdout)
+	}
+	names := func(entries []entryDigest) []string {
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.Name)
+		}
+		return result
+	}
+	var modified []string
+	for _, e := range d.Modified {
+		modified = append(modified, entryKey(e.Name))
+	}
+	if got := names(d.Added); !reflect.DeepEqual(got, []string{"d.txt"}) {
+		t.Errorf("added = %q", got)
+	}
+	if got := names(d.Removed); !reflect.DeepEqual(got, []string{"c.bin"}) {
+		t.Errorf("removed = %q", got)
+	}
+	if !reflect.DeepEqual(modified, []string{"dir/b.txt", "e.txt"}) {
+		t.Errorf("modified = %q", modified)
+	}
+	if d.Unchanged != 1 || d.Old.Format != "Remilia" || d.New.Archive != newPath {
+		t.Errorf("diff = %+v", d)
+	}
+}
+
+func TestDiff_ExitCodes(t *testing.T) {
+	oldPath, newPath := diffArchivePair(t, false)
+	_, corruptPath := diffArchivePair(t, true)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"同じアーカイブ", []string{oldPath, oldPath}, 0},
+		{"展開できないエントリ", []string{oldPath, corruptPath}, 4},
+		{"不明な出力形式", []string{"--format", "xml", oldPath, newPath}, 1},
+		{"引数が足りない", []string{oldPath}, 2},
+		{"両方に形式を指定", []string{"--game", "th06", oldPath, newPath}, 0},
+		{"片方のみ形式を指定", []string{"--old-game", "th06", "--new-archive-format", "remilia", oldPath, newPath}, 0},
+		{"片方の指定が共通の指定より優先", []string{"--game", "th08", "--old-game", "th06", "--new-game", "th06", oldPath, newPath}, 0},
+		{"形式が一致しない", []string{"--game", "th08", oldPath, newPath}, 3},
+		{"旧アーカイブのみ形式が一致しない", []string{"--old-game", "th08", oldPath, newPath}, 3},
+		{"新アーカイブのみ形式が一致しない", []string{"--new-game", "th08", oldPath, newPath}, 3},
+		{"不明なゲーム", []string{"--new-game", "th99x", oldPath, newPath}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, context.Background(), append([]string{"diff"}, tt.args...)...)
+			if res.code != tt.code {
+				t.Errorf("exit code = %d, want %d\nstdout: %s\nstderr: %s", res.code, tt.code, res.stdout, res.stderr)
+			}
+		})
+	}
+}
+
+func TestDiff_Cancelled(t *testing.T) {
+	oldPath, newPath := diffArchivePair(t, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res := runCLI(t, ctx, "diff", oldPath, newPath); res.code != 130 {
+		t.Errorf("exit code = %d, want 130", res.code)
+	}
+}
+
+func TestDiffSideOptions_Apply(t *testing.T) {
+	base := &archiveOptions{game: "th08"}
+	if got := (&diffSideOptions{}).apply(base); got != base {
+		t.Error("apply() without overrides should return the common options")
+	}
+	got := (&diffSideOptions{formatName: "remilia"}).apply(base)
+	if got == base || got.game != "" || got.formatName != "remilia" {
+		t.Errorf("apply() = %+v, want only the side-specific format", got)
+	}
+	if base.game != "th08" {
+		t.Error("apply() modified the common options")
+	}
+}
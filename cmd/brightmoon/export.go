@@ -0,0 +1,195 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// exportWriter は tar/zip 形式の書き出し先
+type exportWriter interface {
+	// create はエントリを追加し、データの書き込み先を返します
+	create(name string, size int64) (io.Writer, error)
+	close() error
+}
+
+// tarExportWriter は tar 形式で書き出します
+type tarExportWriter struct {
+	tw      *tar.Writer
+	modTime time.Time
+}
+
+func (t *tarExportWriter) create(name string, size int64) (io.Writer, error) {
+	err := t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  t.modTime,
+	})
+	return t.tw, err
+}
+
+func (t *tarExportWriter) close() error {
+	return t.tw.Close()
+}
+
+// zipExportWriter は zip 形式 (Deflate 圧縮) で書き出します
+type zipExportWriter struct {
+	zw      *zip.Writer
+	modTime time.Time
+}
+
+func (z *zipExportWriter) create(name string, size int64) (io.Writer, error) {
+	return z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: z.modTime,
+	})
+}
+
+func (z *zipExportWriter) close() error {
+	return z.zw.Close()
+}
+
+// newExportWriter は形式に応じた書き出し先を作成します
+func newExportWriter(w io.Writer, format string, modTime time.Time) (exportWriter, error) {
+	switch format {
+	case "tar":
+		return &tarExportWriter{tw: tar.NewWriter(w), modTime: modTime}, nil
+	case "zip":
+		return &zipExportWriter{zw: zip.NewWriter(w), modTime: modTime}, nil
+	default:
+		return nil, i18n.Errorf("不明な出力形式です: %s (tar, zip のいずれかを指定してください)", format)
+	}
+}
+
+// exportArchive はフィルタに一致するエントリを展開しながら tar/zip に書き出します
+// エントリ名は安全な相対パス ('/' 区切り) に変換し、ディレクトリ構造を保持します
+// 書き出し途中で失敗した場合、出力は不完全になるため処理を中断します
+func exportArchive(ew exportWriter, archive pbgarc.PBGArchive, filter *entryFilter, paths *outputPaths) (int, error) {
+	if !archive.EnumFirst() {
+		return 0, errors.New(i18n.T("アーカイブにファイルがありません"))
+	}
+
+	count := 0
+	do := true
+	for do {
+		entryName := archive.GetEntryName()
+		if !filter.match(entryName) {
+			do = archive.EnumNext()
+			continue
+		}
+
+		// 展開先で出力先の外に書き出されないよう、安全でない名前はスキップ
+		name, err := paths.entryName(entryName)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "安全でないエントリ名のためスキップしました: %v\n", err)
+			do = archive.EnumNext()
+			continue
+		}
+
+		size := int64(archive.GetOriginalSize())
+		w, err := ew.create(name, size)
+		if err != nil {
+			return count, i18n.Errorf("%s を追加できません: %v", entryName, err)
+		}
+		dw := newDigestWriter(w)
+		if !archive.Extract(dw, nil, nil) {
+			return count, i18n.Errorf("抽出に失敗しました: %s", entryName)
+		}
+		if dw.n != size {
+			return count, i18n.Errorf("展開後のサイズが一致しません: %s (期待値 %d, 実際 %d)", entryName, size, dw.n)
+		}
+		logger.Debug("entry exported", pbgarc.LogKeyEntry, entryName, pbgarc.LogKeySize, size)
+		count++
+		do = archive.EnumNext()
+	}
+	return count, nil
+}
+
+// setupExport は export サブコマンドを設定します
+func setupExport(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	format := fs.String("format", "tar", "output format (tar, zip)")
+	archiveOpts := &archiveOptions{}
+	archiveOpts.register(fs)
+	output := fs.String("o", "-", "output `file` (\"-\" for standard output)")
+	var includes, excludes stringList
+	fs.Var(&includes, "include", "export only entries matching the glob `pattern` (repeatable)")
+	fs.Var(&excludes, "exclude", "skip entries matching the glob `pattern` (repeatable)")
+	useRegex := fs.Bool("regex", false, "treat --include/--exclude patterns as regular expressions")
+	rawNames := fs.Bool("raw-names", false, "use entry names as-is without sanitization")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		if *format != "tar" && *format != "zip" {
+			return i18n.Errorf("不明な出力形式です: %s (tar, zip のいずれかを指定してください)", *format)
+		}
+		filter, err := newEntryFilter(args[1:], includes, excludes, *useRegex)
+		if err != nil {
+			return err
+		}
+		if *output == "-" {
+			// tar/zip のストリームを壊さないよう、進捗メッセージは標準エラー出力に書き出す
+			statusOut = os.Stderr
+		}
+
+		fileInfo, err := os.Stat(args[0])
+		if err != nil {
+			return err
+		}
+		archive, err := openArchive(args[0], archiveOpts)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		var out io.Writer = os.Stdout
+		var outFile *os.File
+		if *output != "-" {
+			if outFile, err = os.Create(*output); err != nil {
+				return i18n.Errorf("出力ファイルを作成できません: %v", err)
+			}
+			defer outFile.Close()
+			out = outFile
+		}
+		bw := bufio.NewWriter(out)
+
+		// エントリの更新日時はアーカイブファイルの更新日時に揃える
+		ew, err := newExportWriter(bw, *format, fileInfo.ModTime())
+		if err != nil {
+			return err
+		}
+		count, err := exportArchive(ew, archive, filter, newOutputPaths("", *rawNames))
+		if err == nil {
+			err = ew.close()
+		}
+		if err == nil {
+			err = bw.Flush()
+		}
+		if err != nil {
+			if outFile != nil {
+				outFile.Close()
+				os.Remove(*output) // 不完全なファイルは残さない
+			}
+			return err
+		}
+
+		for _, name := range filter.notFound() {
+			i18n.Fprintf(os.Stderr, "警告: 一致するエントリが見つかりませんでした: %s\n", name)
+		}
+		i18n.Fprintf(statusOut, "%d 個のファイルを %s 形式で書き出しました\n", count, *format)
+		return nil
+	}
+}
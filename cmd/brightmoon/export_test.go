@@ -0,0 +1,149 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readTarEntries は tar の全エントリを名前をキーにして読み込みます
+func readTarEntries(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	entries := make(map[string]string)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("invalid tar: %v", err)
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[hdr.Name] = string(body)
+	}
+}
+
+// readZipEntries は zip の全エントリを名前をキーにして読み込みます
+func readZipEntries(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(body)
+	}
+	return entries
+}
+
+func TestExport(t *testing.T) {
+	arc := buildTestArchive(t)
+	all := map[string]string{}
+	for _, e := range testEntries {
+		all[e.name] = string(e.data)
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		args   []string
+		want   map[string]string
+	}{
+		{"tar", "tar", nil, all},
+		{"zip", "zip", nil, all},
+		{"include", "tar", []string{"--include", "*.txt"}, map[string]string{"a.txt": "alpha\n", "dir/b.txt": "bravo\n"}},
+		{"ファイル名の指定", "zip", nil, map[string]string{"c.bin": all["c.bin"]}},
+	}
+
+	for _, tt := range tests {
+		for _, toFile := range []bool{false, true} {
+			name := tt.name + "/標準出力"
+			if toFile {
+				name = tt.name + "/ファイル"
+			}
+			t.Run(name, func(t *testing.T) {
+				args := append([]string{"export", "--format", tt.format}, tt.args...)
+				outPath := filepath.Join(t.TempDir(), "out."+tt.format)
+				if toFile {
+					args = append(args, "-o", outPath)
+				}
+				args = append(args, arc)
+				if tt.name == "ファイル名の指定" {
+					args = append(args, "c.bin")
+				}
+
+				res := runCLI(t, context.Background(), args...)
+				if res.code != 0 {
+					t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+				}
+				data := []byte(res.stdout)
+				if toFile {
+					var err error
+					if data, err = os.ReadFile(outPath); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				// 標準出力に書き出す場合、進捗メッセージは標準エラー出力に分ける
+				if !toFile && !strings.Contains(res.stderr, "個のファイルを "+tt.format+" 形式で書き出しました") {
+					t.Errorf("status message was not written to stderr: %s", res.stderr)
+				}
+
+				var got map[string]string
+				if tt.format == "zip" {
+					got = readZipEntries(t, data)
+				} else {
+					got = readTarEntries(t, data)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("exported %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestExport_Errors(t *testing.T) {
+	arc := writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: "b.txt", data: []byte("bravo\n"), corrupt: true},
+	})
+
+	t.Run("不明な形式", func(t *testing.T) {
+		if res := runCLI(t, context.Background(), "export", "--format", "7z", arc); res.code == 0 {
+			t.Error("exit code = 0, want failure")
+		}
+	})
+
+	t.Run("展開の失敗", func(t *testing.T) {
+		outPath := filepath.Join(t.TempDir(), "out.tar")
+		res := runCLI(t, context.Background(), "export", "-o", outPath, arc)
+		if res.code == 0 {
+			t.Fatal("exit code = 0, want failure")
+		}
+		if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+			t.Errorf("incomplete output was not removed: %v", err)
+		}
+	})
+}
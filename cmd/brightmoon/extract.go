@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// extractOptions は抽出処理のオプション
+type extractOptions struct {
+	outputDir   string
+	parallel    bool
+	workerCount int
+	includes    stringList
+	excludes    stringList
+	useRegex    bool
+	rawNames    bool
+	overwrite   string
+	resume      bool
+	progress    bool
+
+	manifestPath     string
+	manifestJSONPath string
+	reportPath       string
+}
+
+// register は抽出オプションをフラグセットに登録します
+func (o *extractOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.outputDir, "o", ".", "output directory")
+	fs.BoolVar(&o.parallel, "p", false, "use parallel extraction")
+	fs.IntVar(&o.workerCount, "w", 4, "number of worker threads for parallel extraction")
+	fs.Var(&o.includes, "include", "extract only entries matching the glob `pattern` (repeatable)")
+	fs.Var(&o.excludes, "exclude", "skip entries matching the glob `pattern` (repeatable)")
+	fs.BoolVar(&o.useRegex, "regex", false, "treat --include/--exclude patterns as regular expressions")
+	fs.BoolVar(&o.rawNames, "raw-names", false, "use entry names as-is without sanitization (unsafe: names may point outside the output directory)")
+	fs.StringVar(&o.overwrite, "overwrite", overwriteAlways, "policy for existing files: always, never, newer (archive is newer than the file), if-different")
+	fs.BoolVar(&o.resume, "resume", false, "skip entries whose output already exists with the same size (and hash, if the manifest from a previous run exists)")
+	fs.BoolVar(&o.progress, "progress", false, "show a progress bar (entries, bytes, throughput, ETA); prints periodic log lines when stdout is not a terminal")
+	fs.StringVar(&o.manifestPath, "manifest", "", "write a sha256sum-compatible manifest of extracted entries to `file`")
+	fs.StringVar(&o.manifestJSONPath, "manifest-json", "", "write a JSON manifest (with sizes and source archive) of extracted entries to `file`")
+	o.registerReport(fs)
+}
+
+// registerReport は --report を登録します
+func (o *extractOptions) registerReport(fs *flag.FlagSet) {
+	fs.StringVar(&o.reportPath, "report", "", "write a JSON report of per-archive and per-entry outcomes (with error kinds) to `file`")
+}
+
+// hasFilters は --include/--exclude が指定されているかを返します
+func (o *extractOptions) hasFilters() bool {
+	return len(o.includes) > 0 || len(o.excludes) > 0
+}
+
+// setupExtract は extract サブコマンドを設定します
+func setupExtract(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	archiveOpts := &archiveOptions{}
+	archiveOpts.register(fs)
+	extractOpts := &extractOptions{}
+	extractOpts.register(fs)
+
+	return func(ctx context.Context, args []string) (err error) {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		rep := newReport(extractOpts.reportPath, "extract")
+		defer func() { err = finishReport(rep, extractOpts.reportPath, err) }()
+
+		result := rep.AddArchive(args[0])
+		archive, err := openArchive(args[0], archiveOpts)
+		if err != nil {
+			result.Fail(err)
+			return err
+		}
+		defer archive.Close()
+
+		return runExtraction(ctx, args[0], archive, extractOpts, args[1:], result)
+	}
+}
+
+// runExtraction はアーカイブからファイルを抽出し、結果を表示します
+// filesToExtract が空の場合は全ファイルを抽出します
+// ctx がキャンセルされた場合は新しいエントリの抽出を止め、書き込み中のエントリを破棄して
+// それまでの結果を表示したうえで context.Canceled を返します
+// エントリごとの結果は result に記録し、一部のエントリのみ失敗した場合は outcome.KindPartial のエラーを返します
+func runExtraction(ctx context.Context, filename string, archive pbgarc.PBGArchive, opts *extractOptions, filesToExtract []string, result *outcome.ArchiveResult) error {
+	result.SetFormat(describeArchive(archive))
+	filter, err := newEntryFilter(filesToExtract, opts.includes, opts.excludes, opts.useRegex)
+	if err != nil {
+		return outcome.Default(outcome.KindUsage, err)
+	}
+
+	// マニフェストが指定されている場合は抽出しながらハッシュを計算する
+	var m *manifest
+	if opts.manifestPath != "" || opts.manifestJSONPath != "" {
+		m = &manifest{Archive: filename, Entries: []entryDigest{}}
+		m.Format, m.SubType = describeArchive(archive)
+	}
+
+	// 既存ファイルの扱い (上書き方針・再開) を決定
+	policy, err := newWritePolicy(filename, opts)
+	if err != nil {
+		return outcome.Default(outcome.KindUsage, err)
+	}
+
+	if opts.hasFilters() {
+		i18n.Println("指定された条件に一致するファイルを抽出中...")
+	} else if len(filesToExtract) > 0 {
+		i18n.Printf("%d 個の指定されたファイルを抽出中...\n", len(filesToExtract))
+	} else {
+		i18n.Println("アーカイブ内の全ファイルを抽出中...")
+	}
+
+	// 進捗表示のために抽出対象のエントリ数と合計サイズを先に数える
+	var prog *progress
+	if opts.progress {
+		entries, bytes := countTargets(archive, filter)
+		prog = newProgress(os.Stdout, entries, bytes)
+	}
+
+	var count int
+	var notFound []string
+	var extractErr error
+
+	prog.begin()
+	if opts.parallel {
+		// 並列処理で抽出
+		count, notFound, extractErr = extractArchiveParallel(ctx, archive, newOutputPaths(opts.outputDir, opts.rawNames), policy, opts.workerCount, filter, m, prog, result)
+	} else {
+		// 順次処理で抽出
+		count, notFound, extractErr = extractArchiveSequential(ctx, archive, newOutputPaths(opts.outputDir, opts.rawNames), policy, filter, m, prog, result)
+	}
+	prog.finish()
+
+	canceled := errors.Is(extractErr, context.Canceled)
+	if extractErr != nil && !canceled {
+		// エラーメッセージは抽出関数内で表示される想定だが、ここでも表示
+		i18n.Fprintf(os.Stderr, "抽出処理中にエラーが発生しました: %v\n", extractErr)
+	}
+
+	if len(notFound) > 0 {
+		i18n.Fprintf(os.Stderr, "\n警告: 指定されたファイル・パターンのうち、以下に一致するエントリは見つかりませんでした:\n")
+		for _, f := range notFound {
+			fmt.Fprintf(os.Stderr, "- %s\n", f)
+		}
+	}
+
+	skipped := int(policy.skipped.Load())
+	if canceled {
+		i18n.Printf("\n処理を中断しました (書き込み途中のファイルは削除しました)\n")
+		i18n.Printf("  抽出済み: %d 個\n", count)
+		i18n.Printf("  スキップ: %d 個\n", skipped)
+		if prog != nil {
+			i18n.Printf("  未処理:   %d 個\n", prog.totalEntries-prog.entries.Load())
+		}
+	} else if extractErr == nil || count > 0 || skipped > 0 { // エラーがあっても一部成功していれば表示
+		i18n.Printf("\n%d 個のファイルを抽出しました\n", count)
+		if skipped > 0 {
+			i18n.Printf("%d 個のファイルは既存のファイルのためスキップしました\n", skipped)
+		}
+	}
+
+	// マニフェストを保存 (抽出に成功したエントリのみ。中断した場合も --resume で再開できるように保存する)
+	if m != nil {
+		m.sort()
+		if opts.manifestPath != "" {
+			if err := saveManifest(opts.manifestPath, m.writeSHA256Sum); err != nil {
+				return err
+			}
+			i18n.Printf("マニフェストを書き出しました: %s\n", opts.manifestPath)
+		}
+		if opts.manifestJSONPath != "" {
+			if err := saveManifest(opts.manifestJSONPath, m.writeJSON); err != nil {
+				return err
+			}
+			i18n.Printf("マニフェストを書き出しました: %s\n", opts.manifestJSONPath)
+		}
+	}
+	if canceled {
+		result.Fail(extractErr)
+		return extractErr
+	}
+	if extractErr == nil {
+		return nil
+	}
+	// 何も抽出できなかった場合は失敗 (種類は最初のエラーのもの)、一部でも抽出できた場合は部分的な失敗とする
+	if count == 0 && skipped == 0 {
+		err := i18n.Errorf("ファイルを抽出できませんでした: %w", extractErr)
+		result.Fail(err)
+		return err
+	}
+	return outcome.Wrap(outcome.KindPartial, i18n.Errorf("一部のファイルを抽出できませんでした: %w", extractErr))
+}
+
+// 抽出ジョブを表す構造体
+type extractJob struct {
+	entry   pbgarc.PBGArchiveEntry
+	outPath string
+	relName string // マニフェストに記録する相対パス
+}
+
+// 並列抽出処理に使用するコンテキスト
+type extractContext struct {
+	runCtx   context.Context // キャンセルされたら残りのジョブを処理しない
+	archive  pbgarc.PBGArchive
+	outDir   string
+	policy   *writePolicy
+	manifest *manifest // nil の場合はハッシュを計算しない
+	progress *progress // nil の場合は進捗を表示しない
+	result   *outcome.ArchiveResult
+	jobs     chan extractJob
+	results  chan extractResult
+	wg       sync.WaitGroup
+	mu       sync.Mutex // 出力用のミューテックス
+}
+
+// 抽出結果
+type extractResult struct {
+	entry     pbgarc.PBGArchiveEntry
+	entryName string
+	success   bool
+	written   bool  // false の場合は内容が同じため置き換えなかった (if-different)
+	size      int64 // 書き出したバイト数
+	err       error
+}
+
+// 並列処理で抽出を実行
+// runCtx がキャンセルされるとジョブの投入を止め、ワーカーは実行中のエントリを破棄して終了します
+func extractArchiveParallel(runCtx context.Context, archive pbgarc.PBGArchive, paths *outputPaths, policy *writePolicy, numWorkers int, filter *entryFilter, m *manifest, prog *progress, res *outcome.ArchiveResult) (successCount int, notFoundFiles []string, err error) {
+	if numWorkers <= 0 {
+		numWorkers = 4 // デフォルトのワーカー数
+	}
+
+	// 出力ディレクトリを作成
+	if errMkdir := os.MkdirAll(paths.outDir, 0755); errMkdir != nil {
+		err = i18n.Errorf("出力ディレクトリを作成できません: %w", errMkdir)
+		return
+	}
+
+	// 抽出コンテキストを初期化
+	ctx := &extractContext{
+		runCtx:   runCtx,
+		archive:  archive,
+		outDir:   paths.outDir,
+		policy:   policy,
+		manifest: m,
+		progress: prog,
+		result:   res,
+		jobs:     make(chan extractJob, numWorkers*2),
+		results:  make(chan extractResult, numWorkers*2),
+	}
+
+	// ワーカーを起動
+	for i := 0; i < numWorkers; i++ {
+		ctx.wg.Add(1)
+		go extractWorker(ctx)
+	}
+
+	// 結果処理用のgoroutineを起動
+	var resultErr error
+	resultDone := make(chan struct{})
+	go func() {
+		for result := range ctx.results {
+			if errors.Is(result.err, context.Canceled) {
+				continue // 中断されたエントリはエラーとして扱わない
+			}
+			prog.entryDone(result.entry, false)
+			if result.success {
+				if result.written {
+					successCount++
+					res.AddEntry(result.entryName, outcome.StatusOK, result.size, nil)
+				} else {
+					res.AddEntry(result.entryName, outcome.StatusSkipped, result.size, nil)
+				}
+				logger.Debug("entry written", pbgarc.LogKeyEntry, result.entryName)
+			} else {
+				ctx.mu.Lock()
+				i18n.Fprintf(os.Stderr, "抽出に失敗しました: %s - %v\n", result.entryName, result.err)
+				ctx.mu.Unlock()
+				res.AddEntry(result.entryName, outcome.StatusFailed, 0, result.err)
+				if resultErr == nil { // 最初のエラーを保持
+					resultErr = i18n.Errorf("抽出エラー: %s (%w)", result.entryName, result.err)
+				}
+			}
+		}
+		close(resultDone)
+	}()
+
+	// 全ファイルを列挙してジョブを投入
+	if !archive.EnumFirst() {
+		close(ctx.jobs)
+		ctx.wg.Wait()
+		close(ctx.results)
+		<-resultDone
+		err = outcome.Wrap(outcome.KindFormat, i18n.Errorf("アーカイブにファイルがありません"))
+		return
+	}
+
+	var pathErr error // エントリ名の検証エラー (結果処理 goroutine とは別に保持)
+	do := true
+	for do && runCtx.Err() == nil {
+		entryName := archive.GetEntryName()
+
+		// 抽出対象かチェック
+		if !filter.match(entryName) {
+			do = archive.EnumNext()
+			continue // スキップ
+		}
+
+		// 出力先のパスを決定 (安全でない名前はスキップ)
+		outPath, relName, errPath := paths.resolve(entryName)
+		if errPath != nil {
+			ctx.mu.Lock()
+			i18n.Fprintf(os.Stderr, "安全でないエントリ名のためスキップしました: %v\n", errPath)
+			ctx.mu.Unlock()
+			res.AddEntry(entryName, outcome.StatusFailed, 0, outcome.Wrap(outcome.KindFormat, errPath))
+			if pathErr == nil {
+				pathErr = outcome.Wrap(outcome.KindFormat, i18n.Errorf("安全でないエントリ名: %s", entryName))
+			}
+			prog.entryDone(archive.GetEntry(), false)
+			do = archive.EnumNext()
+			continue
+		}
+
+		// ディレクトリを作成 (エラーは無視しない方が良い)
+		if dir := filepath.Dir(outPath); dir != "." {
+			if errMkdir := os.MkdirAll(dir, 0755); errMkdir != nil {
+				ctx.mu.Lock()
+				i18n.Fprintf(os.Stderr, "ディレクトリを作成できません %s: %v\n", dir, errMkdir)
+				ctx.mu.Unlock()
+				// ここでエラーをresultErrに設定することも検討
+			}
+		}
+
+		// 既存のファイルを確認 (上書き方針・再開)
+		entry := archive.GetEntry()
+		skip, errSkip := policy.skip(outPath, relName, entry, m)
+		if errSkip != nil {
+			ctx.mu.Lock()
+			i18n.Fprintf(os.Stderr, "既存のファイルを確認できません %s: %v\n", outPath, errSkip)
+			ctx.mu.Unlock()
+		}
+		if skip {
+			logger.Debug("entry skipped", pbgarc.LogKeyEntry, entryName, "path", outPath)
+			res.AddEntry(entryName, outcome.StatusSkipped, 0, nil)
+			prog.entryDone(entry, true)
+			do = archive.EnumNext()
+			continue
+		}
+
+		// ジョブをキューに追加 (キューが一杯の間にキャンセルされた場合は投入を止める)
+		select {
+		case ctx.jobs <- extractJob{entry: entry, outPath: outPath, relName: relName}:
+		case <-runCtx.Done():
+		}
+
+		do = archive.EnumNext()
+	}
+
+	// 全てのジョブが投入されたらチャネルを閉じる
+	close(ctx.jobs)
+
+	// 全てのワーカーが終了するのを待つ
+	ctx.wg.Wait()
+	close(ctx.results)
+
+	// 結果処理goroutineの終了を待つ
+	<-resultDone
+
+	// 指定されたファイル・パターンのうち一致しなかったものをリストアップ
+	notFoundFiles = filter.notFound()
+
+	err = resultErr // 抽出中の最初のエラーを設定
+	if err == nil {
+		err = pathErr
+	}
+	if runCtx.Err() != nil {
+		err = runCtx.Err()
+	}
+	return
+}
+
+// 抽出ワーカー
+func extractWorker(ctx *extractContext) {
+	defer ctx.wg.Done()
+
+	for job := range ctx.jobs {
+		// キャンセル後に残っているジョブは処理しない
+		if err := ctx.runCtx.Err(); err != nil {
+			ctx.results <- extractResult{entry: job.entry, entryName: job.entry.GetEntryName(), err: err}
+			continue
+		}
+
+		// 一時ファイルに抽出してからリネーム (書き込みと同時にハッシュを計算)
+		d, written, err := ctx.policy.writeEntryFile(ctx.runCtx, job.entry, job.outPath, job.relName, nil, ctx.progress)
+		if err != nil {
+			ctx.results <- extractResult{
+				entry:     job.entry,
+				entryName: job.entry.GetEntryName(),
+				success:   false,
+				err:       err,
+			}
+			continue
+		}
+
+		ctx.manifest.add(d)
+		ctx.results <- extractResult{
+			entry:     job.entry,
+			entryName: job.entry.GetEntryName(),
+			success:   true,
+			written:   written,
+			size:      d.Size,
+		}
+	}
+}
+
+// 並列処理なしでアーカイブを抽出（既存のコードを移植）
+// 進捗を表示する場合は1エントリごとのメッセージを表示しません
+func extractArchiveSequential(runCtx context.Context, archive pbgarc.PBGArchive, paths *outputPaths, policy *writePolicy, filter *entryFilter, m *manifest, prog *progress, res *outcome.ArchiveResult) (successCount int, notFoundFiles []string, err error) {
+	// 出力ディレクトリを作成
+	if errMkdir := os.MkdirAll(paths.outDir, 0755); errMkdir != nil {
+		err = i18n.Errorf("出力ディレクトリを作成できません: %w", errMkdir)
+		return
+	}
+
+	if !archive.EnumFirst() {
+		err = outcome.Wrap(outcome.KindFormat, i18n.Errorf("アーカイブにファイルがありません"))
+		return
+	}
+
+	cb := callback
+	if prog != nil {
+		cb = nil
+	}
+
+	var firstError error
+	do := true
+	for do {
+		// 中断された場合は次のエントリに進まない
+		if errCtx := runCtx.Err(); errCtx != nil {
+			firstError = errCtx
+			break
+		}
+
+		entryName := archive.GetEntryName()
+
+		// 抽出対象かチェック
+		if !filter.match(entryName) {
+			do = archive.EnumNext()
+			continue // スキップ
+		}
+
+		// 出力先のパスを決定 (安全でない名前はスキップ)
+		outPath, relName, errPath := paths.resolve(entryName)
+		if errPath != nil {
+			i18n.Fprintf(os.Stderr, "安全でないエントリ名のためスキップしました: %v\n", errPath)
+			res.AddEntry(entryName, outcome.StatusFailed, 0, outcome.Wrap(outcome.KindFormat, errPath))
+			if firstError == nil {
+				firstError = outcome.Wrap(outcome.KindFormat, i18n.Errorf("安全でないエントリ名: %s", entryName))
+			}
+			prog.entryDone(archive.GetEntry(), false)
+			do = archive.EnumNext()
+			continue
+		}
+
+		// ディレクトリを作成
+		if dir := filepath.Dir(outPath); dir != "." {
+			if errMkdir := os.MkdirAll(dir, 0755); errMkdir != nil {
+				i18n.Fprintf(os.Stderr, "ディレクトリを作成できません %s: %v\n", dir, errMkdir)
+				// エラーがあっても続行するが、最初のエラーは記録しておく
+				if firstError == nil {
+					firstError = i18n.Errorf("ディレクトリ作成エラー: %s: %w", dir, errMkdir)
+				}
+			}
+		}
+
+		// 既存のファイルを確認 (上書き方針・再開)
+		entry := archive.GetEntry()
+		skip, errSkip := policy.skip(outPath, relName, entry, m)
+		if errSkip != nil {
+			i18n.Fprintf(os.Stderr, "既存のファイルを確認できません %s: %v\n", outPath, errSkip)
+		}
+		if skip {
+			if prog == nil {
+				i18n.Printf("%s skipped (既存のファイル)\n", entryName)
+			}
+			res.AddEntry(entryName, outcome.StatusSkipped, 0, nil)
+			prog.entryDone(entry, true)
+			do = archive.EnumNext()
+			continue
+		}
+
+		// 一時ファイルに抽出してからリネーム (書き込みと同時にハッシュを計算)
+		d, written, errWrite := policy.writeEntryFile(runCtx, entry, outPath, relName, cb, prog)
+		if errors.Is(errWrite, context.Canceled) {
+			if cb != nil {
+				fmt.Println()
+			}
+			firstError = errWrite
+			break
+		}
+		prog.entryDone(entry, false)
+		if errWrite != nil {
+			i18n.Fprintf(os.Stderr, "抽出に失敗しました: %s - %v\n", entryName, errWrite)
+			res.AddEntry(entryName, outcome.StatusFailed, 0, errWrite)
+			if firstError == nil {
+				firstError = i18n.Errorf("抽出失敗: %s (%w)", entryName, errWrite)
+			}
+		} else {
+			m.add(d)
+			if written {
+				successCount++
+				res.AddEntry(entryName, outcome.StatusOK, d.Size, nil)
+			} else {
+				res.AddEntry(entryName, outcome.StatusSkipped, d.Size, nil)
+			}
+		}
+
+		do = archive.EnumNext()
+	}
+
+	// 指定されたファイル・パターンのうち一致しなかったものをリストアップ
+	notFoundFiles = filter.notFound()
+
+	err = firstError // 処理中の最初のエラーを設定
+	return
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+// stringList は複数回指定できる文字列フラグ
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// entryPattern は --include/--exclude で指定されたパターン
+type entryPattern struct {
+	source  string
+	re      *regexp.Regexp // 正規表現モードの場合のみ設定
+	matched bool
+}
+
+// match はエントリ名がパターンに一致するかを判定します
+// glob パターンに '/' が含まれない場合はファイル名部分にも照合します (例: *.anm)
+func (p *entryPattern) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	if ok, _ := path.Match(p.source, name); ok {
+		return true
+	}
+	if !strings.Contains(p.source, "/") {
+		ok, _ := path.Match(p.source, path.Base(name))
+		return ok
+	}
+	return false
+}
+
+// entryFilter は抽出対象のエントリを選択するフィルタ
+// 完全一致のファイル名と --include のいずれかに一致し、--exclude のいずれにも一致しないエントリが対象になります
+// ファイル名も --include も指定されていない場合は --exclude 以外の全エントリが対象です
+type entryFilter struct {
+	names    []string
+	found    map[string]bool
+	includes []*entryPattern
+	excludes []*entryPattern
+}
+
+// newEntryFilter はフィルタを作成します
+// useRegex が true の場合、includes/excludes を正規表現として解釈します
+func newEntryFilter(names, includes, excludes []string, useRegex bool) (*entryFilter, error) {
+	f := &entryFilter{
+		names: names,
+		found: make(map[string]bool),
+	}
+	var err error
+	if f.includes, err = compilePatterns(includes, useRegex); err != nil {
+		return nil, err
+	}
+	if f.excludes, err = compilePatterns(excludes, useRegex); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func compilePatterns(sources []string, useRegex bool) ([]*entryPattern, error) {
+	patterns := make([]*entryPattern, 0, len(sources))
+	for _, src := range sources {
+		p := &entryPattern{source: src}
+		if useRegex {
+			re, err := regexp.Compile(src)
+			if err != nil {
+				return nil, i18n.Errorf("正規表現が不正です %q: %v", src, err)
+			}
+			p.re = re
+		} else if _, err := path.Match(src, ""); err != nil {
+			return nil, i18n.Errorf("glob パターンが不正です %q: %v", src, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// selective は抽出対象が絞り込まれているかを返します
+func (f *entryFilter) selective() bool {
+	return len(f.names) > 0 || len(f.includes) > 0 || len(f.excludes) > 0
+}
+
+// match はエントリを抽出対象とするかを判定し、一致したファイル名・パターンを記録します
+// 並列抽出でもエントリの列挙は単一の goroutine で行うため、ロックは不要です
+func (f *entryFilter) match(entryName string) bool {
+	name := strings.ReplaceAll(entryName, "\\", "/")
+
+	selected := len(f.names) == 0 && len(f.includes) == 0
+	for _, n := range f.names {
+		if n == entryName {
+			f.found[n] = true
+			selected = true
+		}
+	}
+	for _, p := range f.includes {
+		if p.match(name) {
+			p.matched = true
+			selected = true
+		}
+	}
+	if !selected {
+		return false
+	}
+
+	for _, p := range f.excludes {
+		if p.match(name) {
+			p.matched = true
+			return false
+		}
+	}
+	return true
+}
+
+// notFound はどのエントリにも一致しなかったファイル名と --include パターンを返します
+func (f *entryFilter) notFound() []string {
+	var result []string
+	for _, n := range f.names {
+		if !f.found[n] {
+			result = append(result, n)
+		}
+	}
+	for _, p := range f.includes {
+		if !p.matched {
+			result = append(result, "--include "+p.source)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEntryFilter_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		includes []string
+		excludes []string
+		useRegex bool
+		entry    string
+		want     bool
+	}{
+		{"指定なし", nil, nil, nil, false, "a.txt", true},
+		{"ファイル名の完全一致", []string{"a.txt"}, nil, nil, false, "a.txt", true},
+		{"ファイル名の不一致", []string{"a.txt"}, nil, nil, false, "b.txt", false},
+		{"glob", nil, []string{"*.txt"}, nil, false, "a.txt", true},
+		{"glob はファイル名部分にも照合", nil, []string{"*.txt"}, nil, false, "dir/b.txt", true},
+		{"区切り文字を含む glob", nil, []string{"dir/*"}, nil, false, "dir/b.txt", true},
+		{"区切り文字を含む glob の不一致", nil, []string{"dir/*"}, nil, false, "a.txt", false},
+		{"バックスラッシュ区切り", nil, []string{"dir/*"}, nil, false, `dir\b.txt`, true},
+		{"exclude", nil, nil, []string{"*.bin"}, false, "c.bin", false},
+		{"exclude 以外", nil, nil, []string{"*.bin"}, false, "a.txt", true},
+		{"exclude は include より優先", nil, []string{"*"}, []string{"a.*"}, false, "a.txt", false},
+		{"正規表現", nil, []string{`^dir/`}, nil, true, "dir/b.txt", true},
+		{"正規表現の不一致", nil, []string{`^dir/`}, nil, true, "a.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newEntryFilter(tt.names, tt.includes, tt.excludes, tt.useRegex)
+			if err != nil {
+				t.Fatalf("newEntryFilter() error = %v", err)
+			}
+			if got := f.match(tt.entry); got != tt.want {
+				t.Errorf("match(%q) = %v, want %v", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryFilter_InvalidPattern(t *testing.T) {
+	if _, err := newEntryFilter(nil, []string{"["}, nil, false); err == nil {
+		t.Error("newEntryFilter() with invalid glob returned no error")
+	}
+	if _, err := newEntryFilter(nil, nil, []string{"("}, true); err == nil {
+		t.Error("newEntryFilter() with invalid regexp returned no error")
+	}
+}
+
+func TestEntryFilter_NotFound(t *testing.T) {
+	f, err := newEntryFilter([]string{"a.txt", "missing.txt"}, []string{"*.bin", "*.wav"}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "c.bin"} {
+		f.match(name)
+	}
+	want := []string{"missing.txt", "--include *.wav"}
+	if got := f.notFound(); !reflect.DeepEqual(got, want) {
+		t.Errorf("notFound() = %q, want %q", got, want)
+	}
+}
+
+func TestExtract_Filter(t *testing.T) {
+	arc := buildTestArchive(t)
+
+	tests := []struct {
+		name  string
+		flags []string
+		names []string
+		want  []string
+		code  int
+	}{
+		{"全エントリ", nil, nil, []string{"a.txt", "c.bin", "dir/b.txt"}, 0},
+		{"include", []string{"--include", "*.txt"}, nil, []string{"a.txt", "dir/b.txt"}, 0},
+		{"exclude", []string{"--exclude", "dir/*"}, nil, []string{"a.txt", "c.bin"}, 0},
+		{"regex", []string{"--regex", "--include", `\.bin$`}, nil, []string{"c.bin"}, 0},
+		{"ファイル名の指定", nil, []string{"a.txt"}, []string{"a.txt"}, 0},
+		{"不正な glob", []string{"--include", "["}, nil, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := t.TempDir()
+			args := append([]string{"extract", "-o", out}, tt.flags...)
+			args = append(append(args, arc), tt.names...)
+			res := runCLI(t, context.Background(), args...)
+			if res.code != tt.code {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", res.code, tt.code, res.stderr)
+			}
+			var got []string
+			for name := range readTree(t, out) {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// archiveFormats は --format で指定できるアーカイブ形式 (小文字の名前 -> 表記)
+var archiveFormats = map[string]string{
+	"remilia":  "Remilia",
+	"yukari":   "Yukari",
+	"yumemi":   "Yumemi",
+	"suica":    "Suica",
+	"hinanawi": "Hinanawi",
+	"marisa":   "Marisa",
+	"kaguya":   "Kaguya",
+	"kanako":   "Kanako",
+	"kokoro":   "Kokoro",
+}
+
+// archiveSubTypes は形式ごとのサブタイプ名と SetArchiveType に渡す値
+// 同じ値を持つ名前は別名 (例: Kanako の mof と sa)
+var archiveSubTypes = map[string]map[string]int{
+	"Kaguya": {
+		"in":     catalog.KaguyaIN,     // 東方永夜抄 (TH08)
+		"legacy": catalog.KaguyaLegacy, // -t 1 の旧番号 (東方花映塚と同じ表)
+		"pofv":   catalog.KaguyaPoFV,   // 東方花映塚 (TH09)
+	},
+	"Kanako": {
+		"mof": catalog.KanakoMoF, // 東方文花帖 (TH09.5) / 東方風神録 (TH10) / 東方地霊殿 (TH11)
+		"sa":  catalog.KanakoMoF,
+		"ufo": catalog.KanakoUFO, // 東方星蓮船 (TH12) / ダブルスポイラー (TH12.5) / 妖精大戦争 (TH12.8)
+		"ds":  catalog.KanakoUFO,
+		"fw":  catalog.KanakoUFO,
+		"td":  catalog.KanakoTD, // 東方神霊廟 (TH13) 以降
+	},
+}
+
+// primarySubTypes は形式ごとのサブタイプの表示名 (SetArchiveType に渡す値の順)
+var primarySubTypes = map[string][]string{
+	"Kaguya": {"in", "legacy", "pofv"},
+	"Kanako": {"mof", "ufo", "td"},
+}
+
+// subTypeName はサブタイプの値から表示名を返します (サブタイプのない形式は空文字列)
+// --crypt-def で登録した定義は定義名を返します
+func subTypeName(format string, subType int) string {
+	names := primarySubTypes[format]
+	if subType < 0 {
+		return ""
+	}
+	if subType >= len(names) {
+		return registeredSubTypeName(format, subType)
+	}
+	return names[subType]
+}
+
+// archiveSelection は明示的に指定されたアーカイブ形式とサブタイプ
+type archiveSelection struct {
+	format  string // describeArchive と同じ表記 (例: Kaguya)
+	subType int    // サブタイプ (-1 の場合は指定なし)
+}
+
+// gameIDs はゲームIDの一覧をカンマ区切りで返します (エラーメッセージ用)
+func gameIDs() string {
+	games := catalog.Games()
+	ids := make([]string, len(games))
+	for i, g := range games {
+		ids[i] = g.ID
+	}
+	return strings.Join(ids, ", ")
+}
+
+// subTypeNames は形式のサブタイプ名の一覧を値の順に返します (エラーメッセージ用)
+func subTypeNames(format string) string {
+	names := make([]string, 0, len(archiveSubTypes[format]))
+	for name := range archiveSubTypes[format] {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := archiveSubTypes[format][names[i]], archiveSubTypes[format][names[j]]
+		if vi != vj {
+			return vi < vj
+		}
+		return names[i] < names[j]
+	})
+	// --crypt-def で登録した定義名
+	for _, def := range pbgarc.CryptDefinitions() {
+		if strings.EqualFold(def.Format, format) {
+			names = append(names, strings.ToLower(def.Name))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// resolveFormat は形式名とサブタイプ名からアーカイブの選択を作成します
+func resolveFormat(formatName, subTypeName string) (*archiveSelection, error) {
+	format, ok := archiveFormats[strings.ToLower(formatName)]
+	if !ok {
+		return nil, i18n.Errorf("不明なアーカイブ形式です: %s (remilia, yukari, yumemi, suica, hinanawi, marisa, kaguya, kanako, kokoro のいずれかを指定してください)", formatName)
+	}
+	sel := &archiveSelection{format: format, subType: -1}
+	subTypes, hasSubTypes := archiveSubTypes[format]
+	switch {
+	case subTypeName == "" && hasSubTypes:
+		return nil, i18n.Errorf("%s 形式には --subtype の指定が必要です (%s)", format, subTypeNames(format))
+	case subTypeName == "":
+	case !hasSubTypes:
+		return nil, i18n.Errorf("%s 形式にはサブタイプがありません", format)
+	default:
+		v, ok := subTypes[strings.ToLower(subTypeName)]
+		if !ok {
+			v, ok = pbgarc.LookupCryptTable(format, subTypeName)
+		}
+		if !ok {
+			return nil, i18n.Errorf("%s 形式の不明なサブタイプです: %s (%s のいずれかを指定してください)", format, subTypeName, subTypeNames(format))
+		}
+		sel.subType = v
+	}
+	return sel, nil
+}
+
+// resolveGame はゲームIDまたはタイトルからアーカイブの選択を作成します
+// --crypt-def の定義が作品を対象としている場合は、カタログよりも定義を優先します
+func resolveGame(name string) (*archiveSelection, error) {
+	g, ok := catalog.Lookup(name)
+	id := name
+	if ok {
+		id = g.ID
+	}
+	if sel, _, found := cryptDefSelection(id); found {
+		return sel, nil
+	}
+	if !ok {
+		return nil, i18n.Errorf("不明なゲームです: %s (%s のいずれか、または東方花映塚などのタイトルを指定してください)", name, gameIDs())
+	}
+	return &archiveSelection{format: string(g.Format), subType: g.SubType}, nil
+}
+
+// legacyTypeSelection は非推奨の数値タイプ (-t) を従来と同じ解釈で形式に変換し、移行先のオプションを返します
+// 0 と 1 は Kaguya、2 は Kanako として扱われます (1 は花映塚と同じ表の旧番号。Kanako の 0/1 は指定できません)
+func legacyTypeSelection(archiveType int) (sel *archiveSelection, replacement string, err error) {
+	switch archiveType {
+	case 0:
+		return &archiveSelection{format: "Kaguya", subType: 0}, i18n.T("--game th08 (または --format kaguya --subtype in)"), nil
+	case 1:
+		return &archiveSelection{format: "Kaguya", subType: catalog.KaguyaLegacy}, i18n.T("--game th09 (または --format kaguya --subtype legacy)"), nil
+	case 2:
+		return &archiveSelection{format: "Kanako", subType: 2}, i18n.T("--game th13 など (または --format kanako --subtype td)"), nil
+	default:
+		return nil, "", i18n.Errorf("指定されたアーカイブタイプ %d は不明か、タイプ指定不要な形式です (--game または --format を使用してください)", archiveType)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+)
+
+func TestResolveGame(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantFormat  string
+		wantSubType int
+		wantErr     bool
+	}{
+		{"th06", "Remilia", -1, false},
+		{"TH08", "Kaguya", catalog.KaguyaIN, false},
+		{"東方花映塚", "Kaguya", catalog.KaguyaPoFV, false},
+		{"th075", "Suica", -1, false},
+		{"th13", "Kanako", catalog.KanakoTD, false},
+		{"th99x", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := resolveGame(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveGame(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if err == nil && (sel.format != tt.wantFormat || sel.subType != tt.wantSubType) {
+				t.Errorf("resolveGame(%q) = %s %d, want %s %d", tt.name, sel.format, sel.subType, tt.wantFormat, tt.wantSubType)
+			}
+		})
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		format, subType string
+		wantFormat      string
+		wantSubType     int
+		wantErr         bool
+	}{
+		{"remilia", "", "Remilia", -1, false},
+		{"KAGUYA", "pofv", "Kaguya", catalog.KaguyaPoFV, false},
+		{"kaguya", "legacy", "Kaguya", catalog.KaguyaLegacy, false},
+		{"kanako", "ds", "Kanako", catalog.KanakoUFO, false},
+		{"kaguya", "", "", 0, true},       // サブタイプが必要
+		{"remilia", "in", "", 0, true},    // サブタイプがない形式
+		{"kanako", "legacy", "", 0, true}, // 不明なサブタイプ
+		{"pbg5", "", "", 0, true},         // 不明な形式
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.subType, func(t *testing.T) {
+			sel, err := resolveFormat(tt.format, tt.subType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (sel.format != tt.wantFormat || sel.subType != tt.wantSubType) {
+				t.Errorf("resolveFormat() = %s %d, want %s %d", sel.format, sel.subType, tt.wantFormat, tt.wantSubType)
+			}
+		})
+	}
+}
+
+func TestLegacyTypeSelection(t *testing.T) {
+	tests := []struct {
+		archiveType     int
+		wantFormat      string
+		wantSubType     int
+		wantReplacement string
+		wantErr         bool
+	}{
+		{0, "Kaguya", catalog.KaguyaIN, "--game th08", false},
+		{1, "Kaguya", catalog.KaguyaLegacy, "--subtype legacy", false},
+		{2, "Kanako", catalog.KanakoTD, "--subtype td", false},
+		{3, "", 0, "", true},
+	}
+	for _, tt := range tests {
+		sel, replacement, err := legacyTypeSelection(tt.archiveType)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("legacyTypeSelection(%d) error = %v, wantErr %v", tt.archiveType, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if sel.format != tt.wantFormat || sel.subType != tt.wantSubType || !strings.Contains(replacement, tt.wantReplacement) {
+			t.Errorf("legacyTypeSelection(%d) = %s %d %q, want %s %d %q", tt.archiveType, sel.format, sel.subType, replacement, tt.wantFormat, tt.wantSubType, tt.wantReplacement)
+		}
+	}
+}
+
+func TestSubTypeName(t *testing.T) {
+	tests := []struct {
+		format  string
+		subType int
+		want    string
+	}{
+		{"Kaguya", catalog.KaguyaIN, "in"},
+		{"Kaguya", catalog.KaguyaLegacy, "legacy"},
+		{"Kaguya", catalog.KaguyaPoFV, "pofv"},
+		{"Kanako", catalog.KanakoUFO, "ufo"},
+		{"Remilia", -1, ""},
+	}
+	for _, tt := range tests {
+		if got := subTypeName(tt.format, tt.subType); got != tt.want {
+			t.Errorf("subTypeName(%s, %d) = %q, want %q", tt.format, tt.subType, got, tt.want)
+		}
+	}
+}
+
+func TestArchiveOptions_Game(t *testing.T) {
+	arc := buildTestArchive(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		wantErr string
+	}{
+		{"自動判別", nil, 0, ""},
+		{"--game", []string{"--game", "th06"}, 0, ""},
+		{"タイトルで指定", []string{"--game", "東方紅魔郷"}, 0, ""},
+		{"--archive-format", []string{"--archive-format", "remilia"}, 0, ""},
+		{"形式が一致しない", []string{"--game", "th08"}, 3, ""},
+		{"不明なゲーム", []string{"--game", "th99x"}, 2, "不明なゲームです"},
+		{"同時に指定", []string{"--game", "th06", "--archive-format", "remilia"}, 2, "同時に指定できません"},
+		{"サブタイプのみ", []string{"--subtype", "in"}, 2, "--subtype は"},
+		{"非推奨の -t", []string{"-t", "1"}, 3, "--subtype legacy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"list"}, tt.args...)
+			res := runCLI(t, context.Background(), append(args, arc)...)
+			if res.code != tt.code {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", res.code, tt.code, res.stderr)
+			}
+			if !strings.Contains(res.stderr, tt.wantErr) {
+				t.Errorf("stderr does not contain %q:\n%s", tt.wantErr, res.stderr)
+			}
+		})
+	}
+}
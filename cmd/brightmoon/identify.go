@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// 判定方法
+const (
+	identifiedByContent  = "content"  // アーカイブ内の BGM 定義ファイルから判定
+	identifiedByFormat   = "format"   // アーカイブ形式から一意に判定
+	identifiedByFileName = "filename" // ファイル名から判定
+)
+
+// identification は identify サブコマンドの判定結果
+type identification struct {
+	File        string `json:"file"`
+	Size        int64  `json:"size"`
+	Fingerprint string `json:"fingerprint"`
+	Method      string `json:"method,omitempty"`
+	GameID      string `json:"game,omitempty"`
+	Number      string `json:"number,omitempty"`
+	Title       string `json:"title,omitempty"`
+	TitleEN     string `json:"title_en,omitempty"`
+	Edition     string `json:"edition,omitempty"`
+	Format      string `json:"format,omitempty"`
+	SubType     string `json:"subtype,omitempty"`
+}
+
+// bgmGamePattern は thbgm.fmt や musiccmt.txt に含まれる曲ファイル名 (th15_01.wav など) からゲーム ID を取り出す正規表現
+var bgmGamePattern = regexp.MustCompile(`th(\d{2,3})_\d{2}`)
+
+// setupIdentify は identify サブコマンドを設定します
+func setupIdentify(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	format := fs.String("format", "text", "output format (text, json)")
+	opts := &archiveOptions{}
+	fs.StringVar(&opts.keyFile, "k", "", "RSA public key file for TFPK (.pak) archives (required to open them: no keys are built in)")
+	fs.StringVar(&opts.nameList, "n", "", "file name list for resolving TFPK (.pak) entry names")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		if *format != "text" && *format != "json" {
+			return i18n.Errorf("不明な出力形式です: %s (text, json のいずれかを指定してください)", *format)
+		}
+		if err := loadKokoroOptions(opts.keyFile, opts.nameList); err != nil {
+			return err
+		}
+
+		// 形式の判定中に表示される進捗メッセージは出力しない
+		statusOut = io.Discard
+
+		var results []*identification
+		unknown := 0
+		for _, filename := range args {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			id, err := identifyFile(filename)
+			if err != nil {
+				return err
+			}
+			if id.GameID == "" {
+				unknown++
+			}
+			results = append(results, id)
+		}
+
+		if *format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return err
+			}
+		} else {
+			for i, id := range results {
+				if i > 0 {
+					fmt.Println()
+				}
+				writeIdentification(os.Stdout, id)
+			}
+		}
+
+		if unknown > 0 {
+			return i18n.Errorf("%d 個のファイルで作品を特定できませんでした", unknown)
+		}
+		return nil
+	}
+}
+
+// identifyFile はファイルの作品・アーカイブ形式を判定します
+// アーカイブの内容、アーカイブ形式、ファイル名の順に作品を推定します
+// フィンガープリントは判定には使わず、不具合報告などでファイルを確認できるように表示します
+func identifyFile(filename string) (*identification, error) {
+	fp, err := catalog.FingerprintFile(filename)
+	if err != nil {
+		return nil, i18n.Errorf("ファイルを読み込めません: %w", err)
+	}
+	id := &identification{File: filename, Size: fp.Size, Fingerprint: fp.String()}
+
+	sel, gameID, trial := probeArchive(filename)
+	if sel != nil {
+		id.Format = sel.format
+		id.SubType = subTypeName(sel.format, sel.subType)
+	}
+	if game, ok := catalog.Lookup(gameID); ok {
+		id.setGame(game)
+		id.Method = identifiedByContent
+		id.Edition = catalog.EditionProduct
+		if trial {
+			id.Edition = catalog.EditionTrial
+		}
+		return id, nil
+	}
+	if sel != nil {
+		if game, ok := uniqueGameForFormat(sel.format); ok {
+			id.setGame(game)
+			id.Method = identifiedByFormat
+			return id, nil
+		}
+	}
+	if game, ok := catalog.FromFileName(filename); ok && (sel == nil || string(game.Format) == sel.format) {
+		id.setGame(game)
+		id.Method = identifiedByFileName
+		if game.IsTrialArchive(filename) {
+			id.Edition = catalog.EditionTrial
+		}
+	}
+	return id, nil
+}
+
+// setGame は判定した作品の情報を設定します (形式が判定済みの場合は上書きしません)
+func (id *identification) setGame(game catalog.Game) {
+	id.GameID = game.ID
+	id.Number = game.Number
+	id.Title = game.Title
+	id.TitleEN = game.TitleEN
+	if id.Format == "" {
+		id.Format = string(game.Format)
+		id.SubType = game.SubTypeName
+	}
+}
+
+// probeArchive は各形式 (Kaguya/Kanako は各サブタイプ) でアーカイブを開き、形式と作品を推定します
+// サブタイプは BGM 定義ファイルを正しく復号できたものを採用します
+func probeArchive(filename string) (sel *archiveSelection, gameID string, trial bool) {
+	for _, format := range []string{"Remilia", "Yukari", "Yumemi", "Suica", "Hinanawi", "Marisa", "Kaguya", "Kanako", "Kokoro"} {
+		subTypes := []int{-1}
+		if names, ok := primarySubTypes[format]; ok {
+			subTypes = subTypes[:0]
+			for v := range names {
+				subTypes = append(subTypes, v)
+			}
+		}
+		for _, subType := range subTypes {
+			candidate := &archiveSelection{format: format, subType: subType}
+			archive, err := openSelectedArchive(filename, candidate)
+			if err != nil {
+				continue
+			}
+			gameID, trial = gameFromBGM(archive)
+			archive.Close()
+			if gameID != "" {
+				return candidate, gameID, trial
+			}
+			if sel == nil {
+				sel = candidate
+			}
+		}
+	}
+	return sel, "", false
+}
+
+// gameFromBGM はアーカイブ内の thbgm.fmt / musiccmt.txt に含まれる曲ファイル名からゲーム ID を推定します
+// 体験版のファイル (thbgm_tr.fmt など) しかない場合は trial=true を返します
+func gameFromBGM(archive pbgarc.PBGArchive) (gameID string, trial bool) {
+	names := map[string]bool{
+		"thbgm.fmt": false, "musiccmt.txt": false,
+		"thbgm_tr.fmt": true, "musiccmt_tr.txt": true,
+	}
+	if !archive.EnumFirst() {
+		return "", false
+	}
+	do := true
+	for do {
+		base := strings.ToLower(path.Base(entryKey(archive.GetEntryName())))
+		if isTrial, ok := names[base]; ok {
+			var buf bytes.Buffer
+			if archive.GetEntry().Extract(&buf, nil, nil) {
+				if m := bgmGamePattern.FindSubmatch(buf.Bytes()); m != nil {
+					if _, known := catalog.Lookup("th" + string(m[1])); known {
+						return "th" + string(m[1]), isTrial
+					}
+				}
+			}
+		}
+		do = archive.EnumNext()
+	}
+	return "", false
+}
+
+// uniqueGameForFormat は形式を使う作品が1つだけの場合にその作品を返します
+func uniqueGameForFormat(format string) (catalog.Game, bool) {
+	var found []catalog.Game
+	for _, g := range catalog.Games() {
+		if string(g.Format) == format {
+			found = append(found, g)
+		}
+	}
+	if len(found) != 1 {
+		return catalog.Game{}, false
+	}
+	return found[0], true
+}
+
+// writeIdentification は判定結果をテキスト形式で書き出します
+func writeIdentification(w io.Writer, id *identification) {
+	i18n.Fprintf(w, "ファイル: %s\n", id.File)
+	i18n.Fprintf(w, "サイズ: %d バイト\n", id.Size)
+	i18n.Fprintf(w, "フィンガープリント: %s\n", id.Fingerprint)
+	if id.GameID == "" {
+		i18n.Fprintln(w, "判定: 不明 (対応する作品が見つかりませんでした)")
+	} else {
+		i18n.Fprintf(w, "判定: %s\n", describeIdentification(id))
+		i18n.Fprintf(w, "判定方法: %s\n", describeMethod(id.Method))
+	}
+	if id.Format != "" {
+		if id.SubType != "" {
+			i18n.Fprintf(w, "形式: %s (%s)\n", id.Format, id.SubType)
+		} else {
+			i18n.Fprintf(w, "形式: %s\n", id.Format)
+		}
+	}
+}
+
+// describeIdentification は判定した作品を "TH15 Legacy of Lunatic Kingdom (東方紺珠伝) 製品版" の形式で返します
+func describeIdentification(id *identification) string {
+	s := fmt.Sprintf("%s %s (%s)", strings.ToUpper(id.GameID), id.TitleEN, id.Title)
+	switch id.Edition {
+	case catalog.EditionProduct:
+		s += i18n.T(" 製品版")
+	case catalog.EditionTrial:
+		s += i18n.T(" 体験版")
+	}
+	return s
+}
+
+// describeMethod は判定方法の説明を返します
+func describeMethod(method string) string {
+	switch method {
+	case identifiedByContent:
+		return i18n.T("アーカイブの内容 (BGM 定義ファイル)")
+	case identifiedByFormat:
+		return i18n.T("アーカイブ形式")
+	case identifiedByFileName:
+		return i18n.T("ファイル名")
+	}
+	return method
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+)
+
+func TestIdentify(t *testing.T) {
+	dir := t.TempDir()
+	renamed := writePBG3Archive(t, dir, "renamed.dat", testEntries)
+	trial := writePBG3Archive(t, dir, "bgm/data.dat", []testEntry{
+		{name: "thbgm_tr.fmt", data: []byte("th06_01.wav\x00")},
+	})
+	junkTH08 := filepath.Join(dir, "th08.dat")
+	junk := filepath.Join(dir, "junk.dat")
+	for _, p := range []string{junkTH08, junk} {
+		if err := os.WriteFile(p, []byte("not an archive"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		wantGame    string
+		wantMethod  string
+		wantFormat  string
+		wantEdition string
+	}{
+		{"アーカイブ形式から判定", renamed, "th06", identifiedByFormat, "Remilia", ""},
+		{"BGM 定義ファイルから判定", trial, "th06", identifiedByContent, "Remilia", catalog.EditionTrial},
+		{"ファイル名から判定", junkTH08, "th08", identifiedByFileName, "Kaguya", ""},
+		{"判定できない", junk, "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, context.Background(), "identify", "--format", "json", tt.file)
+			wantCode := 0
+			if tt.wantGame == "" {
+				wantCode = 1
+			}
+			if res.code != wantCode {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", res.code, wantCode, res.stderr)
+			}
+
+			var results []identification
+			if err := json.Unmarshal([]byte(res.stdout), &results); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, res.stdout)
+			}
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			id := results[0]
+			if id.GameID != tt.wantGame || id.Method != tt.wantMethod || id.Format != tt.wantFormat || id.Edition != tt.wantEdition {
+				t.Errorf("identify = game %q method %q format %q edition %q, want %q %q %q %q",
+					id.GameID, id.Method, id.Format, id.Edition, tt.wantGame, tt.wantMethod, tt.wantFormat, tt.wantEdition)
+			}
+			info, err := os.Stat(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id.Size != info.Size() || !strings.HasPrefix(id.Fingerprint, strconv.FormatInt(info.Size(), 10)+":") {
+				t.Errorf("size = %d, fingerprint = %q", id.Size, id.Fingerprint)
+			}
+		})
+	}
+}
+
+func TestIdentify_Text(t *testing.T) {
+	arc := buildTestArchive(t)
+	res := runCLI(t, context.Background(), "identify", arc)
+	if res.code != 0 {
+		t.Fatalf("exit code = %d (stderr: %s)", res.code, res.stderr)
+	}
+	for _, want := range []string{
+		"判定: TH06 the Embodiment of Scarlet Devil (東方紅魔郷)\n",
+		"判定方法: アーカイブ形式",
+		"形式: Remilia",
+		"フィンガープリント: ",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, res.stdout)
+		}
+	}
+	// 形式の判定中の進捗メッセージは表示しない
+	if strings.Contains(res.stdout, "自動検出中") {
+		t.Errorf("stdout contains auto-detection messages:\n%s", res.stdout)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+// setupInfo は info サブコマンドを設定します
+func setupInfo(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	opts := &archiveOptions{}
+	opts.register(fs)
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		filename := args[0]
+
+		fileInfo, err := os.Stat(filename)
+		if err != nil {
+			return i18n.Errorf("ファイル情報の取得に失敗: %w", err)
+		}
+
+		archive, err := openArchive(filename, opts)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		// エントリ数とサイズの合計を集計
+		var count int
+		var totalOrig, totalComp uint64
+		for ok := archive.EnumFirst(); ok; ok = archive.EnumNext() {
+			count++
+			totalOrig += uint64(archive.GetOriginalSize())
+			totalComp += uint64(archive.GetCompressedSize())
+		}
+
+		format, subType := describeArchive(archive)
+		fmt.Println()
+		i18n.Printf("ファイル: %s\n", filename)
+		i18n.Printf("サイズ: %d バイト\n", fileInfo.Size())
+		i18n.Printf("形式: %s\n", format)
+		if subType != "" {
+			i18n.Printf("サブタイプ: %s\n", subType)
+		}
+		i18n.Printf("エントリ数: %d\n", count)
+		i18n.Printf("元サイズ合計: %d バイト\n", totalOrig)
+		i18n.Printf("圧縮サイズ合計: %d バイト\n", totalComp)
+		if totalOrig > 0 {
+			i18n.Printf("圧縮率: %.1f%%\n", float64(totalComp)*100/float64(totalOrig))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+)
+
+// runLegacy は従来のフラグ形式 (brightmoon [-l] [-x] ... <アーカイブファイル>) でコマンドを実行します
+func runLegacy(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("brightmoon", flag.ContinueOnError)
+	extractFlag := fs.Bool("x", false, "extract files")
+	listFlag := fs.Bool("l", false, "list files")
+	versionFlag := fs.Bool("v", false, "show version information")
+	archiveOpts := &archiveOptions{}
+	archiveOpts.register(fs)
+	extractOpts := &extractOptions{}
+	extractOpts.register(fs)
+	loader := registerConfigFlags(legacyCommandName, fs)
+	registerLangFlag(fs)
+	registerLogFlags(fs)
+
+	fs.Usage = func() {
+		out := fs.Output()
+		i18n.Fprintln(out, "使用方法: brightmoon <サブコマンド> [オプション] <アーカイブファイル>")
+		i18n.Fprintln(out, "       brightmoon [オプション] <アーカイブファイル> [抽出ファイル...]")
+		fmt.Fprintln(out)
+		i18n.Fprintln(out, "サブコマンド:")
+		for _, cmd := range commands {
+			fmt.Fprintf(out, "  %-10s %s\n", cmd.name, i18n.T(cmd.summary))
+		}
+		fmt.Fprintln(out)
+		i18n.Fprintln(out, "オプション (サブコマンドなしの場合):")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return outcome.ExitUsage
+	}
+	args, err := applyUserConfig(legacyCommandName, fs, loader)
+	if err != nil {
+		i18n.PrintError(os.Stderr, err)
+		return outcome.ExitUsage
+	}
+	if err := setupLogger(); err != nil {
+		i18n.PrintError(os.Stderr, err)
+		return outcome.ExitUsage
+	}
+
+	// バージョン情報の表示
+	if *versionFlag {
+		fmt.Printf("brightmoon version %s\n", version)
+		return 0
+	}
+
+	// 引数チェック
+	if len(args) < 1 {
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		return outcome.ExitUsage
+	}
+	return exitStatus(runLegacyArchive(ctx, args, archiveOpts, extractOpts, *listFlag, *extractFlag))
+}
+
+// runLegacyArchive は従来のフラグ形式でアーカイブの一覧表示・抽出を行います
+func runLegacyArchive(ctx context.Context, args []string, archiveOpts *archiveOptions, extractOpts *extractOptions, list, extract bool) (err error) {
+	rep := newReport(extractOpts.reportPath, "")
+	defer func() { err = finishReport(rep, extractOpts.reportPath, err) }()
+
+	filename := args[0]
+	result := rep.AddArchive(filename)
+	archive, err := openArchive(filename, archiveOpts)
+	if err != nil {
+		result.Fail(err)
+		return err
+	}
+	defer archive.Close()
+
+	// リストを表示する
+	if list {
+		listArchive(filename, archive)
+	}
+
+	// 抽出する (-x フラグ、ファイル指定または --include/--exclude がある場合)
+	filesToExtract := args[1:]
+	if extract || len(filesToExtract) > 0 || extractOpts.hasFilters() {
+		return runExtraction(ctx, filename, archive, extractOpts, filesToExtract, result)
+	}
+	result.SetFormat(describeArchive(archive))
+	return nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/width"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// 一覧の出力形式
+const (
+	listFormatTable = "table"
+	listFormatJSON  = "json"
+	listFormatCSV   = "csv"
+	listFormatTSV   = "tsv"
+)
+
+// archiveListing はアーカイブの一覧情報 (JSON 出力の形式)
+type archiveListing struct {
+	Archive string        `json:"archive"`
+	Format  string        `json:"format"`
+	SubType string        `json:"subtype,omitempty"`
+	Entries []listedEntry `json:"entries"`
+}
+
+// listedEntry はエントリ1件分の一覧情報
+type listedEntry struct {
+	Name           string                 `json:"name"`
+	Offset         int64                  `json:"offset"`
+	OriginalSize   uint32                 `json:"original_size"`
+	CompressedSize uint32                 `json:"compressed_size"`
+	Ratio          float64                `json:"ratio"` // 圧縮サイズ / 元サイズ
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// collectListing はアーカイブの全エントリの情報を収集します
+func collectListing(filename string, archive pbgarc.PBGArchive) *archiveListing {
+	format, subType := describeArchive(archive)
+	listing := &archiveListing{
+		Archive: filename,
+		Format:  format,
+		SubType: subType,
+		Entries: []listedEntry{},
+	}
+
+	if !archive.EnumFirst() {
+		return listing
+	}
+	do := true
+	for do {
+		entry := archive.GetEntry()
+		offset, metadata := entryDetails(archive, entry)
+		listed := listedEntry{
+			Name:           entry.GetEntryName(),
+			Offset:         offset,
+			OriginalSize:   entry.GetOriginalSize(),
+			CompressedSize: entry.GetCompressedSize(),
+			Metadata:       metadata,
+		}
+		if listed.OriginalSize > 0 {
+			listed.Ratio = float64(listed.CompressedSize) / float64(listed.OriginalSize)
+		}
+		listing.Entries = append(listing.Entries, listed)
+		do = archive.EnumNext()
+	}
+	return listing
+}
+
+// entryDetails はエントリのファイル先頭からのオフセットと形式固有のメタデータを取得します
+func entryDetails(archive pbgarc.PBGArchive, entry pbgarc.PBGArchiveEntry) (int64, map[string]interface{}) {
+	switch e := entry.(type) {
+	case *pbgarc.RemiliaEntry:
+		return int64(e.Offset), map[string]interface{}{"checksum": e.Checksum}
+	case *pbgarc.YukariEntry:
+		return int64(e.Offset), map[string]interface{}{"extra": e.Extra}
+	case *pbgarc.YumemiEntry:
+		return int64(e.Offset), map[string]interface{}{"key": e.Key}
+	case *pbgarc.SuicaEntry:
+		return int64(e.Offset), nil
+	case *pbgarc.HinanawiEntry:
+		return int64(e.Offset), nil
+	case *pbgarc.MarisaEntry:
+		return int64(e.Offset), nil
+	case *pbgarc.KaguyaEntry:
+		dataType, err := e.GetDataType()
+		if err != nil {
+			return int64(e.Offset), map[string]interface{}{"edz_error": err.Error()}
+		}
+		return int64(e.Offset), map[string]interface{}{"edz_type": dataType}
+	case *pbgarc.KanakoEntry:
+		return int64(e.Offset), map[string]interface{}{"crypt_param_index": e.GetCryptParamIndex()}
+	case *pbgarc.KokoroEntry:
+		var base int64
+		if a, ok := archive.(*pbgarc.KokoroArchive); ok {
+			base = a.GetDataOffset()
+		}
+		return base + int64(e.Offset), map[string]interface{}{"hash": fmt.Sprintf("%016x", e.Hash)}
+	default:
+		return -1, nil
+	}
+}
+
+// writeListing は指定された形式で一覧を書き出します
+func writeListing(w io.Writer, listing *archiveListing, format string) error {
+	switch format {
+	case listFormatTable:
+		writeListingTable(w, listing)
+		return nil
+	case listFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listing)
+	case listFormatCSV:
+		return writeListingCSV(w, listing, ',')
+	case listFormatTSV:
+		return writeListingCSV(w, listing, '\t')
+	default:
+		return i18n.Errorf("不明な出力形式です: %s (table, json, csv, tsv のいずれかを指定してください)", format)
+	}
+}
+
+// writeListingTable は一覧を表形式で書き出します
+// ファイル名の列幅は最も長いファイル名 (全角文字は2桁) に合わせます
+func writeListingTable(w io.Writer, listing *archiveListing) {
+	nameWidth := 32
+	for _, e := range listing.Entries {
+		nameWidth = max(nameWidth, displayWidth(e.Name))
+	}
+	rule := strings.Repeat("-", nameWidth+22)
+
+	i18n.Fprintln(w, "アーカイブ内のファイル一覧:")
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "%s %s %s\n", padRight(i18n.T("ファイル名"), nameWidth), padLeft(i18n.T("元サイズ"), 10), padLeft(i18n.T("圧縮サイズ"), 10))
+	fmt.Fprintln(w, rule)
+
+	if len(listing.Entries) == 0 {
+		i18n.Fprintln(w, "ファイルがありません")
+		return
+	}
+	for _, e := range listing.Entries {
+		fmt.Fprintf(w, "%s %10d %10d\n", padRight(e.Name, nameWidth), e.OriginalSize, e.CompressedSize)
+	}
+	fmt.Fprintln(w, rule)
+}
+
+// writeListingCSV は一覧を区切り文字付きの形式で書き出します
+// メタデータは "key=value" をセミコロンで連結した1列にまとめます
+func writeListingCSV(w io.Writer, listing *archiveListing, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write([]string{"archive", "format", "subtype", "name", "offset", "original_size", "compressed_size", "ratio", "metadata"})
+	for _, e := range listing.Entries {
+		cw.Write([]string{
+			listing.Archive,
+			listing.Format,
+			listing.SubType,
+			e.Name,
+			strconv.FormatInt(e.Offset, 10),
+			strconv.FormatUint(uint64(e.OriginalSize), 10),
+			strconv.FormatUint(uint64(e.CompressedSize), 10),
+			strconv.FormatFloat(e.Ratio, 'f', 4, 64),
+			formatMetadata(e.Metadata),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatMetadata はメタデータをキー順に "key=value;..." 形式の文字列にします
+func formatMetadata(metadata map[string]interface{}) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, metadata[k]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// displayWidth は端末上での表示幅 (全角文字は2桁) を返します
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// padRight は表示幅が w になるよう空白で右側を埋めます
+func padRight(s string, w int) string {
+	if d := w - displayWidth(s); d > 0 {
+		return s + strings.Repeat(" ", d)
+	}
+	return s
+}
+
+// padLeft は表示幅が w になるよう空白で左側を埋めます
+func padLeft(s string, w int) string {
+	if d := w - displayWidth(s); d > 0 {
+		return strings.Repeat(" ", d) + s
+	}
+	return s
+}
+
+// アーカイブのリストを表示
+func listArchive(filename string, archive pbgarc.PBGArchive) {
+	writeListingTable(os.Stdout, collectListing(filename, archive))
+}
+
+// setupList は list サブコマンドを設定します
+func setupList(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	format := fs.String("format", listFormatTable, "output format (table, json, csv, tsv)")
+	opts := &archiveOptions{}
+	opts.register(fs)
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+		switch *format {
+		case listFormatTable, listFormatJSON, listFormatCSV, listFormatTSV:
+		default:
+			return i18n.Errorf("不明な出力形式です: %s (table, json, csv, tsv のいずれかを指定してください)", *format)
+		}
+		if *format != listFormatTable {
+			// 構造化出力を壊さないよう、進捗メッセージは標準エラー出力に書き出す
+			statusOut = os.Stderr
+		}
+
+		archive, err := openArchive(args[0], opts)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		return writeListing(os.Stdout, collectListing(args[0], archive), *format)
+	}
+}
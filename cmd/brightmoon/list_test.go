@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestList_Formats(t *testing.T) {
+	arc := buildTestArchive(t)
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, stdout string)
+	}{
+		{listFormatTable, func(t *testing.T, stdout string) {
+			for _, e := range testEntries {
+				if !strings.Contains(stdout, e.name) {
+					t.Errorf("table output does not contain %s:\n%s", e.name, stdout)
+				}
+			}
+		}},
+		{listFormatJSON, func(t *testing.T, stdout string) {
+			var listing archiveListing
+			if err := json.Unmarshal([]byte(stdout), &listing); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+			}
+			if listing.Format != "Remilia" || len(listing.Entries) != len(testEntries) {
+				t.Fatalf("listing = %s with %d entries, want Remilia with %d", listing.Format, len(listing.Entries), len(testEntries))
+			}
+			for i, e := range testEntries {
+				got := listing.Entries[i]
+				if got.Name != e.name || got.OriginalSize != uint32(len(e.data)) {
+					t.Errorf("entry %d = %s (%d bytes), want %s (%d bytes)", i, got.Name, got.OriginalSize, e.name, len(e.data))
+				}
+				if _, ok := got.Metadata["checksum"]; !ok {
+					t.Errorf("entry %s has no checksum metadata", got.Name)
+				}
+			}
+		}},
+		{listFormatCSV, func(t *testing.T, stdout string) {
+			checkListingRecords(t, stdout, ',')
+		}},
+		{listFormatTSV, func(t *testing.T, stdout string) {
+			checkListingRecords(t, stdout, '\t')
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			res := runCLI(t, context.Background(), "list", "--format", tt.format, arc)
+			if res.code != 0 {
+				t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+			}
+			tt.check(t, res.stdout)
+		})
+	}
+
+	t.Run("不明な形式", func(t *testing.T) {
+		if res := runCLI(t, context.Background(), "list", "--format", "xml", arc); res.code == 0 {
+			t.Error("exit code = 0, want failure")
+		}
+	})
+}
+
+// checkListingRecords は CSV/TSV の一覧にヘッダと全エントリが含まれることを確認します
+// 機械可読な形式では、標準出力に状態表示が混ざらないことも確認します
+func checkListingRecords(t *testing.T, stdout string, comma rune) {
+	t.Helper()
+	r := csv.NewReader(strings.NewReader(stdout))
+	r.Comma = comma
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, stdout)
+	}
+	if len(records) != len(testEntries)+1 {
+		t.Fatalf("got %d records, want %d:\n%s", len(records), len(testEntries)+1, stdout)
+	}
+	if records[0][3] != "name" || records[0][5] != "original_size" {
+		t.Errorf("header = %q", records[0])
+	}
+	for i, e := range testEntries {
+		if rec := records[i+1]; rec[1] != "Remilia" || rec[3] != e.name {
+			t.Errorf("record %d = %q, want %s", i+1, rec, e.name)
+		}
+	}
+}
+
+func TestFormatMetadata(t *testing.T) {
+	got := formatMetadata(map[string]interface{}{"key": 1, "checksum": 654})
+	if want := "checksum=654;key=1"; got != want {
+		t.Errorf("formatMetadata() = %q, want %q", got, want)
+	}
+	if got := formatMetadata(nil); got != "" {
+		t.Errorf("formatMetadata(nil) = %q, want empty", got)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"a.txt", 5},
+		{"紅魔郷.txt", 10},
+		{"ｱｲｳ", 3},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.s); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+	if got := padRight("紅", 4); got != "紅  " {
+		t.Errorf("padRight() = %q", got)
+	}
+	if got := padLeft("紅", 4); got != "  紅" {
+		t.Errorf("padLeft() = %q", got)
+	}
+}
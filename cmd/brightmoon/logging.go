@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+var (
+	// logger は診断ログの出力先 (標準出力の一覧や抽出データと混ざらないよう標準エラー出力に出力する)
+	logger = slog.New(slog.DiscardHandler)
+
+	// logLevel と logFormat は --log-level と --log-format の値
+	logLevel  = "warn"
+	logFormat = "text"
+)
+
+// registerLogFlags は --log-level と --log-format を登録します
+func registerLogFlags(fs *flag.FlagSet) {
+	fs.StringVar(&logLevel, "log-level", logLevel, "log `level` written to stderr (debug, info, warn, error); -d implies debug")
+	fs.StringVar(&logFormat, "log-format", logFormat, "log `format` (text, json)")
+}
+
+// parseLogLevel はログレベルの名前を解析します
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, i18n.Errorf("不明なログレベルです: %s (debug, info, warn, error)", name)
+	}
+	return level, nil
+}
+
+// newLogger は指定されたレベルと形式で w に出力するロガーを作成します
+func newLogger(w io.Writer, levelName, format string, debug bool) (*slog.Logger, error) {
+	level, err := parseLogLevel(levelName)
+	if err != nil {
+		return nil, err
+	}
+	if debug {
+		level = min(level, slog.LevelDebug)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, i18n.Errorf("不明なログ形式です: %s (text, json)", format)
+	}
+}
+
+// setupLogger はフラグの値から logger を設定します (フラグと設定ファイルの適用後に呼び出す)
+func setupLogger() error {
+	l, err := newLogger(os.Stderr, logLevel, logFormat, debugMode)
+	if err != nil {
+		return err
+	}
+	logger = l
+	return nil
+}
+
+// withLogger は logger を出力先とするアーカイブの設定を返します
+func withLogger() pbgarc.Option {
+	return pbgarc.WithLogger(logger)
+}
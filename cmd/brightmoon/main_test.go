@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bitWriter はテスト用の MSB ファーストなビットライター (pkg/pbgarc のテストと同じもの)
+type bitWriter struct {
+	buf   []byte
+	cur   byte
+	count uint
+}
+
+func (w *bitWriter) write(value uint32, numBits uint) {
+	for i := int(numBits) - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte((value>>uint(i))&1)
+		w.count++
+		if w.count == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.count = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) writePBG3Uint32(value uint32) {
+	size := uint32(3)
+	switch {
+	case value <= 0xff:
+		size = 0
+	case value <= 0xffff:
+		size = 1
+	case value <= 0xffffff:
+		size = 2
+	}
+	w.write(size, 2)
+	w.write(value, uint(size+1)*8)
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.count > 0 {
+		return append(w.buf, w.cur<<(8-w.count))
+	}
+	return w.buf
+}
+
+// lzssLiteral はデータを全てリテラルとして LZSS 形式にエンコードします
+func lzssLiteral(data []byte) []byte {
+	w := &bitWriter{}
+	for _, b := range data {
+		w.write(1, 1)
+		w.write(uint32(b), 8)
+	}
+	w.write(0, 1)
+	w.write(0, 13)
+	return w.bytes()
+}
+
+// testEntry はテスト用アーカイブのエントリ
+type testEntry struct {
+	name    string
+	data    []byte
+	corrupt bool // チェックサムを不正にする
+}
+
+// testEntries はテストで標準的に使うエントリ
+var testEntries = []testEntry{
+	{name: "a.txt", data: []byte("alpha\n")},
+	{name: "dir/b.txt", data: []byte("bravo\n")},
+	{name: "c.bin", data: bytes.Repeat([]byte{0x00, 0xff}, 64)},
+}
+
+// writePBG3Archive は entries を格納した PBG3 形式 (東方紅魔郷) のアーカイブを dir/name に作成します
+func writePBG3Archive(t *testing.T, dir, name string, entries []testEntry) string {
+	t.Helper()
+
+	header := &bitWriter{}
+	body := &bytes.Buffer{}
+	list := &bitWriter{}
+	offset := uint32(16)
+	for _, e := range entries {
+		comp := lzssLiteral(e.data)
+		var sum uint32
+		for _, b := range comp {
+			sum += uint32(b)
+		}
+		if e.corrupt {
+			sum++
+		}
+		list.writePBG3Uint32(0)
+		list.writePBG3Uint32(0)
+		list.writePBG3Uint32(sum)
+		list.writePBG3Uint32(offset)
+		list.writePBG3Uint32(uint32(len(e.data)))
+		for _, c := range []byte(e.name) {
+			list.write(uint32(c), 8)
+		}
+		list.write(0, 8)
+		body.Write(comp)
+		offset += uint32(len(comp))
+	}
+	header.writePBG3Uint32(uint32(len(entries)))
+	header.writePBG3Uint32(offset)
+
+	data := make([]byte, 16)
+	copy(data, "PBG3")
+	copy(data[4:], header.bytes())
+	data = append(data, body.Bytes()...)
+	data = append(data, list.bytes()...)
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	return path
+}
+
+// buildTestArchive は testEntries を格納した th06.dat を一時ディレクトリに作成します
+func buildTestArchive(t *testing.T) string {
+	t.Helper()
+	return writePBG3Archive(t, t.TempDir(), "th06.dat", testEntries)
+}
+
+// cliResult はコマンドの実行結果
+type cliResult struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// runCLI はコマンドを実行し、終了コードと標準出力・標準エラー出力を返します
+// 表示言語は日本語に固定し、ユーザー設定ファイルは読み込まないようにします
+func runCLI(t *testing.T, ctx context.Context, args ...string) cliResult {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	origStdout, origStderr, origStatus := os.Stdout, os.Stderr, statusOut
+	os.Stdout, os.Stderr, statusOut = stdout, stderr, stdout
+	defer func() {
+		os.Stdout, os.Stderr, statusOut = origStdout, origStderr, origStatus
+		debugMode = false
+	}()
+
+	if len(args) > 0 && findCommand(args[0]) != nil {
+		args = append([]string{args[0], "--lang", "ja"}, args[1:]...)
+	}
+	code := run(ctx, args)
+
+	return cliResult{code: code, stdout: readAll(t, stdout), stderr: readAll(t, stderr)}
+}
+
+// readAll はファイルの先頭から全て読み込みます
+func readAll(t *testing.T, f *os.File) string {
+	t.Helper()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// readTree はディレクトリ以下のファイルを "/" 区切りの相対パスをキーにして読み込みます
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestRun_UnknownFlag(t *testing.T) {
+	res := runCLI(t, context.Background(), "list", "--no-such-flag", "x.dat")
+	if res.code != 2 {
+		t.Errorf("exit code = %d, want 2 (stderr: %s)", res.code, res.stderr)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// entryDigest はエントリの展開後データのハッシュとサイズ
+type entryDigest struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// digestWriter は書き込まれたデータの SHA-256 ハッシュとバイト数を計算しながら w に書き出すライター
+type digestWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func newDigestWriter(w io.Writer) *digestWriter {
+	return &digestWriter{w: w, h: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	d.h.Write(p[:n])
+	d.n += int64(n)
+	return n, err
+}
+
+// digest はこれまでに書き込まれたデータのハッシュとサイズを返します
+func (d *digestWriter) digest(name string) entryDigest {
+	return entryDigest{
+		Name:   name,
+		Size:   d.n,
+		SHA256: hex.EncodeToString(d.h.Sum(nil)),
+	}
+}
+
+// hashEntry はエントリを io.Discard に展開しながら SHA-256 ハッシュとサイズを計算します
+// 壊れたデータで展開処理が panic した場合もエラーとして扱います
+func hashEntry(entry pbgarc.PBGArchiveEntry) (d entryDigest, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = i18n.Errorf("展開中に異常が発生しました: %v", r)
+		}
+	}()
+
+	dw := newDigestWriter(io.Discard)
+	if !entry.Extract(dw, nil, nil) {
+		return dw.digest(entry.GetEntryName()), errors.New(i18n.T("展開に失敗しました"))
+	}
+	return dw.digest(entry.GetEntryName()), nil
+}
+
+// entryKey はエントリ名の区切り文字を '/' に統一した、比較・照合用の名前を返します
+func entryKey(name string) string {
+	return strings.ReplaceAll(name, "\\", "/")
+}
+
+// manifest は抽出したエントリのハッシュ一覧
+// 並列抽出のワーカーから同時に追加されるため、追加はミューテックスで保護します
+type manifest struct {
+	Archive string        `json:"archive"`
+	Format  string        `json:"format,omitempty"`
+	SubType string        `json:"subtype,omitempty"`
+	Entries []entryDigest `json:"entries"`
+
+	mu sync.Mutex
+}
+
+// add はエントリのハッシュを追加します (m が nil の場合は何もしません)
+func (m *manifest) add(d entryDigest) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.Name = entryKey(d.Name)
+	m.Entries = append(m.Entries, d)
+}
+
+// sort はエントリを名前順に並べ替えます (並列抽出でも出力を安定させるため)
+func (m *manifest) sort() {
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Name < m.Entries[j].Name })
+}
+
+// writeSHA256Sum は sha256sum -c で検証できる形式で書き出します
+// パスは抽出先ディレクトリからの相対パスです
+func (m *manifest) writeSHA256Sum(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range m.Entries {
+		fmt.Fprintf(bw, "%s  %s\n", e.SHA256, e.Name)
+	}
+	return bw.Flush()
+}
+
+// writeJSON はサイズと抽出元アーカイブを含む JSON 形式で書き出します
+func (m *manifest) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// saveManifest はマニフェストをファイルに保存します
+func saveManifest(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return i18n.Errorf("マニフェストを作成できません: %v", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return i18n.Errorf("マニフェストを書き込めません: %v", err)
+	}
+	return f.Close()
+}
+
+// loadManifest は sha256sum 形式または JSON 形式のマニフェストを読み込みます
+// sha256sum 形式にはサイズが含まれないため、Size は -1 になります
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, i18n.Errorf("マニフェストを読み込めません: %v", err)
+	}
+
+	m := &manifest{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, m); err != nil {
+			return nil, i18n.Errorf("JSON マニフェストの形式が不正です: %v", err)
+		}
+		return m, nil
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// "<ハッシュ>  <パス>" または "<ハッシュ> *<パス>" (バイナリモード)
+		if len(line) < 67 || (line[65] != ' ' && line[65] != '*') || line[64] != ' ' {
+			return nil, i18n.Errorf("マニフェストの %d 行目の形式が不正です", i+1)
+		}
+		sum := strings.ToLower(line[:64])
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, i18n.Errorf("マニフェストの %d 行目のハッシュが不正です", i+1)
+		}
+		m.Entries = append(m.Entries, entryDigest{Name: line[66:], Size: -1, SHA256: sum})
+	}
+	return m, nil
+}
+
+// byName はエントリ名をキーにしたマップを返します
+func (m *manifest) byName() map[string]entryDigest {
+	result := make(map[string]entryDigest, len(m.Entries))
+	for _, e := range m.Entries {
+		result[entryKey(e.Name)] = e
+	}
+	return result
+}
+
+// compareDigest は期待値と実際のハッシュ・サイズを比較し、一致しない場合は問題を記録します
+func compareDigest(status *entryStatus, want, got entryDigest) {
+	if want.Size >= 0 && want.Size != got.Size {
+		status.fail("サイズがマニフェストと一致しません (期待値 %d, 実際 %d)", want.Size, got.Size)
+	}
+	if want.SHA256 != got.SHA256 {
+		status.fail("SHA-256 がマニフェストと一致しません")
+	}
+}
+
+// verifyTree は抽出済みのディレクトリをマニフェストと照合します
+func verifyTree(dir string, m *manifest) []*entryStatus {
+	statuses := make([]*entryStatus, 0, len(m.Entries))
+	for _, want := range m.Entries {
+		status := &entryStatus{name: want.Name}
+		statuses = append(statuses, status)
+
+		// マニフェストの名前が dir の外 (../ など) を指す場合は読み込まない
+		path, err := pbgarc.JoinEntryPath(dir, want.Name)
+		if err != nil {
+			status.fail("安全でないエントリ名: %s", want.Name)
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			status.fail("ファイルを開けません: %v", err)
+			continue
+		}
+		dw := newDigestWriter(io.Discard)
+		_, err = io.Copy(dw, f)
+		f.Close()
+		if err != nil {
+			status.fail("ファイルを読み込めません: %v", err)
+			continue
+		}
+		compareDigest(status, want, dw.digest(want.Name))
+	}
+	return statuses
+}
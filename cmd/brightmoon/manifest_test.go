@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	tests := []struct {
+		name     string
+		content  string
+		wantName []string
+		wantSize int64
+		wantErr  bool
+	}{
+		{"sha256sum 形式", sum + "  a.txt\n# コメント\n\n" + sum + "  dir/b.txt\n", []string{"a.txt", "dir/b.txt"}, -1, false},
+		{"バイナリモード", sum + " *a.txt\r\n", []string{"a.txt"}, -1, false},
+		{"大文字のハッシュ", strings.ToUpper(sum) + "  a.txt\n", []string{"a.txt"}, -1, false},
+		{"JSON 形式", `{"archive":"th06.dat","entries":[{"name":"a.txt","size":6,"sha256":"` + sum + `"}]}`, []string{"a.txt"}, 6, false},
+		{"区切りが不正", sum + " a.txt\n", nil, 0, true},
+		{"ハッシュが不正", strings.Repeat("zz", 32) + "  a.txt\n", nil, 0, true},
+		{"JSON が不正", `{"entries":`, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := loadManifest(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(m.Entries) != len(tt.wantName) {
+				t.Fatalf("got %d entries, want %d", len(m.Entries), len(tt.wantName))
+			}
+			for i, e := range m.Entries {
+				if e.Name != tt.wantName[i] || e.SHA256 != sum || e.Size != tt.wantSize {
+					t.Errorf("entry %d = %+v, want %s (%d bytes)", i, e, tt.wantName[i], tt.wantSize)
+				}
+			}
+		})
+	}
+}
+
+func TestManifest_ExtractAndVerify(t *testing.T) {
+	arc := buildTestArchive(t)
+	out := filepath.Join(t.TempDir(), "out")
+	sumPath := filepath.Join(t.TempDir(), "manifest.sha256")
+	jsonPath := filepath.Join(t.TempDir(), "manifest.json")
+
+	res := runCLI(t, context.Background(), "extract", "-o", out, "--manifest", sumPath, "--manifest-json", jsonPath, arc)
+	if res.code != 0 {
+		t.Fatalf("extract exit code = %d (stderr: %s)", res.code, res.stderr)
+	}
+
+	// sha256sum 形式のマニフェストはエントリ名順に並ぶ
+	data, err := os.ReadFile(sumPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for _, e := range []testEntry{testEntries[0], testEntries[2], testEntries[1]} {
+		h := sha256.Sum256(e.data)
+		want.WriteString(hex.EncodeToString(h[:]) + "  " + e.name + "\n")
+	}
+	if string(data) != want.String() {
+		t.Errorf("manifest =\n%s\nwant\n%s", data, want.String())
+	}
+
+	for _, manifestPath := range []string{sumPath, jsonPath} {
+		for _, target := range []string{arc, out} {
+			res := runCLI(t, context.Background(), "verify", "--manifest", manifestPath, target)
+			if res.code != 0 {
+				t.Errorf("verify --manifest %s %s exit code = %d\n%s%s", filepath.Base(manifestPath), target, res.code, res.stdout, res.stderr)
+			}
+		}
+	}
+}
+
+func TestManifest_VerifyTreeFailures(t *testing.T) {
+	h := sha256.Sum256([]byte("alpha\n"))
+	sum := hex.EncodeToString(h[:])
+
+	tests := []struct {
+		name     string
+		manifest string
+		wantNG   string
+	}{
+		{"内容の不一致", strings.Repeat("00", 32) + "  a.txt\n", "a.txt"},
+		{"ファイルがない", sum + "  missing.txt\n", "missing.txt"},
+		{"ディレクトリの外", sum + "  ../a.txt\n", "../a.txt"},
+		{"絶対パス", sum + "  /etc/passwd\n", "/etc/passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "out")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			// ディレクトリの外にも同じ内容のファイルを置き、読み込まれないことを確認する
+			for _, p := range []string{filepath.Join(dir, "a.txt"), filepath.Join(root, "a.txt")} {
+				if err := os.WriteFile(p, []byte("alpha\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			manifestPath := filepath.Join(root, "manifest.sha256")
+			if err := os.WriteFile(manifestPath, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			res := runCLI(t, context.Background(), "verify", "--manifest", manifestPath, dir)
+			if res.code != 3 {
+				t.Errorf("exit code = %d, want 3\n%s", res.code, res.stdout)
+			}
+			if !strings.Contains(res.stdout, "NG  "+tt.wantNG) {
+				t.Errorf("stdout does not report %s:\n%s", tt.wantNG, res.stdout)
+			}
+		})
+	}
+}
+
+func TestManifest_RenamedEntries(t *testing.T) {
+	// 大文字小文字のみが異なるエントリは別名で書き出され、マニフェストにも別名で記録される
+	arc := writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "A.txt", data: []byte("upper\n")},
+		{name: "a.txt", data: []byte("lower\n")},
+	})
+	out := filepath.Join(t.TempDir(), "out")
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+
+	if res := runCLI(t, context.Background(), "extract", "-o", out, "--manifest-json", manifestPath, arc); res.code != 0 {
+		t.Fatalf("extract exit code = %d (stderr: %s)", res.code, res.stderr)
+	}
+	if files := readTree(t, out); len(files) != 2 {
+		t.Fatalf("extracted %v, want 2 files", files)
+	}
+
+	for _, target := range []string{arc, out} {
+		res := runCLI(t, context.Background(), "verify", "--manifest", manifestPath, target)
+		if res.code != 0 {
+			t.Errorf("verify %s exit code = %d\n%s%s", target, res.code, res.stdout, res.stderr)
+		}
+	}
+}
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// statusOut はアーカイブを開く際の進捗メッセージの出力先
+// 一覧を JSON などで標準出力に書き出す場合は標準エラー出力に切り替えます
+var statusOut io.Writer = os.Stdout
+
+// TFPK アーカイブ用の設定 (loadKokoroOptions で読み込む)
+var (
+	kokoroKey   *pbgarc.KokoroKey
+	kokoroNames []string
+)
+
+// archiveOptions はアーカイブを開く際の共通オプション
+type archiveOptions struct {
+	archiveType int // 非推奨 (-t)
+	game        string
+	formatName  string
+	subTypeName string
+	keyFile     string
+	nameList    string
+	cryptDefs   stringList
+
+	selection *archiveSelection // --game / --format / -t から決定した形式 (nil の場合は自動判別)
+	resolved  bool
+}
+
+// register は共通オプションをフラグセットに登録します
+// --format は出力形式として使用していないサブコマンドでのみ --archive-format の別名として登録します
+// (出力形式の --format を持つサブコマンドでは、その定義の後に呼び出してください)
+func (o *archiveOptions) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.archiveType, "t", -1, "deprecated: numeric archive type (use --game or --archive-format/--subtype instead)")
+	fs.StringVar(&o.game, "game", "", "game `id` or title (e.g., th09, 東方花映塚); selects the archive format and subtype. If omitted, auto-detection is attempted.")
+	fs.StringVar(&o.formatName, "archive-format", "", "archive `format` (remilia, yukari, yumemi, suica, hinanawi, marisa, kaguya, kanako, kokoro)")
+	if fs.Lookup("format") == nil {
+		fs.StringVar(&o.formatName, "format", "", "alias for --archive-format")
+	}
+	fs.StringVar(&o.subTypeName, "subtype", "", "archive subtype `name` for --archive-format (kaguya: in, legacy, pofv; kanako: mof, ufo, td)")
+	fs.StringVar(&o.keyFile, "k", "", "RSA public key file for TFPK (.pak) archives (required to open them: no keys are built in)")
+	fs.StringVar(&o.nameList, "n", "", "file name list for resolving TFPK (.pak) entry names")
+	fs.Var(&o.cryptDefs, "crypt-def", "crypt parameter definition `file` (JSON or TOML) for kanako/kaguya archives (can be repeated)")
+	fs.BoolVar(&debugMode, "d", false, "debug mode (show more info)")
+}
+
+// resolve は --game / --format / -t から開く形式を決定します (nil の場合は自動判別)
+// 非推奨の -t が指定されている場合は、移行先のオプションを一度だけ表示します
+func (o *archiveOptions) resolve() (*archiveSelection, error) {
+	if o.resolved {
+		return o.selection, nil
+	}
+	// --game や --subtype で定義名を参照できるよう、先に定義ファイルを登録する
+	if err := loadCryptDefinitions(o.cryptDefs); err != nil {
+		return nil, err
+	}
+	specified := 0
+	for _, set := range []bool{o.game != "", o.formatName != "", o.archiveType != -1} {
+		if set {
+			specified++
+		}
+	}
+	if specified > 1 {
+		return nil, errors.New(i18n.T("--game, --format (--archive-format), -t は同時に指定できません"))
+	}
+
+	var sel *archiveSelection
+	var err error
+	switch {
+	case o.subTypeName != "" && o.formatName == "":
+		return nil, errors.New(i18n.T("--subtype は --format (--archive-format) と一緒に指定してください"))
+	case o.game != "":
+		sel, err = resolveGame(o.game)
+	case o.formatName != "":
+		sel, err = resolveFormat(o.formatName, o.subTypeName)
+	case o.archiveType != -1:
+		var replacement string
+		sel, replacement, err = legacyTypeSelection(o.archiveType)
+		if err == nil {
+			i18n.Fprintf(os.Stderr, "警告: -t %d は非推奨です (0/1 は Kaguya、2 は Kanako と解釈され、形式によって意味が異なります)。代わりに %s を使用してください\n", o.archiveType, replacement)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.selection, o.resolved = sel, true
+	return sel, nil
+}
+
+// errKokoroKeyRequired は -k を指定せずに TFPK アーカイブを開こうとした場合のエラー
+var errKokoroKeyRequired = outcome.New(outcome.KindUsage, "kokoro.key_required", "TFPK (.pak) アーカイブの RSA 公開鍵は同梱していないため、-k で鍵ファイルを指定してください")
+
+// openArchive はオプションに従ってアーカイブを開きます
+// オプションの誤りは使用方法、ファイルを読めない場合は読み書き、それ以外は形式を認識できないエラーとして返します
+func openArchive(filename string, opts *archiveOptions) (_ pbgarc.PBGArchive, err error) {
+	defer func() {
+		if errors.Is(err, pbgarc.ErrKokoroKeyRequired) {
+			err = fmt.Errorf("%w: %s", errKokoroKeyRequired, filename)
+		}
+		err = outcome.Default(outcome.KindFormat, err)
+	}()
+
+	sel, err := opts.resolve()
+	if err != nil {
+		return nil, outcome.Default(outcome.KindUsage, err)
+	}
+
+	// TFPK 用の鍵と名前リストを読み込む
+	if err := loadKokoroOptions(opts.keyFile, opts.nameList); err != nil {
+		return nil, outcome.Default(outcome.KindUsage, err)
+	}
+
+	// 存在しない・読めないファイルを形式の自動判別の失敗と区別する
+	if _, err := os.Stat(filename); err != nil {
+		return nil, i18n.Errorf("アーカイブファイルにアクセスできません: %w", err)
+	}
+
+	logFileInfo(filename)
+
+	if sel != nil {
+		// 形式が指定されている場合
+		return openSelectedArchive(filename, sel)
+	}
+	// --crypt-def の定義が対象とする作品のファイル名の場合は、その定義で開く
+	if game, ok := catalog.FromFileName(filename); ok {
+		if sel, def, ok := cryptDefSelection(game.ID); ok {
+			i18n.Fprintf(statusOut, "暗号化パラメータの定義 %s を使用します\n", def.Name)
+			return openSelectedArchive(filename, sel)
+		}
+	}
+	// 形式が指定されていない場合 (自動判別)
+	return openArchiveAuto(filename)
+}
+
+// logFileInfo はアーカイブファイルのサイズや先頭バイトをデバッグログに出力します
+func logFileInfo(filename string) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		logger.Debug("stat archive failed", pbgarc.LogKeyArchive, filename, pbgarc.LogKeyError, err)
+		return
+	}
+	// ファイルの先頭数バイトを出力
+	var header []byte
+	if file, err := os.Open(filename); err == nil {
+		buf := make([]byte, 16)
+		n, _ := file.Read(buf)
+		header = buf[:n]
+		file.Close()
+	}
+	logger.Debug("archive file", pbgarc.LogKeyArchive, filename, pbgarc.LogKeySize, fileInfo.Size(),
+		"modified", fileInfo.ModTime(), "header", hex.EncodeToString(header))
+}
+
+// describeArchive はアーカイブの形式名とサブタイプの説明を返します
+func describeArchive(archive pbgarc.PBGArchive) (format string, subType string) {
+	switch a := archive.(type) {
+	case *pbgarc.RemiliaArchive:
+		return "Remilia", ""
+	case *pbgarc.YukariArchive:
+		return "Yukari", ""
+	case *pbgarc.YumemiArchive:
+		return "Yumemi", ""
+	case *pbgarc.SuicaArchive:
+		return "Suica", ""
+	case *pbgarc.HinanawiArchive:
+		return "Hinanawi", ""
+	case *pbgarc.MarisaArchive:
+		return "Marisa", ""
+	case *pbgarc.KaguyaArchive:
+		if name := registeredSubTypeName("Kaguya", a.GetArchiveType()); name != "" {
+			return "Kaguya", name
+		}
+		return "Kaguya", fmt.Sprintf("Type %d", a.GetArchiveType())
+	case *pbgarc.KanakoArchive:
+		if name := registeredSubTypeName("Kanako", a.GetArchiveType()); name != "" {
+			return "Kanako", name
+		}
+		options := pbgarc.GetArchiveTypeOptions()
+		if t := a.GetArchiveType(); t >= 0 && t < len(options) {
+			return "Kanako", options[t]
+		}
+		return "Kanako", fmt.Sprintf("Type %d", a.GetArchiveType())
+	case *pbgarc.KokoroArchive:
+		return "Kokoro", fmt.Sprintf("TFPK version %d", a.GetVersion())
+	default:
+		return fmt.Sprintf("%T", archive), ""
+	}
+}
+
+// loadKokoroOptions は TFPK アーカイブ用の RSA 公開鍵と名前リストを読み込みます
+func loadKokoroOptions(keyPath, namesPath string) error {
+	kokoroKey, kokoroNames = nil, nil
+	if keyPath != "" {
+		f, err := os.Open(keyPath)
+		if err != nil {
+			return i18n.Errorf("鍵ファイルを開けません: %w", err)
+		}
+		defer f.Close()
+		if kokoroKey, err = pbgarc.ParseKokoroKey(f); err != nil {
+			return i18n.Errorf("鍵ファイルを読み込めません %s: %w", keyPath, err)
+		}
+	}
+	if namesPath != "" {
+		data, err := os.ReadFile(namesPath)
+		if err != nil {
+			return i18n.Errorf("名前リストを開けません: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if name := strings.TrimSpace(line); name != "" && !strings.HasPrefix(name, "#") {
+				kokoroNames = append(kokoroNames, name)
+			}
+		}
+	}
+	return nil
+}
+
+// newKokoroArchive は鍵と名前リストを設定した KokoroArchive を作成します
+func newKokoroArchive(opts ...pbgarc.Option) *pbgarc.KokoroArchive {
+	archive := pbgarc.NewKokoroArchive(opts...)
+	archive.SetKey(kokoroKey)
+	archive.AddNames(kokoroNames...)
+	return archive
+}
+
+// openSelectedArchive は指定された形式・サブタイプでアーカイブを開きます
+func openSelectedArchive(filename string, sel *archiveSelection) (pbgarc.PBGArchive, error) {
+	var targetArchive pbgarc.PBGArchive
+	targetName := sel.format
+
+	switch sel.format {
+	case "Remilia":
+		targetArchive = pbgarc.NewRemiliaArchive(withLogger())
+	case "Yukari":
+		targetArchive = pbgarc.NewYukariArchive(withLogger())
+	case "Yumemi":
+		targetArchive = pbgarc.NewYumemiArchive(withLogger())
+	case "Suica":
+		targetArchive = pbgarc.NewSuicaArchive(withLogger())
+	case "Hinanawi":
+		targetArchive = pbgarc.NewHinanawiArchive(withLogger())
+	case "Marisa":
+		targetArchive = pbgarc.NewMarisaArchive(withLogger())
+	case "Kaguya":
+		kaguyaArchive := pbgarc.NewKaguyaArchive(withLogger())
+		kaguyaArchive.SetArchiveType(sel.subType)
+		targetArchive = kaguyaArchive
+		if name := registeredSubTypeName(sel.format, sel.subType); name != "" {
+			targetName = fmt.Sprintf("%s (%s)", targetName, name)
+		} else {
+			targetName = fmt.Sprintf("%s (Type %d)", targetName, sel.subType)
+		}
+	case "Kanako":
+		kanakoArchive := pbgarc.NewKanakoArchive(withLogger())
+		kanakoArchive.SetArchiveType(sel.subType)
+		targetArchive = kanakoArchive
+		if name := registeredSubTypeName(sel.format, sel.subType); name != "" {
+			targetName = fmt.Sprintf("%s (%s)", targetName, name)
+		} else if options := pbgarc.GetArchiveTypeOptions(); sel.subType >= 0 && sel.subType < len(options) {
+			targetName = fmt.Sprintf("%s (%s)", targetName, options[sel.subType])
+		} else {
+			targetName = fmt.Sprintf("%s (Type %d)", targetName, sel.subType)
+		}
+	case "Kokoro":
+		targetArchive = newKokoroArchive(withLogger())
+	default:
+		return nil, i18n.Errorf("指定されたアーカイブ形式 %s に対応する実装が見つかりません", sel.format)
+	}
+
+	// ファイルを開く
+	ok, err := targetArchive.Open(filename)
+	if err != nil {
+		return nil, i18n.Errorf("%s としてアーカイブを開けませんでした: %w", targetName, err)
+	}
+	if !ok || !targetArchive.EnumFirst() {
+		targetArchive.Close()
+		return nil, i18n.Errorf("%s としてアーカイブを開きましたが、無効か空のようです", targetName)
+	}
+
+	i18n.Fprintf(statusOut, "%s アーカイブを開きました: %s\n", targetName, filename)
+	return targetArchive, nil
+}
+
+// guessArchiveInfoFromName はファイル名からアーカイブ形式とサブタイプを推測します
+func guessArchiveInfoFromName(filename string) (expectedFormatName string, expectedSubType int, err error) {
+	// 黄昏フロンティア作品 (TH13.5 以降) の .pak は TFPK 形式
+	if strings.HasSuffix(strings.ToLower(filename), ".pak") {
+		return string(catalog.FormatKokoro), catalog.NoSubType, nil
+	}
+	game, ok := catalog.FromFileName(filename)
+	if !ok {
+		return "", catalog.NoSubType, errors.New(i18n.T("ファイル名からゲームバージョンを特定できませんでした"))
+	}
+	return string(game.Format), game.SubType, nil
+}
+
+// アーカイブを開く (自動判別)
+func openArchiveAuto(filename string) (pbgarc.PBGArchive, error) {
+	// 各アーカイブタイプを試す
+	archiveMappings := []struct {
+		name      string
+		newFunc   interface{} // 型を interface{} に
+		needsType bool
+		baseType  int
+	}{
+		{"Remilia", pbgarc.NewRemiliaArchive, false, 0}, // TH06 (PBG3形式)
+		{"Yukari", pbgarc.NewYukariArchive, false, 0},   // TH07 (PBG4形式)
+		{"Yumemi", pbgarc.NewYumemiArchive, false, 0},
+		{"Suica", pbgarc.NewSuicaArchive, false, 0},
+		{"Hinanawi", pbgarc.NewHinanawiArchive, false, 0},
+		{"Marisa", pbgarc.NewMarisaArchive, false, 0},
+		{"Kaguya", pbgarc.NewKaguyaArchive, true, 1},
+		{"Kanako", pbgarc.NewKanakoArchive, true, 2},
+		{"Kokoro", newKokoroArchive, false, 0}, // TH13.5 以降 (TFPK形式)
+	}
+
+	// 候補リストの型も変更
+	candidates := []struct {
+		name    string
+		archive pbgarc.PBGArchive // インターフェース型で保持
+		mapping *struct {         // mapping情報も保持
+			name      string
+			newFunc   interface{}
+			needsType bool
+			baseType  int
+		}
+	}{}
+	var errorsDetected []string
+	var keyErr error
+
+	// 選択しなかった候補 (エラーの場合は全ての候補) を閉じる
+	var opened pbgarc.PBGArchive
+	defer func() {
+		for _, c := range candidates {
+			if c.archive != opened {
+				c.archive.Close()
+			}
+		}
+	}()
+
+	i18n.Fprintln(statusOut, "アーカイブ形式を自動検出中...")
+	for i := range archiveMappings {
+		mapping := &archiveMappings[i]
+		var archive pbgarc.PBGArchive
+
+		// newFunc の型に応じてインスタンス化
+		switch fn := mapping.newFunc.(type) {
+		case func(...pbgarc.Option) *pbgarc.RemiliaArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.YukariArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.YumemiArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.SuicaArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.HinanawiArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.MarisaArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.KaguyaArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.KanakoArchive:
+			archive = fn(withLogger())
+		case func(...pbgarc.Option) *pbgarc.KokoroArchive:
+			archive = fn(withLogger())
+		default:
+			// 予期しない型
+			continue
+		}
+
+		ok, err := archive.Open(filename)
+
+		if err != nil {
+			if errors.Is(err, pbgarc.ErrKokoroKeyRequired) {
+				keyErr = err // TFPK と判別できたが鍵がない
+			}
+			errorsDetected = append(errorsDetected, fmt.Sprintf("- %s (Open): %v", mapping.name, err))
+			continue
+		}
+		if !ok {
+			// Open returned false, but no error. Treat as non-candidate.
+			errorsDetected = append(errorsDetected, fmt.Sprintf("- %s (Open): returned false without error", mapping.name))
+			continue
+		}
+
+		// Open succeeded (ok=true), now check EnumFirst
+		if archive.EnumFirst() {
+			i18n.Fprintf(statusOut, "- %s: 候補として検出\n", mapping.name)
+			candidates = append(candidates, struct {
+				name    string
+				archive pbgarc.PBGArchive
+				mapping *struct {
+					name      string
+					newFunc   interface{}
+					needsType bool
+					baseType  int
+				}
+			}{mapping.name, archive, mapping})
+		} else {
+			// EnumFirst failed, record this
+			archive.Close()
+			errorsDetected = append(errorsDetected, i18n.Sprintf("- %s: 開けましたが無効か空のようです (EnumFirst failed)", mapping.name))
+		}
+	}
+
+	// ---- 自動選択ロジック ----
+	if len(candidates) == 0 {
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		errorMsg := i18n.T("対応するアーカイブ形式が見つかりませんでした。")
+		// Always show detailed errors if detection failed
+		if len(errorsDetected) > 0 {
+			errorMsg += i18n.T("\n検出時のエラー詳細:\n") + strings.Join(errorsDetected, "\n")
+		}
+		return nil, errors.New(errorMsg)
+	}
+
+	var chosenArchive pbgarc.PBGArchive
+	var chosenMapping *struct {
+		name      string
+		newFunc   interface{}
+		needsType bool
+		baseType  int
+	}
+
+	guessedFormat, guessedSubType, guessErr := guessArchiveInfoFromName(filename)
+
+	if len(candidates) == 1 {
+		i18n.Fprintf(statusOut, "形式 %s を検出しました。\n", candidates[0].name)
+		chosenArchive = candidates[0].archive
+		chosenMapping = candidates[0].mapping
+
+		// 候補が一つでも、推測と異なる場合は警告 (デバッグ用)
+		if guessErr == nil && chosenMapping.name != guessedFormat {
+			i18n.Fprintf(statusOut, "警告: 検出された形式 (%s) はファイル名から推測される形式 (%s) と異なります。\n", chosenMapping.name, guessedFormat)
+		} else if guessErr != nil {
+			logger.Debug("guess format from file name failed", pbgarc.LogKeyArchive, filename, pbgarc.LogKeyError, guessErr)
+		}
+
+	} else {
+		// 複数の候補が見つかった場合、ファイル名から推測した形式を優先
+		i18n.Fprintln(statusOut, "\n複数の候補が見つかりました:")
+		for _, c := range candidates {
+			fmt.Fprintf(statusOut, "- %s\n", c.name)
+		}
+
+		if guessErr != nil {
+			return nil, i18n.Errorf("複数の形式候補が見つかりましたが、ファイル名から形式を特定できませんでした: %w。 `--game` または `--format` オプションで形式を明示的に指定してください", guessErr)
+		}
+
+		i18n.Fprintf(statusOut, "ファイル名から %s 形式と推測します...\n", guessedFormat)
+		foundMatch := false
+		for _, c := range candidates {
+			if c.mapping.name == guessedFormat {
+				chosenArchive = c.archive
+				chosenMapping = c.mapping
+				foundMatch = true
+				i18n.Fprintf(statusOut, "%s を選択しました。\n", chosenMapping.name)
+				break
+			}
+		}
+
+		if !foundMatch {
+			return nil, i18n.Errorf("複数の形式候補が見つかりましたが、ファイル名から推測された形式 (%s) が候補内にありません。 `--game` または `--format` オプションで形式を明示的に指定してください", guessedFormat)
+		}
+	}
+
+	// 選ばれた形式が Kaguya または Kanako の場合、サブタイプを自動設定
+	if chosenMapping.needsType {
+		if guessErr != nil || guessedSubType == -1 {
+			// ファイル名からサブタイプを推測できなかった場合
+			errMsg := i18n.T("選択された形式はサブタイプ指定が必要ですが、ファイル名から自動特定できませんでした。")
+			if guessErr != nil {
+				errMsg += i18n.Sprintf(" (エラー: %v)", guessErr)
+			}
+			return nil, i18n.Errorf("%s `--game` または `--format` と `--subtype` でタイプを明示的に指定してください", errMsg)
+		}
+
+		// サブタイプを設定
+		if chosenMapping.baseType == 1 { // Kaguya
+			if kaguyaArchive, ok := chosenArchive.(*pbgarc.KaguyaArchive); ok {
+				kaguyaArchive.SetArchiveType(guessedSubType)
+				i18n.Fprintf(statusOut, "Kaguya サブタイプを %d (ファイル名から自動設定) に設定しました。\n", guessedSubType)
+			} else {
+				return nil, errors.New(i18n.T("内部エラー: KaguyaArchive への型アサーションに失敗しました"))
+			}
+		} else if chosenMapping.baseType == 2 { // Kanako
+			if kanakoArchive, ok := chosenArchive.(*pbgarc.KanakoArchive); ok {
+				options := pbgarc.GetArchiveTypeOptions()
+				if guessedSubType >= 0 && guessedSubType < len(options) {
+					kanakoArchive.SetArchiveType(guessedSubType)
+					i18n.Fprintf(statusOut, "Kanako サブタイプを %d (%s) (ファイル名から自動設定) に設定しました。\n", guessedSubType, options[guessedSubType])
+				} else {
+					return nil, i18n.Errorf("内部エラー: ファイル名から推測された Kanako サブタイプ %d が無効です", guessedSubType)
+				}
+			} else {
+				return nil, errors.New(i18n.T("内部エラー: KanakoArchive への型アサーションに失敗しました"))
+			}
+		}
+	}
+
+	i18n.Fprintf(statusOut, "%s アーカイブとして開きました: %s\n", chosenMapping.name, filename) // 最終的な形式名を表示
+	opened = chosenArchive
+	return chosenArchive, nil
+}
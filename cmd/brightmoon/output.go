@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// outputPaths はエントリ名から出力先のパスを決定します
+// 通常はエントリ名を安全な相対パスに変換し、大文字小文字のみが異なる名前は別名に変更します
+// rawNames が true の場合はエントリ名をそのまま使用します
+type outputPaths struct {
+	outDir   string
+	rawNames bool
+	names    *pbgarc.EntryNameSet
+}
+
+func newOutputPaths(outDir string, rawNames bool) *outputPaths {
+	return &outputPaths{outDir: outDir, rawNames: rawNames, names: pbgarc.NewEntryNameSet()}
+}
+
+// entryName はエントリを書き出す際の相対パス ('/' 区切り) を返します
+// 安全でない名前の場合はエラーを返します
+func (p *outputPaths) entryName(name string) (string, error) {
+	unique, renamed, err := p.mapName(name)
+	if renamed {
+		i18n.Fprintf(os.Stderr, "警告: 大文字小文字のみが異なるエントリ名が重複しているため、%s を %s として書き出します\n", name, unique)
+	}
+	return unique, err
+}
+
+// mapName はエントリ名を書き出す際の相対パスに変換します (警告は表示しません)
+// マニフェストには変換後の名前が記録されるため、マニフェストとの照合にも使用します
+func (p *outputPaths) mapName(name string) (unique string, renamed bool, err error) {
+	if p.rawNames {
+		return name, false, nil
+	}
+	safe, err := pbgarc.SanitizeEntryName(name)
+	if err != nil {
+		return "", false, err
+	}
+	unique, renamed = p.names.Add(safe)
+	return unique, renamed, nil
+}
+
+// resolve はエントリの出力先パスと相対パスを返します
+func (p *outputPaths) resolve(name string) (outPath string, relName string, err error) {
+	relName, err = p.entryName(name)
+	if err != nil {
+		return "", "", err
+	}
+	if p.rawNames {
+		return filepath.Join(p.outDir, name), relName, nil
+	}
+	outPath, err = pbgarc.JoinEntryPath(p.outDir, relName)
+	return outPath, relName, err
+}
+
+// 既存ファイルの上書き方針
+const (
+	overwriteAlways      = "always"       // 常に上書きする
+	overwriteNever       = "never"        // 既存のファイルは上書きしない
+	overwriteNewer       = "newer"        // アーカイブの方が新しい場合のみ上書きする
+	overwriteIfDifferent = "if-different" // 内容が異なる場合のみ上書きする
+)
+
+// writePolicy は既存の出力ファイルの扱いを決定します
+type writePolicy struct {
+	overwrite   string
+	resume      bool
+	archiveTime time.Time              // アーカイブファイルの更新日時 (newer の判定に使用)
+	previous    map[string]entryDigest // 前回のマニフェスト (--resume 時のハッシュ照合に使用)
+
+	skipped atomic.Int64 // スキップしたエントリ数 (並列抽出のワーカーからも更新される)
+}
+
+// newWritePolicy は抽出オプションから書き出し方針を作成します
+// --resume 指定時に前回のマニフェストが存在すれば、ハッシュの照合に使用します
+func newWritePolicy(filename string, opts *extractOptions) (*writePolicy, error) {
+	switch opts.overwrite {
+	case overwriteAlways, overwriteNever, overwriteNewer, overwriteIfDifferent:
+	default:
+		return nil, i18n.Errorf("不明な上書き方針です: %s (always, never, newer, if-different のいずれかを指定してください)", opts.overwrite)
+	}
+
+	p := &writePolicy{overwrite: opts.overwrite, resume: opts.resume}
+	if fileInfo, err := os.Stat(filename); err == nil {
+		p.archiveTime = fileInfo.ModTime()
+	}
+	if opts.resume {
+		for _, path := range []string{opts.manifestJSONPath, opts.manifestPath} {
+			if path == "" {
+				continue
+			}
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			prev, err := loadManifest(path)
+			if err != nil {
+				return nil, err
+			}
+			p.previous = prev.byName()
+			break
+		}
+	}
+	return p, nil
+}
+
+// skip は既存の出力ファイルがあるエントリを書き出さずにスキップするかを判定します
+// スキップする場合、マニフェスト用に既存ファイルのハッシュを計算して返します (m が nil の場合は計算しません)
+func (p *writePolicy) skip(outPath, relName string, entry pbgarc.PBGArchiveEntry, m *manifest) (bool, error) {
+	info, err := os.Stat(outPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	var existing *entryDigest
+	switch {
+	case p.resume && info.Size() == int64(entry.GetOriginalSize()):
+		// サイズが一致し、前回のマニフェストがあればハッシュも一致する場合は抽出済みとみなす
+		if want, ok := p.previous[entryKey(relName)]; ok {
+			got, err := hashFile(outPath, relName)
+			if err != nil || got.SHA256 != want.SHA256 {
+				return false, nil
+			}
+			existing = &got
+		}
+	case p.overwrite == overwriteNever:
+	case p.overwrite == overwriteNewer && !p.archiveTime.After(info.ModTime()):
+	default:
+		return false, nil
+	}
+
+	if m != nil {
+		if existing == nil {
+			got, err := hashFile(outPath, relName)
+			if err != nil {
+				return false, err
+			}
+			existing = &got
+		}
+		m.add(*existing)
+	}
+	p.skipped.Add(1)
+	return true, nil
+}
+
+// hashFile はファイルの SHA-256 ハッシュとサイズを計算します
+func hashFile(path, name string) (entryDigest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return entryDigest{}, err
+	}
+	defer f.Close()
+
+	dw := newDigestWriter(io.Discard)
+	if _, err := io.Copy(dw, f); err != nil {
+		return entryDigest{}, err
+	}
+	return dw.digest(name), nil
+}
+
+// createOutputTemp は outPath と同じディレクトリに、書き出し用の一時ファイルを作成します
+// os.CreateTemp は権限 0600 で作成するため、os.Create と同じく 0666 から umask を除いた権限で作成します
+func createOutputTemp(outPath string) (*os.File, error) {
+	dir, base := filepath.Dir(outPath), filepath.Base(outPath)
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(rand.Uint64(), 36)+".tmp")
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if errors.Is(err, os.ErrExist) && try < 100 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// writeEntryFile はエントリを同じディレクトリの一時ファイルに展開し、完了後にリネームして outPath に配置します
+// 途中で失敗・中断しても outPath に書きかけのファイルが残ることはありません
+// if-different の場合、既存のファイルと内容が同じであれば置き換えずに written=false を返します
+func (p *writePolicy) writeEntryFile(ctx context.Context, entry pbgarc.PBGArchiveEntry, outPath, relName string, callback func(string, interface{}) bool, prog *progress) (d entryDigest, written bool, err error) {
+	tmp, err := createOutputTemp(outPath)
+	if err != nil {
+		return entryDigest{}, false, i18n.Errorf("ファイルを作成できません: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if !written {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// バッファ付きライターを使用 (書き込みと同時にハッシュを計算)
+	writer := bufio.NewWriter(tmp)
+	dw := newDigestWriter(writer)
+	success := entry.Extract(&progressWriter{ctx: ctx, w: dw, p: prog}, callback, nil)
+	flushErr := writer.Flush()
+	closeErr := tmp.Close()
+	switch {
+	case ctx.Err() != nil:
+		// 中断された場合は書き込み途中の一時ファイルを削除する
+		return entryDigest{}, false, ctx.Err()
+	case flushErr != nil:
+		return entryDigest{}, false, i18n.Errorf("ファイル書き込み(Flush)に失敗しました: %w", flushErr)
+	case closeErr != nil:
+		return entryDigest{}, false, i18n.Errorf("ファイル書き込み(Close)に失敗しました: %w", closeErr)
+	case !success:
+		// 書き込みのエラー (bufio.Writer に残る) は上で判定済みのため、展開の失敗は形式のエラーとする
+		return entryDigest{}, false, outcome.Wrap(outcome.KindFormat, errors.New(i18n.T("抽出に失敗しました")))
+	}
+	d = dw.digest(relName)
+
+	if p.overwrite == overwriteIfDifferent {
+		if existing, err := hashFile(outPath, relName); err == nil && existing == d {
+			p.skipped.Add(1)
+			return d, false, nil
+		}
+	}
+
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		return entryDigest{}, false, i18n.Errorf("ファイルを配置できません: %w", err)
+	}
+	return d, true, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract_OverwritePolicies(t *testing.T) {
+	past := time.Now().Add(-24 * time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		args     []string
+		existing string    // 既存の a.txt の内容 (アーカイブでは "alpha\n")
+		modTime  time.Time // 既存の a.txt の更新日時
+		manifest string    // 前回のマニフェストの a.txt のハッシュ ("" の場合はマニフェストなし)
+		want     string    // 抽出後の a.txt の内容
+		skipped  int
+	}{
+		{"always", []string{"--overwrite", "always"}, "old!!\n", past, "", "alpha\n", 0},
+		{"never", []string{"--overwrite", "never"}, "old!!\n", past, "", "old!!\n", 1},
+		{"newer (既存のファイルが古い)", []string{"--overwrite", "newer"}, "old!!\n", past, "", "alpha\n", 0},
+		{"newer (既存のファイルが新しい)", []string{"--overwrite", "newer"}, "old!!\n", future, "", "old!!\n", 1},
+		{"if-different (内容が異なる)", []string{"--overwrite", "if-different"}, "old!!\n", past, "", "alpha\n", 0},
+		{"if-different (内容が同じ)", []string{"--overwrite", "if-different"}, "alpha\n", past, "", "alpha\n", 1},
+		{"resume (サイズが同じ)", []string{"--resume"}, "old!!\n", past, "", "old!!\n", 1},
+		{"resume (サイズが異なる)", []string{"--resume"}, "old\n", past, "", "alpha\n", 0},
+		{"resume (マニフェストのハッシュが異なる)", []string{"--resume"}, "old!!\n", past, strings.Repeat("00", 32), "alpha\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arc := buildTestArchive(t)
+			out := t.TempDir()
+			existing := filepath.Join(out, "a.txt")
+			if err := os.WriteFile(existing, []byte(tt.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(existing, tt.modTime, tt.modTime); err != nil {
+				t.Fatal(err)
+			}
+			args := append([]string{"extract", "-o", out}, tt.args...)
+			if tt.manifest != "" {
+				manifestPath := filepath.Join(t.TempDir(), "manifest.sha256")
+				if err := os.WriteFile(manifestPath, []byte(tt.manifest+"  a.txt\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--manifest", manifestPath)
+			}
+
+			res := runCLI(t, context.Background(), append(args, arc)...)
+			if res.code != 0 {
+				t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+			}
+			files := readTree(t, out)
+			if files["a.txt"] != tt.want {
+				t.Errorf("a.txt = %q, want %q", files["a.txt"], tt.want)
+			}
+			if files["dir/b.txt"] != "bravo\n" {
+				t.Errorf("dir/b.txt = %q, want extracted", files["dir/b.txt"])
+			}
+			if len(files) != len(testEntries) {
+				t.Errorf("output contains %d files, want %d (temporary files left?)", len(files), len(testEntries))
+			}
+			if tt.skipped > 0 && !strings.Contains(res.stdout, "1 個のファイルは既存のファイルのためスキップしました") {
+				t.Errorf("stdout does not report the skipped file:\n%s", res.stdout)
+			}
+		})
+	}
+
+	t.Run("不明な方針", func(t *testing.T) {
+		arc := buildTestArchive(t)
+		if res := runCLI(t, context.Background(), "extract", "-o", t.TempDir(), "--overwrite", "sometimes", arc); res.code != 2 {
+			t.Errorf("exit code = %d, want 2", res.code)
+		}
+	})
+}
+
+func TestExtract_Parallel(t *testing.T) {
+	arc := buildTestArchive(t)
+	for _, workers := range []string{"1", "4"} {
+		t.Run(workers, func(t *testing.T) {
+			out := t.TempDir()
+			res := runCLI(t, context.Background(), "extract", "-p", "-w", workers, "-o", out, arc)
+			if res.code != 0 {
+				t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+			}
+			files := readTree(t, out)
+			for _, e := range testEntries {
+				if files[e.name] != string(e.data) {
+					t.Errorf("%s = %q, want %q", e.name, files[e.name], e.data)
+				}
+			}
+		})
+	}
+}
+
+func TestExtract_PartialFailure(t *testing.T) {
+	arc := writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: "b.txt", data: []byte("bravo\n"), corrupt: true},
+	})
+	out := t.TempDir()
+	res := runCLI(t, context.Background(), "extract", "-o", out, arc)
+	if res.code != 4 {
+		t.Errorf("exit code = %d, want 4 (stderr: %s)", res.code, res.stderr)
+	}
+	files := readTree(t, out)
+	if len(files) != 1 || files["a.txt"] != "alpha\n" {
+		t.Errorf("extracted %v, want only a.txt (no partial b.txt or temporary files)", files)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+const (
+	// progressBarWidth は進捗バーの幅 (文字数)
+	progressBarWidth = 24
+	// progressTTYInterval は端末に進捗バーを再描画する間隔
+	progressTTYInterval = 200 * time.Millisecond
+	// progressLogInterval は端末以外に進捗を1行ずつ出力する間隔
+	progressLogInterval = 5 * time.Second
+)
+
+// progress は抽出の進捗 (エントリ数・バイト数・スループット・残り時間) を表示します
+// 出力先が端末の場合は1行の進捗バーを上書きし、それ以外の場合は一定間隔でログ行を出力します
+// 進捗の加算は並列抽出のワーカーから同時に行われるため、カウンタはアトミックに更新します
+// nil の場合は何もしません
+type progress struct {
+	out      io.Writer
+	tty      bool
+	interval time.Duration
+
+	totalEntries int64
+	totalBytes   int64
+	entries      atomic.Int64
+	bytes        atomic.Int64
+	start        time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newProgress は進捗表示を作成します
+// out が端末かどうかで表示方法を切り替えます
+func newProgress(out *os.File, totalEntries int, totalBytes int64) *progress {
+	p := &progress{
+		out:          out,
+		tty:          isTerminal(out),
+		interval:     progressLogInterval,
+		totalEntries: int64(totalEntries),
+		totalBytes:   totalBytes,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if p.tty {
+		p.interval = progressTTYInterval
+	}
+	return p
+}
+
+// isTerminal はファイルが端末 (キャラクタデバイス) かを判定します
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// countTargets は抽出対象になるエントリの数と展開後の合計サイズを数えます
+// filter.match は一致したパターンを記録するだけなので、抽出前に呼び出しても結果は変わりません
+func countTargets(archive pbgarc.PBGArchive, filter *entryFilter) (entries int, bytes int64) {
+	if !archive.EnumFirst() {
+		return 0, 0
+	}
+	do := true
+	for do {
+		if filter.match(archive.GetEntryName()) {
+			entries++
+			bytes += int64(archive.GetOriginalSize())
+		}
+		do = archive.EnumNext()
+	}
+	return entries, bytes
+}
+
+// begin は定期的な表示を開始します
+func (p *progress) begin() {
+	if p == nil {
+		return
+	}
+	p.start = time.Now()
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render(false)
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// finish は表示を停止し、最終的な進捗を表示します
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.render(true)
+}
+
+// addBytes は書き込んだバイト数を加算します
+func (p *progress) addBytes(n int) {
+	if p == nil {
+		return
+	}
+	p.bytes.Add(int64(n))
+}
+
+// entryDone はエントリ1件の処理 (抽出・スキップ・失敗) が終わったことを記録します
+// 抽出したエントリのバイト数は addBytes で加算済みのため、スキップした場合のみサイズをまとめて加算します
+func (p *progress) entryDone(entry pbgarc.PBGArchiveEntry, skipped bool) {
+	if p == nil {
+		return
+	}
+	if skipped {
+		p.bytes.Add(int64(entry.GetOriginalSize()))
+	}
+	p.entries.Add(1)
+}
+
+// render は現在の進捗を1回表示します
+func (p *progress) render(final bool) {
+	entries, bytes := p.entries.Load(), p.bytes.Load()
+	elapsed := time.Since(p.start)
+
+	var rate float64
+	if secs := elapsed.Seconds(); secs > 0 {
+		rate = float64(bytes) / secs
+	}
+	eta := "--:--"
+	if rate > 0 && bytes < p.totalBytes {
+		eta = formatDuration(time.Duration(float64(p.totalBytes-bytes) / rate * float64(time.Second)))
+	} else if bytes >= p.totalBytes {
+		eta = formatDuration(0)
+	}
+
+	stats := i18n.Sprintf("%d/%d エントリ  %s/%s  %s/s  残り %s",
+		entries, p.totalEntries, formatBytes(bytes), formatBytes(p.totalBytes), formatBytes(int64(rate)), eta)
+
+	if !p.tty {
+		i18n.Fprintf(p.out, "進捗: %s\n", stats)
+		return
+	}
+
+	filled := progressBarWidth
+	if p.totalBytes > 0 && bytes < p.totalBytes {
+		filled = int(int64(progressBarWidth) * bytes / p.totalBytes)
+	}
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+	// 行頭に戻って上書きし、前回の表示の残りを消去する
+	fmt.Fprintf(p.out, "\r[%s] %s\x1b[K", bar, stats)
+	if final {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// formatBytes はバイト数を KiB・MiB などの単位で表します
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDuration は時間を mm:ss (1時間以上は h:mm:ss) で表します
+func formatDuration(d time.Duration) string {
+	s := int64(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
+
+// progressWriter は書き込みのたびにキャンセルを確認し、書き込んだバイト数を進捗に加算するライター
+// キャンセルされた場合は書き込みを失敗させ、展開処理を途中で打ち切ります
+type progressWriter struct {
+	ctx context.Context
+	w   io.Writer
+	p   *progress
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	if err := pw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := pw.w.Write(b)
+	pw.p.addBytes(n)
+	return n, err
+}
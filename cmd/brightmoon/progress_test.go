@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract_Progress(t *testing.T) {
+	arc := buildTestArchive(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"全エントリ", nil, "進捗: 3/3 エントリ  140 B/140 B"},
+		{"絞り込み", []string{"--include", "*.txt"}, "進捗: 2/2 エントリ  12 B/12 B"},
+		{"並列", []string{"-p"}, "進捗: 3/3 エントリ  140 B/140 B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 標準出力が端末ではないため、プログレスバーの代わりにログ行を出力する
+			args := append([]string{"extract", "--progress", "-o", t.TempDir()}, tt.args...)
+			res := runCLI(t, context.Background(), append(args, arc)...)
+			if res.code != 0 {
+				t.Fatalf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+			}
+			if !strings.Contains(res.stdout, tt.want) {
+				t.Errorf("stdout does not contain %q:\n%s", tt.want, res.stdout)
+			}
+			if strings.Contains(res.stdout, "\r") {
+				t.Errorf("progress bar was drawn to a non-terminal:\n%q", res.stdout)
+			}
+		})
+	}
+}
+
+func TestExtract_Cancelled(t *testing.T) {
+	arc := buildTestArchive(t)
+
+	for _, parallel := range []bool{false, true} {
+		name := "順次"
+		if parallel {
+			name = "並列"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			out := t.TempDir()
+			manifestPath := filepath.Join(t.TempDir(), "manifest.sha256")
+			args := []string{"extract", "--progress", "-o", out, "--manifest", manifestPath}
+			if parallel {
+				args = append(args, "-p")
+			}
+			res := runCLI(t, ctx, append(args, arc)...)
+			if res.code != 130 {
+				t.Fatalf("exit code = %d, want 130 (stderr: %s)", res.code, res.stderr)
+			}
+			if !strings.Contains(res.stdout, "処理を中断しました") || !strings.Contains(res.stdout, "未処理:   3 個") {
+				t.Errorf("stdout does not report the cancellation:\n%s", res.stdout)
+			}
+			if files := readTree(t, out); len(files) != 0 {
+				t.Errorf("cancelled extraction left files: %v", files)
+			}
+			// 中断した場合も --resume で再開できるようにマニフェストを保存する
+			if _, err := os.Stat(manifestPath); err != nil {
+				t.Errorf("manifest was not written: %v", err)
+			}
+		})
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	p := &progress{}
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	pw := &progressWriter{ctx: ctx, w: &buf, p: p}
+
+	if n, err := pw.Write([]byte("abc")); n != 3 || err != nil {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if got := p.bytes.Load(); got != 3 {
+		t.Errorf("progress bytes = %d, want 3", got)
+	}
+
+	cancel()
+	if _, err := pw.Write([]byte("def")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Write() after cancel error = %v, want context.Canceled", err)
+	}
+	if buf.String() != "abc" {
+		t.Errorf("written = %q, want %q", buf.String(), "abc")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00"},
+		{1500 * time.Millisecond, "00:02"},
+		{75 * time.Second, "01:15"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+)
+
+// newReport は --report で指定されたファイルに書き出す実行レポートを作成します (path が空の場合は nil)
+func newReport(path, command string) *outcome.Report {
+	if path == "" {
+		return nil
+	}
+	return outcome.NewReport("brightmoon", version, command)
+}
+
+// finishReport はコマンドの結果を実行レポートに記録して書き出し、コマンドのエラーを返します
+// レポートを書き出せない場合はそのエラーも返します
+func finishReport(rep *outcome.Report, path string, err error) error {
+	if rep == nil {
+		return err
+	}
+	rep.Finish(err)
+	if werr := rep.WriteFile(path); werr != nil {
+		return errors.Join(err, werr)
+	}
+	return err
+}
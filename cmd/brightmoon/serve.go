@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// servedArchive は serve で公開するアーカイブ
+type servedArchive struct {
+	id      string
+	path    string
+	modTime time.Time
+	archive pbgarc.PBGArchive
+	listing *archiveListing
+
+	entries map[string]pbgarc.PBGArchiveEntry // 正規化したエントリ名 (区切り文字は '/')
+	names   []string                          // entries のキーを名前順に並べたもの
+
+	// 各形式の展開処理はアーカイブのファイル位置を共有するため、同じアーカイブの展開は1件ずつ行う
+	mu sync.Mutex
+}
+
+// archiveServer はアーカイブのエントリを HTTP (と読み取り専用の WebDAV) で公開します
+type archiveServer struct {
+	archives []*servedArchive
+	byID     map[string]*servedArchive
+	cache    *entryCache
+}
+
+// mediaTypes は mime パッケージが知らない拡張子の Content-Type
+// テキストのエントリ (cat の textExtensions) は Shift-JIS として返します
+var mediaTypes = map[string]string{
+	".wav": "audio/wav",
+	".ogg": "audio/ogg",
+	".mid": "audio/midi",
+	".bmp": "image/bmp",
+	".png": "image/png",
+	".jpg": "image/jpeg",
+	".dds": "image/vnd-ms.dds",
+}
+
+// setupServe は serve サブコマンドを設定します
+func setupServe(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	opts := &archiveOptions{}
+	opts.register(fs)
+	addr := fs.String("addr", "127.0.0.1:8080", "listen `address`")
+	webdav := fs.Bool("webdav", false, "also expose archives as a read-only WebDAV share under /dav/")
+	cacheSize := fs.Int64("cache-size", 64, "maximum size of the extracted entry cache in `MiB` (0 disables caching)")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+
+		s := &archiveServer{
+			byID:  make(map[string]*servedArchive),
+			cache: newEntryCache(*cacheSize << 20),
+		}
+		defer s.close()
+		for _, filename := range args {
+			if err := s.add(filename, opts); err != nil {
+				return err
+			}
+		}
+
+		srv := &http.Server{Addr: *addr, Handler: s.handler(*webdav)}
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.ListenAndServe() }()
+
+		i18n.Printf("http://%s/ で公開しています (Ctrl+C で終了)\n", *addr)
+		if *webdav {
+			fmt.Printf("WebDAV: http://%s/dav/\n", *addr)
+		}
+		for _, a := range s.archives {
+			i18n.Printf("  /a/%s/  %s (%s, %d エントリ)\n", a.id, a.path, a.listing.Format, len(a.names))
+		}
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+			// Ctrl+C は通常の終了として扱う
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdownCtx)
+		}
+	}
+}
+
+// add はアーカイブを開いて公開対象に追加します
+// URL に使う ID はファイル名で、重複する場合は ~2, ~3 ... を付けます
+func (s *archiveServer) add(filename string, opts *archiveOptions) error {
+	archive, err := openArchive(filename, opts)
+	if err != nil {
+		return err
+	}
+
+	id := filepath.Base(filename)
+	for i := 2; s.byID[id] != nil; i++ {
+		id = fmt.Sprintf("%s~%d", filepath.Base(filename), i)
+	}
+	a := &servedArchive{
+		id:      id,
+		path:    filename,
+		archive: archive,
+		listing: collectListing(filename, archive),
+		entries: make(map[string]pbgarc.PBGArchiveEntry),
+	}
+	if fileInfo, err := os.Stat(filename); err == nil {
+		a.modTime = fileInfo.ModTime()
+	}
+
+	if archive.EnumFirst() {
+		do := true
+		for do {
+			name, err := pbgarc.SanitizeEntryName(archive.GetEntryName())
+			if err != nil {
+				i18n.Fprintf(os.Stderr, "安全でないエントリ名のため公開しません: %v\n", err)
+			} else if _, dup := a.entries[name]; !dup {
+				a.entries[name] = archive.GetEntry()
+				a.names = append(a.names, name)
+			}
+			do = archive.EnumNext()
+		}
+	}
+	sort.Strings(a.names)
+
+	s.archives = append(s.archives, a)
+	s.byID[id] = a
+	return nil
+}
+
+// close は全アーカイブを閉じます
+func (s *archiveServer) close() {
+	for _, a := range s.archives {
+		a.archive.Close()
+	}
+}
+
+// handler はルーティングを設定したハンドラを返します
+func (s *archiveServer) handler(webdav bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.serveIndex)
+	mux.HandleFunc("GET /api/archives", s.serveArchiveList)
+	mux.HandleFunc("GET /api/archives/{id}", s.serveArchiveListing)
+	mux.HandleFunc("GET /a/{id}/{path...}", s.serveBrowse)
+	if webdav {
+		mux.HandleFunc("/dav/", s.serveDAV)
+	}
+	if !logger.Enabled(context.Background(), slog.LevelInfo) {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mux.ServeHTTP(w, r)
+		logger.Info("request", "method", r.Method, "path", r.URL.Path, pbgarc.LogKeyDuration, time.Since(start))
+	})
+}
+
+// readEntry はエントリを展開したデータを返します (キャッシュ済みであれば展開しません)
+func (s *archiveServer) readEntry(a *servedArchive, name string) ([]byte, error) {
+	return s.cache.get(a.id+"\x00"+name, func() (data []byte, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = i18n.Errorf("展開中に異常が発生しました: %v", r)
+			}
+		}()
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		entry := a.entries[name]
+		var buf bytes.Buffer
+		buf.Grow(int(entry.GetOriginalSize()))
+		if !entry.Extract(&buf, nil, nil) {
+			return nil, i18n.Errorf("抽出に失敗しました: %s", name)
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// contentTypeFor はエントリ名から Content-Type を決定します
+func contentTypeFor(name string) string {
+	if isTextEntry(name) {
+		return "text/plain; charset=Shift_JIS"
+	}
+	ext := strings.ToLower(path.Ext(name))
+	if t, ok := mediaTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// serveEntry はエントリの内容を返します
+// http.ServeContent により Range・If-Modified-Since などの条件付きリクエストに対応します
+func (s *archiveServer) serveEntry(w http.ResponseWriter, r *http.Request, a *servedArchive, name string) {
+	data, err := s.readEntry(a, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeFor(name))
+	http.ServeContent(w, r, path.Base(name), a.modTime, bytes.NewReader(data))
+}
+
+// dirEntry はディレクトリ一覧の1行分
+type dirEntry struct {
+	name  string // ディレクトリ内での名前 (ディレクトリは末尾に '/' を付けない)
+	dir   bool
+	entry pbgarc.PBGArchiveEntry
+}
+
+// readDir はディレクトリ dir ("" はルート、それ以外は末尾が '/') 直下のエントリを返します
+// dir がどのエントリの親でもない場合は false を返します
+func (a *servedArchive) readDir(dir string) ([]dirEntry, bool) {
+	var result []dirEntry
+	seen := make(map[string]bool)
+	for _, name := range a.names {
+		if !strings.HasPrefix(name, dir) {
+			continue
+		}
+		rest := name[len(dir):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			if sub := rest[:i]; !seen[sub] {
+				seen[sub] = true
+				result = append(result, dirEntry{name: sub, dir: true})
+			}
+			continue
+		}
+		result = append(result, dirEntry{name: rest, entry: a.entries[name]})
+	}
+	// ディレクトリを先に並べる
+	sort.SliceStable(result, func(i, j int) bool { return result[i].dir && !result[j].dir })
+	return result, dir == "" || len(result) > 0
+}
+
+// escapePath はパスの各要素を URL 用にエスケープします
+func escapePath(p string) string {
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+// listingTemplate はディレクトリ一覧の HTML (見出しなどは T で表示言語に翻訳します)
+var listingTemplate = template.Must(template.New("listing").Funcs(template.FuncMap{"T": i18n.T, "lang": i18n.Lang}).Parse(`<!DOCTYPE html>
+<html lang="{{lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.2em 1em; text-align: left; }
+td.size { text-align: right; font-family: monospace; }
+tr:nth-child(even) { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Parent}}<p><a href="{{.Parent}}">../</a></p>{{end}}
+<table>
+<tr><th>{{T "名前"}}</th><th>{{T "サイズ"}}</th><th>{{T "圧縮サイズ"}}</th></tr>
+{{range .Rows}}<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td class="size">{{.Size}}</td><td class="size">{{.CompressedSize}}</td></tr>
+{{else}}<tr><td colspan="3">{{T "ファイルがありません"}}</td></tr>
+{{end}}</table>
+{{if .JSON}}<p><a href="{{.JSON}}">JSON</a></p>{{end}}
+</body>
+</html>
+`))
+
+type listingRow struct {
+	Name           string
+	Href           string
+	Size           string
+	CompressedSize string
+}
+
+type listingPage struct {
+	Title  string
+	Parent string
+	JSON   string
+	Rows   []listingRow
+}
+
+// writeListingHTML はディレクトリ一覧の HTML を書き出します
+func writeListingHTML(w http.ResponseWriter, page listingPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := listingTemplate.Execute(w, page); err != nil {
+		i18n.Fprintf(os.Stderr, "HTML を書き出せません: %v\n", err)
+	}
+}
+
+// serveIndex は公開しているアーカイブの一覧を返します
+func (s *archiveServer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	page := listingPage{Title: "brightmoon", JSON: "/api/archives"}
+	for _, a := range s.archives {
+		page.Rows = append(page.Rows, listingRow{
+			Name: fmt.Sprintf("%s/ (%s)", a.id, a.listing.Format),
+			Href: "/a/" + escapePath(a.id) + "/",
+			Size: i18n.Sprintf("%d エントリ", len(a.names)),
+		})
+	}
+	writeListingHTML(w, page)
+}
+
+// serveBrowse はアーカイブ内のディレクトリ一覧またはエントリの内容を返します
+func (s *archiveServer) serveBrowse(w http.ResponseWriter, r *http.Request) {
+	a := s.byID[r.PathValue("id")]
+	if a == nil {
+		http.NotFound(w, r)
+		return
+	}
+	p := r.PathValue("path")
+	if _, ok := a.entries[p]; ok {
+		s.serveEntry(w, r, a, p)
+		return
+	}
+
+	dir := p
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	items, ok := a.readDir(dir)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if dir != p {
+		// ディレクトリは末尾に '/' を付けた URL に揃える (相対リンクのため)
+		http.Redirect(w, r, "/a/"+escapePath(a.id+"/"+dir), http.StatusMovedPermanently)
+		return
+	}
+
+	page := listingPage{
+		Title:  a.id + "/" + dir,
+		Parent: "../",
+		JSON:   "/api/archives/" + escapePath(a.id),
+	}
+	for _, item := range items {
+		row := listingRow{Name: item.name, Href: "./" + escapePath(item.name)}
+		if item.dir {
+			row.Name += "/"
+			row.Href += "/"
+		} else {
+			row.Size = fmt.Sprint(item.entry.GetOriginalSize())
+			row.CompressedSize = fmt.Sprint(item.entry.GetCompressedSize())
+		}
+		page.Rows = append(page.Rows, row)
+	}
+	writeListingHTML(w, page)
+}
+
+// archiveSummary は /api/archives で返すアーカイブの情報
+type archiveSummary struct {
+	ID      string `json:"id"`
+	Archive string `json:"archive"`
+	Format  string `json:"format"`
+	SubType string `json:"subtype,omitempty"`
+	Entries int    `json:"entries"`
+	Listing string `json:"listing_url"`
+	Browse  string `json:"browse_url"`
+}
+
+// writeJSON は v を JSON で返します
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		i18n.Fprintf(os.Stderr, "JSON を書き出せません: %v\n", err)
+	}
+}
+
+// serveArchiveList は公開しているアーカイブの一覧を JSON で返します
+func (s *archiveServer) serveArchiveList(w http.ResponseWriter, r *http.Request) {
+	summaries := make([]archiveSummary, 0, len(s.archives))
+	for _, a := range s.archives {
+		summaries = append(summaries, archiveSummary{
+			ID:      a.id,
+			Archive: a.path,
+			Format:  a.listing.Format,
+			SubType: a.listing.SubType,
+			Entries: len(a.names),
+			Listing: "/api/archives/" + escapePath(a.id),
+			Browse:  "/a/" + escapePath(a.id) + "/",
+		})
+	}
+	writeJSON(w, summaries)
+}
+
+// serveArchiveListing はアーカイブのエントリ一覧を list --format json と同じ形式で返します
+func (s *archiveServer) serveArchiveListing(w http.ResponseWriter, r *http.Request) {
+	a := s.byID[r.PathValue("id")]
+	if a == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, a.listing)
+}
+
+// WebDAV (RFC 4918) の PROPFIND 応答
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"D:multistatus"`
+	XMLNS     string        `xml:"xmlns:D,attr"`
+	Responses []davResponse `xml:"D:response"`
+}
+
+type davResponse struct {
+	Href     string      `xml:"D:href"`
+	Propstat davPropstat `xml:"D:propstat"`
+}
+
+type davPropstat struct {
+	Prop   davProp `xml:"D:prop"`
+	Status string  `xml:"D:status"`
+}
+
+type davProp struct {
+	DisplayName   string          `xml:"D:displayname"`
+	ResourceType  davResourceType `xml:"D:resourcetype"`
+	ContentLength *uint32         `xml:"D:getcontentlength,omitempty"`
+	ContentType   string          `xml:"D:getcontenttype,omitempty"`
+	LastModified  string          `xml:"D:getlastmodified"`
+}
+
+type davResourceType struct {
+	Collection *struct{} `xml:"D:collection"`
+}
+
+// davResource は PROPFIND の応答に含めるリソースを作成します
+func davResource(href, name string, modTime time.Time, entry pbgarc.PBGArchiveEntry) davResponse {
+	prop := davProp{DisplayName: name, LastModified: modTime.UTC().Format(http.TimeFormat)}
+	if entry == nil {
+		prop.ResourceType.Collection = &struct{}{}
+	} else {
+		size := entry.GetOriginalSize()
+		prop.ContentLength = &size
+		prop.ContentType = contentTypeFor(name)
+	}
+	return davResponse{Href: href, Propstat: davPropstat{Prop: prop, Status: "HTTP/1.1 200 OK"}}
+}
+
+// serveDAV は /dav/ 以下を読み取り専用の WebDAV として公開します
+// 対応するメソッドは OPTIONS・PROPFIND (Depth: 0 または 1)・GET・HEAD のみです
+func (s *archiveServer) serveDAV(w http.ResponseWriter, r *http.Request) {
+	const allow = "OPTIONS, GET, HEAD, PROPFIND"
+	w.Header().Set("DAV", "1")
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", allow)
+		return
+	case http.MethodGet, http.MethodHead, "PROPFIND":
+	default:
+		w.Header().Set("Allow", allow)
+		http.Error(w, i18n.T("読み取り専用です"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// /dav/<id>/<path> に分解する
+	rest := strings.TrimPrefix(r.URL.Path, "/dav/")
+	id, p, _ := strings.Cut(rest, "/")
+
+	var a *servedArchive
+	if id != "" {
+		if a = s.byID[id]; a == nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if a != nil {
+		if _, ok := a.entries[p]; ok {
+			if r.Method == "PROPFIND" {
+				s.writeDAV(w, []davResponse{davResource(r.URL.Path, path.Base(p), a.modTime, a.entries[p])})
+				return
+			}
+			s.serveEntry(w, r, a, p)
+			return
+		}
+	}
+
+	// コレクション (ルートまたはアーカイブ内のディレクトリ)
+	dir := p
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	self := "/dav/"
+	var items []dirEntry
+	var modTime time.Time
+	if a == nil {
+		for _, sa := range s.archives {
+			items = append(items, dirEntry{name: sa.id, dir: true})
+		}
+	} else {
+		var ok bool
+		if items, ok = a.readDir(dir); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		self += escapePath(a.id + "/" + dir)
+		modTime = a.modTime
+	}
+
+	if r.Method != "PROPFIND" {
+		page := listingPage{Title: strings.TrimPrefix(self, "/dav/")}
+		for _, item := range items {
+			row := listingRow{Name: item.name, Href: self + escapePath(item.name)}
+			if item.dir {
+				row.Name += "/"
+				row.Href += "/"
+			}
+			page.Rows = append(page.Rows, row)
+		}
+		writeListingHTML(w, page)
+		return
+	}
+
+	responses := []davResponse{davResource(self, path.Base(strings.TrimSuffix(self, "/")), modTime, nil)}
+	if r.Header.Get("Depth") != "0" {
+		// Depth: infinity は 1 として扱う
+		for _, item := range items {
+			href := self + escapePath(item.name)
+			itemTime := modTime
+			if a == nil {
+				itemTime = s.byID[item.name].modTime
+			}
+			if item.dir {
+				responses = append(responses, davResource(href+"/", item.name, itemTime, nil))
+			} else {
+				responses = append(responses, davResource(href, item.name, itemTime, item.entry))
+			}
+		}
+	}
+	s.writeDAV(w, responses)
+}
+
+// writeDAV は 207 Multi-Status の応答を書き出します
+func (s *archiveServer) writeDAV(w http.ResponseWriter, responses []davResponse) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(davMultistatus{XMLNS: "DAV:", Responses: responses}); err != nil {
+		i18n.Fprintf(os.Stderr, "WebDAV の応答を書き出せません: %v\n", err)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestServer は archives を公開する HTTP サーバーを起動します
+func newTestServer(t *testing.T, webdav bool, archives ...string) *httptest.Server {
+	t.Helper()
+
+	orig := statusOut
+	statusOut = io.Discard
+	defer func() { statusOut = orig }()
+
+	s := &archiveServer{byID: make(map[string]*servedArchive), cache: newEntryCache(1 << 20)}
+	for _, filename := range archives {
+		if err := s.add(filename, &archiveOptions{archiveType: -1}); err != nil {
+			t.Fatalf("add(%s) error = %v", filename, err)
+		}
+	}
+	ts := httptest.NewServer(s.handler(webdav))
+	t.Cleanup(func() {
+		ts.Close()
+		s.close()
+	})
+	return ts
+}
+
+// doRequest はリクエストを送信し、ステータスコード・ヘッダ・本文を返します
+func doRequest(t *testing.T, method, url string, header map[string]string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header, string(body)
+}
+
+func TestServe_HTTP(t *testing.T) {
+	arc := writePBG3Archive(t, t.TempDir(), "th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: `dir\b.txt`, data: []byte("bravo\n")},
+		{name: "c.wav", data: []byte("RIFF....WAVE")},
+		{name: "../evil.txt", data: []byte("evil\n")},
+		{name: "bad.bin", data: []byte("broken"), corrupt: true},
+	})
+	ts := newTestServer(t, false, arc)
+
+	tests := []struct {
+		name        string
+		path        string
+		header      map[string]string
+		status      int
+		contentType string
+		body        string // 本文に含まれる文字列
+	}{
+		{"アーカイブの一覧", "/", nil, http.StatusOK, "text/html; charset=utf-8", `href="/a/th06.dat/"`},
+		{"ルートのディレクトリ一覧", "/a/th06.dat/", nil, http.StatusOK, "text/html; charset=utf-8", `href="./dir/"`},
+		{"サブディレクトリの一覧", "/a/th06.dat/dir/", nil, http.StatusOK, "text/html; charset=utf-8", `href="./b.txt"`},
+		{"テキストのエントリ", "/a/th06.dat/a.txt", nil, http.StatusOK, "text/plain; charset=Shift_JIS", "alpha\n"},
+		{"区切り文字を正規化したエントリ", "/a/th06.dat/dir/b.txt", nil, http.StatusOK, "text/plain; charset=Shift_JIS", "bravo\n"},
+		{"音声のエントリ", "/a/th06.dat/c.wav", nil, http.StatusOK, "audio/wav", "RIFF"},
+		{"Range リクエスト", "/a/th06.dat/a.txt", map[string]string{"Range": "bytes=1-3"}, http.StatusPartialContent, "", "lph"},
+		{"末尾の / がないディレクトリ", "/a/th06.dat/dir", nil, http.StatusMovedPermanently, "", ""},
+		{"存在しないエントリ", "/a/th06.dat/missing.txt", nil, http.StatusNotFound, "", ""},
+		{"存在しないアーカイブ", "/a/th07.dat/", nil, http.StatusNotFound, "", ""},
+		{"安全でない名前のエントリは公開しない", "/a/th06.dat/evil.txt", nil, http.StatusNotFound, "", ""},
+		{"展開に失敗するエントリ", "/a/th06.dat/bad.bin", nil, http.StatusInternalServerError, "", ""},
+		{"WebDAV は無効", "/dav/", nil, http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, header, body := doRequest(t, http.MethodGet, ts.URL+tt.path, tt.header)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", status, tt.status, body)
+			}
+			if tt.contentType != "" && header.Get("Content-Type") != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", header.Get("Content-Type"), tt.contentType)
+			}
+			if !strings.Contains(body, tt.body) {
+				t.Errorf("body does not contain %q:\n%s", tt.body, body)
+			}
+		})
+	}
+}
+
+func TestServe_API(t *testing.T) {
+	dir := t.TempDir()
+	arc := writePBG3Archive(t, dir, "th06.dat", testEntries)
+	// 同じファイル名のアーカイブには ~2 を付けた ID を割り当てる
+	other := writePBG3Archive(t, dir, "other/th06.dat", testEntries[:1])
+	ts := newTestServer(t, false, arc, other)
+
+	_, _, body := doRequest(t, http.MethodGet, ts.URL+"/api/archives", nil)
+	var summaries []archiveSummary
+	if err := json.Unmarshal([]byte(body), &summaries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, body)
+	}
+	if len(summaries) != 2 || summaries[0].ID != "th06.dat" || summaries[1].ID != "th06.dat~2" {
+		t.Fatalf("archives = %+v", summaries)
+	}
+	if summaries[0].Format != "Remilia" || summaries[0].Entries != 3 || summaries[1].Entries != 1 {
+		t.Errorf("archives = %+v", summaries)
+	}
+
+	_, _, body = doRequest(t, http.MethodGet, ts.URL+summaries[1].Listing, nil)
+	var listing archiveListing
+	if err := json.Unmarshal([]byte(body), &listing); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, body)
+	}
+	if listing.Archive != other || len(listing.Entries) != 1 || listing.Entries[0].Name != "a.txt" {
+		t.Errorf("listing = %+v", listing)
+	}
+
+	if status, _, _ := doRequest(t, http.MethodGet, ts.URL+"/api/archives/missing.dat", nil); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", status)
+	}
+}
+
+func TestServe_WebDAV(t *testing.T) {
+	arc := buildTestArchive(t)
+	ts := newTestServer(t, true, arc)
+
+	propfind := func(path, depth string) []string {
+		t.Helper()
+		status, _, body := doRequest(t, "PROPFIND", ts.URL+path, map[string]string{"Depth": depth})
+		if status != http.StatusMultiStatus {
+			t.Fatalf("PROPFIND %s status = %d, want 207", path, status)
+		}
+		var ms struct {
+			Responses []struct {
+				Href string `xml:"href"`
+			} `xml:"response"`
+		}
+		if err := xml.Unmarshal([]byte(body), &ms); err != nil {
+			t.Fatalf("invalid XML: %v\n%s", err, body)
+		}
+		var hrefs []string
+		for _, r := range ms.Responses {
+			hrefs = append(hrefs, r.Href)
+		}
+		return hrefs
+	}
+
+	tests := []struct {
+		path  string
+		depth string
+		want  []string
+	}{
+		{"/dav/", "1", []string{"/dav/", "/dav/th06.dat/"}},
+		{"/dav/th06.dat/", "1", []string{"/dav/th06.dat/", "/dav/th06.dat/dir/", "/dav/th06.dat/a.txt", "/dav/th06.dat/c.bin"}},
+		{"/dav/th06.dat/", "0", []string{"/dav/th06.dat/"}},
+		{"/dav/th06.dat/dir/b.txt", "0", []string{"/dav/th06.dat/dir/b.txt"}},
+	}
+	for _, tt := range tests {
+		got := propfind(tt.path, tt.depth)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("PROPFIND %s (Depth: %s) = %q, want %q", tt.path, tt.depth, got, tt.want)
+		}
+	}
+
+	if status, _, body := doRequest(t, http.MethodGet, ts.URL+"/dav/th06.dat/dir/b.txt", nil); status != http.StatusOK || body != "bravo\n" {
+		t.Errorf("GET = %d %q, want 200 %q", status, body, "bravo\n")
+	}
+	if status, header, _ := doRequest(t, http.MethodOptions, ts.URL+"/dav/", nil); status != http.StatusOK || header.Get("DAV") != "1" {
+		t.Errorf("OPTIONS = %d, DAV: %q", status, header.Get("DAV"))
+	}
+	for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL"} {
+		if status, _, _ := doRequest(t, method, ts.URL+"/dav/th06.dat/a.txt", nil); status != http.StatusMethodNotAllowed {
+			t.Errorf("%s status = %d, want 405", method, status)
+		}
+	}
+}
+
+func TestServe_Command(t *testing.T) {
+	arc := buildTestArchive(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Ctrl+C (コンテキストのキャンセル) は通常の終了として扱う
+	res := runCLI(t, ctx, "serve", "--addr", "127.0.0.1:0", arc)
+	if res.code != 0 {
+		t.Errorf("exit code = %d, want 0 (stderr: %s)", res.code, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "/a/th06.dat/") {
+		t.Errorf("stdout does not list the archive:\n%s", res.stdout)
+	}
+
+	if res := runCLI(t, ctx, "serve", "--addr", "127.0.0.1:0", arc+".missing"); res.code != 5 {
+		t.Errorf("exit code for a missing archive = %d, want 5", res.code)
+	}
+}
+
+func TestEntryCache(t *testing.T) {
+	c := newEntryCache(10)
+	loads := 0
+	load := func(data string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			loads++
+			return []byte(data), nil
+		}
+	}
+
+	c.get("a", load("aaaa"))
+	c.get("b", load("bbbb"))
+	c.get("a", load("aaaa")) // キャッシュ済み
+	if loads != 2 {
+		t.Errorf("loads = %d, want 2", loads)
+	}
+
+	c.get("c", load("cccc")) // 上限を超えるため、最も古い b を破棄する
+	c.get("a", load("aaaa"))
+	c.get("b", load("bbbb"))
+	if loads != 4 {
+		t.Errorf("loads = %d, want 4", loads)
+	}
+
+	c.get("big", load("0123456789ab")) // 上限より大きいデータはキャッシュしない
+	c.get("big", load("0123456789ab"))
+	if loads != 6 {
+		t.Errorf("loads = %d, want 6", loads)
+	}
+
+	failing := func() ([]byte, error) { loads++; return nil, errors.New("failed") }
+	if _, err := c.get("err", failing); err == nil {
+		t.Error("get() error = nil, want failure")
+	}
+	c.get("err", failing) // 失敗はキャッシュしない
+	if loads != 8 {
+		t.Errorf("loads = %d, want 8", loads)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"os"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+// errNoTerminal は端末の操作に対応していない OS の場合のエラー
+var errNoTerminal = i18n.NewError("terminal.unsupported", "この OS では端末の操作に対応していません")
+
+func makeRaw(f *os.File) (restore func(), err error) {
+	return nil, errNoTerminal
+}
+
+func terminalSize(f *os.File) (cols, rows int, err error) {
+	return 0, 0, errNoTerminal
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
+)
+
+// entryStatus はエントリ1件分の検証結果
+type entryStatus struct {
+	name     string
+	problems []string
+}
+
+func (s *entryStatus) fail(format string, args ...interface{}) {
+	s.problems = append(s.problems, i18n.Sprintf(format, args...))
+}
+
+// setupVerify は verify サブコマンドを設定します
+func setupVerify(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	opts := &archiveOptions{}
+	opts.register(fs)
+	manifestPath := fs.String("manifest", "", "verify against a sha256sum or JSON `manifest` (targets may be archives or extracted directories)")
+
+	return func(ctx context.Context, args []string) error {
+		if err := requireArgs(fs, args, 1); err != nil {
+			return err
+		}
+
+		var expected *manifest
+		if *manifestPath != "" {
+			m, err := loadManifest(*manifestPath)
+			if err != nil {
+				return err
+			}
+			expected = m
+		}
+
+		failed := 0
+		var firstErr error
+		for i, target := range args {
+			if ctx.Err() != nil {
+				break
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := verifyTarget(target, opts, expected); err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if len(args) > 1 {
+			i18n.Printf("\n%d 個中 %d 個が正常です\n", len(args), len(args)-failed)
+		}
+		return verifyError(failed, len(args), firstErr)
+	}
+}
+
+// verifyError は検証結果をエラーにまとめます (失敗がない場合は nil)
+// 一部の対象のみ失敗した場合は outcome.KindPartial、全て失敗した場合は最初の失敗の種類のエラーです
+func verifyError(failed, total int, firstErr error) error {
+	err := errors.New(i18n.T("検証に失敗したアーカイブがあります"))
+	switch {
+	case failed == 0:
+		return nil
+	case failed < total:
+		return outcome.Wrap(outcome.KindPartial, err)
+	default:
+		return outcome.Wrap(outcome.KindOf(firstErr), err)
+	}
+}
+
+// errInvalidEntries は検証で異常が見つかったアーカイブ・ディレクトリのエラー
+var errInvalidEntries = outcome.New(outcome.KindFormat, "verify.invalid_entries", "異常のあるエントリがあります")
+
+// verifyTarget はアーカイブまたは抽出済みディレクトリを検証し、結果を表示します
+// ディレクトリはマニフェストが指定されている場合のみ検証できます
+// 異常がある場合や開けない場合は、その種類 (outcome.Kind) を持つエラーを返します
+func verifyTarget(target string, opts *archiveOptions, expected *manifest) error {
+	fileInfo, err := os.Stat(target)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "ファイルにアクセスできません: %v\n", err)
+		return err
+	}
+
+	var statuses []*entryStatus
+	if fileInfo.IsDir() {
+		if expected == nil {
+			i18n.Fprintf(os.Stderr, "ディレクトリを検証するには --manifest を指定してください: %s\n", target)
+			return outcome.Wrap(outcome.KindUsage, errors.New(i18n.T("ディレクトリを検証するには --manifest を指定してください")))
+		}
+		i18n.Printf("検証中: %s (抽出済みディレクトリ)\n", target)
+		statuses = verifyTree(target, expected)
+	} else {
+		archive, err := openArchive(target, opts)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "アーカイブを開けません %s: %v\n", target, err)
+			return err
+		}
+		defer archive.Close()
+
+		format, _ := describeArchive(archive)
+		i18n.Printf("検証中: %s (%s)\n", target, format)
+		statuses = verifyArchive(archive, fileInfo.Size(), expected)
+	}
+
+	okCount := 0
+	for _, s := range statuses {
+		if len(s.problems) == 0 {
+			okCount++
+			fmt.Printf("  OK  %s\n", s.name)
+			continue
+		}
+		for _, p := range s.problems {
+			fmt.Printf("  NG  %s: %s\n", s.name, p)
+		}
+	}
+	i18n.Printf("結果: %d 個中 %d 個のエントリが正常です\n", len(statuses), okCount)
+	if len(statuses) == 0 || okCount < len(statuses) {
+		return errInvalidEntries
+	}
+	return nil
+}
+
+// verifyArchive はアーカイブの全エントリを検証します
+// エントリの領域がファイル内に収まり互いに重複しないこと、展開結果が元サイズと一致することを確認します
+// expected が指定されている場合は展開結果のハッシュもマニフェストと照合します
+// 展開したデータは io.Discard に書き出すため、ディスクには何も書き込みません
+func verifyArchive(archive pbgarc.PBGArchive, fileSize int64, expected *manifest) []*entryStatus {
+	type region struct {
+		status       *entryStatus
+		offset, size int64
+	}
+	var statuses []*entryStatus
+	var regions []region
+
+	var want map[string]entryDigest
+	// マニフェストには抽出時に変換した名前 (安全な相対パス、大文字小文字の衝突を避けた別名) が記録されている
+	names := newOutputPaths("", false)
+	if expected != nil {
+		want = expected.byName()
+	}
+
+	if !archive.EnumFirst() {
+		return nil
+	}
+	do := true
+	for do {
+		entry := archive.GetEntry()
+		status := &entryStatus{name: entry.GetEntryName()}
+		statuses = append(statuses, status)
+
+		// 領域がファイル内に収まっているか
+		offset, _ := entryDetails(archive, entry)
+		size := int64(entry.GetCompressedSize())
+		if offset >= 0 {
+			if offset+size > fileSize {
+				status.fail("データ領域 (オフセット %d, サイズ %d) がファイルサイズ %d を超えています", offset, size, fileSize)
+			} else {
+				regions = append(regions, region{status, offset, size})
+			}
+		}
+
+		// 展開して長さとハッシュを確認
+		got, err := hashEntry(entry)
+		if err != nil {
+			status.fail("%v", err)
+		} else if wantSize := int64(entry.GetOriginalSize()); got.Size != wantSize {
+			status.fail("展開後のサイズが一致しません (期待値 %d, 実際 %d)", wantSize, got.Size)
+		}
+		if want != nil {
+			key := manifestKey(names, status.name, want)
+			if d, ok := want[key]; !ok {
+				status.fail("マニフェストに含まれていません")
+			} else if err == nil {
+				compareDigest(status, d, got)
+			}
+			delete(want, key)
+		}
+
+		do = archive.EnumNext()
+	}
+
+	// 領域の重複を確認
+	sort.SliceStable(regions, func(i, j int) bool { return regions[i].offset < regions[j].offset })
+	for i := 1; i < len(regions); i++ {
+		prev, cur := regions[i-1], regions[i]
+		if cur.size > 0 && prev.offset+prev.size > cur.offset {
+			cur.status.fail("データ領域が %s と重複しています (オフセット %d)", prev.status.name, cur.offset)
+		}
+	}
+
+	// マニフェストにあってアーカイブにないエントリ
+	missing := make([]string, 0, len(want))
+	for name := range want {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		status := &entryStatus{name: name}
+		status.fail("アーカイブに存在しません")
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// manifestKey はエントリに対応するマニフェストの名前を返します
+// 抽出時と同じ変換をした名前を優先し、マニフェストにない場合は元の名前 (--raw-names で抽出した場合) を使います
+func manifestKey(names *outputPaths, name string, want map[string]entryDigest) string {
+	if mapped, _, err := names.mapName(name); err == nil {
+		if _, ok := want[mapped]; ok {
+			return mapped
+		}
+	}
+	return entryKey(name)
+}
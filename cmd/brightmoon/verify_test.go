@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
+)
+
+func TestVerify_ExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	good := writePBG3Archive(t, dir, "good/th06.dat", testEntries)
+	corrupt := writePBG3Archive(t, dir, "corrupt/th06.dat", []testEntry{
+		{name: "a.txt", data: []byte("alpha\n")},
+		{name: "b.txt", data: []byte("bravo\n"), corrupt: true},
+	})
+	junk := filepath.Join(dir, "junk.dat")
+	if err := os.WriteFile(junk, []byte("not an archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		wantOut string
+	}{
+		{"正常", []string{good}, 0, "結果: 3 個中 3 個のエントリが正常です"},
+		{"チェックサムの不一致", []string{corrupt}, 3, "NG  b.txt"},
+		{"アーカイブではない", []string{junk}, 3, ""},
+		{"存在しない", []string{filepath.Join(dir, "missing.dat")}, 5, ""},
+		{"マニフェストなしのディレクトリ", []string{dir}, 2, ""},
+		{"一部のみ失敗", []string{good, corrupt}, 4, "2 個中 1 個が正常です"},
+		{"引数なし", nil, 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, context.Background(), append([]string{"verify"}, tt.args...)...)
+			if res.code != tt.code {
+				t.Fatalf("exit code = %d, want %d\nstdout: %s\nstderr: %s", res.code, tt.code, res.stdout, res.stderr)
+			}
+			if !strings.Contains(res.stdout, tt.wantOut) {
+				t.Errorf("stdout does not contain %q:\n%s", tt.wantOut, res.stdout)
+			}
+		})
+	}
+}
+
+func TestVerify_Cancelled(t *testing.T) {
+	arc := buildTestArchive(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res := runCLI(t, ctx, "verify", arc); res.code != 130 {
+		t.Errorf("exit code = %d, want 130", res.code)
+	}
+}
+
+func TestVerifyError(t *testing.T) {
+	formatErr := outcome.New(outcome.KindFormat, "test", "format")
+	tests := []struct {
+		name          string
+		failed, total int
+		firstErr      error
+		want          outcome.Kind
+	}{
+		{"一部のみ失敗", 1, 2, formatErr, outcome.KindPartial},
+		{"全て失敗", 2, 2, formatErr, outcome.KindFormat},
+		{"入出力エラー", 1, 1, &os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, outcome.KindIO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyError(tt.failed, tt.total, tt.firstErr)
+			if got := outcome.KindOf(err); got != tt.want {
+				t.Errorf("KindOf(verifyError()) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if err := verifyError(0, 2, nil); err != nil {
+		t.Errorf("verifyError(0, 2) = %v, want nil", err)
+	}
+}
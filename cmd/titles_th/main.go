@@ -2,16 +2,24 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
 	"github.com/shiroemons/go-brightmoon/internal/titles/app"
 	"github.com/shiroemons/go-brightmoon/internal/titles/config"
 )
 
 func main() {
+	// 表示言語の設定 (フラグの解析前に行い、設定ファイルのエラーなども選択した言語で表示する)
+	if err := i18n.Setup(i18n.ArgLang(os.Args[1:])); err != nil {
+		i18n.PrintError(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	// コマンドライン引数の解析
 	cfg := config.ParseFlags()
 
@@ -34,11 +42,12 @@ func main() {
 	application := app.New(cfg)
 	if err := application.Run(ctx); err != nil {
 		// コンテキストキャンセルの場合は特別なメッセージ
-		if err == context.Canceled {
-			fmt.Fprintf(os.Stderr, "\n処理がキャンセルされました\n")
-			os.Exit(130) // 128 + SIGINT(2)
+		if errors.Is(err, context.Canceled) {
+			i18n.Fprintf(os.Stderr, "\n処理がキャンセルされました\n")
+		} else {
+			i18n.PrintError(os.Stderr, err)
 		}
-		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
-		os.Exit(1)
+		// 終了コードはエラーの種類で決まる (README の「終了コード」を参照)
+		os.Exit(outcome.ExitCode(err))
 	}
 }
@@ -2,4 +2,7 @@ module github.com/shiroemons/go-brightmoon
 
 go 1.25
 
-require golang.org/x/text v0.31.0
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang.org/x/text v0.31.0
+)
@@ -0,0 +1,215 @@
+// Package i18n は brightmoon と titles_th のメッセージを日本語または英語で表示します
+//
+// メッセージは日本語の文字列をキーとしてメッセージカタログ (golang.org/x/text/message/catalog) から英訳を検索します
+// 書式の整形は fmt で行うため、数値の桁区切りなどの出力は表示言語によらず同じです
+// 表示言語は --lang または環境変数 (LC_ALL, LC_MESSAGES, LANG) で選択します (既定は日本語)
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+// 表示言語
+const (
+	Japanese = "ja"
+	English  = "en"
+)
+
+var (
+	mu      sync.RWMutex
+	current = Japanese
+
+	// messages は英訳のメッセージカタログ (キーは日本語のメッセージ)
+	messages = catalog.NewBuilder(catalog.Fallback(language.Japanese))
+)
+
+func init() {
+	for _, table := range []map[string]string{commonMessages, brightmoonMessages, titlesMessages} {
+		for ja, en := range table {
+			if err := messages.SetString(language.English, ja, en); err != nil {
+				panic(fmt.Sprintf("i18n: %q を登録できません: %v", ja, err))
+			}
+		}
+	}
+}
+
+// Setup は表示言語を設定します
+// 環境変数 LC_ALL, LC_MESSAGES, LANG の順に最初に設定されているものから判定し、lang が空でなければ lang を優先します
+// 環境変数が日本語以外のロケール (C, en_US.UTF-8 など) の場合は英語、いずれも未設定の場合は日本語です
+// lang が対応していない言語の場合は環境変数から判定した言語でエラーを返します
+func Setup(lang string) error {
+	SetLang(envLang())
+	if lang == "" {
+		return nil
+	}
+	l, ok := parseLang(lang)
+	if !ok {
+		return Errorf("対応していない表示言語です: %s (ja, en)", lang)
+	}
+	SetLang(l)
+	return nil
+}
+
+// envLang は環境変数から表示言語を判定します
+func envLang() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if l, ok := parseLang(v); ok {
+				return l
+			}
+			return English
+		}
+	}
+	return Japanese
+}
+
+// SetLang は表示言語 (Japanese または English) を設定します
+func SetLang(lang string) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Lang は現在の表示言語を返します
+func Lang() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// parseLang は言語名またはロケール名 (ja_JP.UTF-8 など) から表示言語を判定します
+func parseLang(s string) (string, bool) {
+	s = strings.ToLower(s)
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "c" || s == "posix" {
+		return English, true
+	}
+	tag, err := language.Parse(strings.ReplaceAll(s, "_", "-"))
+	if err != nil {
+		return "", false
+	}
+	switch base, _ := tag.Base(); base.String() {
+	case Japanese:
+		return Japanese, true
+	case English:
+		return English, true
+	}
+	return "", false
+}
+
+// ArgLang は引数から --lang (-lang) の値を取り出します (フラグの解析前に表示言語を設定するために使用します)
+// "--" 以降の引数は対象にしません
+func ArgLang(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "lang" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// renderer はカタログから検索したメッセージを受け取ります
+type renderer struct {
+	s string
+}
+
+func (r *renderer) Render(s string)       { r.s += s }
+func (r *renderer) Arg(i int) interface{} { return nil }
+
+// T はメッセージを現在の表示言語に翻訳します (英訳が登録されていない場合はそのまま返します)
+func T(key string) string {
+	if Lang() == Japanese {
+		return key
+	}
+	r := &renderer{}
+	if err := messages.Context(language.English, r).Execute(key); err != nil {
+		return key
+	}
+	return r.s
+}
+
+// Sprintf は書式を翻訳して fmt.Sprintf で整形します
+func Sprintf(format string, a ...any) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// Printf は書式を翻訳して標準出力に書き出します
+func Printf(format string, a ...any) {
+	fmt.Printf(T(format), a...)
+}
+
+// Fprintf は書式を翻訳して w に書き出します
+func Fprintf(w io.Writer, format string, a ...any) {
+	fmt.Fprintf(w, T(format), a...)
+}
+
+// Println はメッセージを翻訳して改行付きで標準出力に書き出します
+func Println(msg string) {
+	fmt.Println(T(msg))
+}
+
+// Fprintln はメッセージを翻訳して改行付きで w に書き出します
+func Fprintln(w io.Writer, msg string) {
+	fmt.Fprintln(w, T(msg))
+}
+
+// Errorf は書式を翻訳して fmt.Errorf でエラーを作成します (%w も使用できます)
+func Errorf(format string, a ...any) error {
+	return fmt.Errorf(T(format), a...)
+}
+
+// Error は表示言語によらない識別子を持つエラー
+// メッセージは Error() の呼び出し時に翻訳するため、パッケージ変数のエラーにも使用できます
+type Error struct {
+	ID  string // 識別子 (例: app.parse_thfmt)
+	msg string // 日本語のメッセージ
+}
+
+// NewError は識別子とメッセージからエラーを作成します
+func NewError(id, msg string) *Error {
+	return &Error{ID: id, msg: msg}
+}
+
+// Error は現在の表示言語のメッセージを返します
+func (e *Error) Error() string {
+	return T(e.msg)
+}
+
+// ErrorID はエラーの連鎖から最初に見つかった識別子を返します (識別子がない場合は空文字列)
+func ErrorID(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.ID
+	}
+	return ""
+}
+
+// PrintError はエラーを「エラー: メッセージ」の形式で w に書き出します
+// 識別子がある場合は「エラー [識別子]: メッセージ」とし、ログの解析などで言語によらず識別できるようにします
+func PrintError(w io.Writer, err error) {
+	if id := ErrorID(err); id != "" {
+		Fprintf(w, "エラー [%s]: %v\n", id, err)
+		return
+	}
+	Fprintf(w, "エラー: %v\n", err)
+}
@@ -0,0 +1,168 @@
+package i18n
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verbPattern は書式の動詞 (%d, %#04x, %% など) に一致します
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z%]`)
+
+// withLang はテストの間だけ表示言語を変更します
+func withLang(t *testing.T, lang string) {
+	t.Helper()
+	old := Lang()
+	SetLang(lang)
+	t.Cleanup(func() { SetLang(old) })
+}
+
+func TestCatalog_Verbs(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, table := range []map[string]string{commonMessages, brightmoonMessages, titlesMessages} {
+		for ja, en := range table {
+			if seen[ja] {
+				t.Errorf("%q is registered in multiple tables", ja)
+			}
+			seen[ja] = true
+			// 英訳は日本語と同じ書式の動詞を同じ順序で使用する
+			if got, want := verbPattern.FindAllString(en, -1), verbPattern.FindAllString(ja, -1); !slices.Equal(got, want) {
+				t.Errorf("verbs of %q = %v, want %v (%q)", en, got, want, ja)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	withLang(t, English)
+	if got := T("サブコマンド:"); got != "Subcommands:" {
+		t.Errorf("T() = %q, want %q", got, "Subcommands:")
+	}
+	// 英訳がない場合はそのまま返す
+	if got := T("未登録のメッセージ"); got != "未登録のメッセージ" {
+		t.Errorf("T() = %q, want the key", got)
+	}
+	// 数値は桁区切りなしで整形する
+	if got := Sprintf("サイズ: %d バイト\n", 1234567); got != "Size: 1234567 bytes\n" {
+		t.Errorf("Sprintf() = %q", got)
+	}
+
+	SetLang(Japanese)
+	if got := T("サブコマンド:"); got != "サブコマンド:" {
+		t.Errorf("T() = %q, want Japanese", got)
+	}
+}
+
+func TestParseLang(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"ja", Japanese, true},
+		{"ja_JP.UTF-8", Japanese, true},
+		{"en", English, true},
+		{"en_US.UTF-8", English, true},
+		{"EN-gb", English, true},
+		{"C", English, true},
+		{"POSIX", English, true},
+		{"C.UTF-8", English, true},
+		{"fr_FR.UTF-8", "", false},
+		{"???", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLang(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseLang(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	withLang(t, Japanese)
+	tests := []struct {
+		name    string
+		env     map[string]string
+		lang    string
+		want    string
+		wantErr bool
+	}{
+		{"no environment", nil, "", Japanese, false},
+		{"LANG", map[string]string{"LANG": "en_US.UTF-8"}, "", English, false},
+		{"LC_ALL overrides LANG", map[string]string{"LC_ALL": "ja_JP.UTF-8", "LANG": "en_US.UTF-8"}, "", Japanese, false},
+		{"other locale", map[string]string{"LANG": "de_DE.UTF-8"}, "", English, false},
+		{"flag overrides environment", map[string]string{"LANG": "en_US.UTF-8"}, "ja", Japanese, false},
+		{"unsupported flag", map[string]string{"LANG": "en_US.UTF-8"}, "fr", English, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+				t.Setenv(name, tt.env[name])
+			}
+			err := Setup(tt.lang)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := Lang(); got != tt.want {
+				t.Errorf("Lang() = %q, want %q", got, tt.want)
+			}
+			// 環境変数から判定した言語でエラーを表示する
+			if err != nil && err.Error() != "unsupported language: fr (ja, en)" {
+				t.Errorf("Setup() error = %q", err)
+			}
+		})
+	}
+}
+
+func TestArgLang(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"list", "--lang", "en", "th08.dat"}, "en"},
+		{[]string{"-lang=ja", "-x", "th08.dat"}, "ja"},
+		{[]string{"cat", "th08.dat", "--", "--lang"}, ""},
+		{[]string{"list", "--language", "en"}, ""},
+		{[]string{"--lang"}, ""},
+	}
+	for _, tt := range tests {
+		if got := ArgLang(tt.args); got != tt.want {
+			t.Errorf("ArgLang(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	withLang(t, English)
+	errTest := NewError("test.extract_failed", "ファイルの展開に失敗しました")
+	wrapped := Errorf("設定ファイルを読み込めません: %w", errTest)
+
+	if !errors.Is(wrapped, errTest) {
+		t.Error("Errorf() should wrap the error with %w")
+	}
+	if got := wrapped.Error(); got != "cannot read configuration file: failed to extract file" {
+		t.Errorf("Error() = %q", got)
+	}
+	// 識別子は表示言語によらない
+	if got := ErrorID(fmt.Errorf("context: %w", wrapped)); got != "test.extract_failed" {
+		t.Errorf("ErrorID() = %q, want test.extract_failed", got)
+	}
+	if got := ErrorID(errors.New("plain")); got != "" {
+		t.Errorf("ErrorID() = %q, want empty", got)
+	}
+
+	var buf bytes.Buffer
+	PrintError(&buf, wrapped)
+	PrintError(&buf, errors.New("plain"))
+	SetLang(Japanese)
+	PrintError(&buf, errTest)
+	want := "error [test.extract_failed]: cannot read configuration file: failed to extract file\n" +
+		"error: plain\n" +
+		"エラー [test.extract_failed]: ファイルの展開に失敗しました\n"
+	if got := buf.String(); got != want {
+		t.Errorf("PrintError() output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,306 @@
+package i18n
+
+// brightmoonMessages は brightmoon (cmd/brightmoon, pkg/pbgarc のコールバック) のメッセージの英訳
+var brightmoonMessages = map[string]string{
+	// commands.go
+	"サブコマンド:": "Subcommands:",
+	"各サブコマンドの詳細は 'brightmoon help <サブコマンド>' を参照してください。": "Run 'brightmoon help <subcommand>' for details on each subcommand.",
+	"使用方法: brightmoon <サブコマンド> [オプション] <アーカイブファイル>":     "Usage: brightmoon <subcommand> [options] <archive file>",
+	"不明なサブコマンドです: %s":                                            "unknown subcommand: %s",
+	"使用方法: brightmoon %s %s\n\n%s\n":                             "Usage: brightmoon %s %s\n\n%s\n",
+	"\nオプション:":                                                   "\nOptions:",
+	"\n処理がキャンセルされました\n":                                          "\nOperation cancelled\n",
+	"[オプション] <アーカイブファイル>":                                        "[options] <archive file>",
+	"アーカイブ内のファイル一覧を表示します":                                        "List the files in an archive",
+	"[オプション] <アーカイブファイル> [抽出ファイル...]":                            "[options] <archive file> [files to extract...]",
+	"アーカイブからファイルを抽出します":                                          "Extract files from an archive",
+	"[オプション] <ディレクトリ|glob|アーカイブファイル...>":                         "[options] <directory|glob|archive file...>",
+	"複数のアーカイブを <出力先>/<ゲーム ID>/ に一括で抽出します":                        "Extract multiple archives into <output>/<game ID>/ in one run",
+	"アーカイブの形式やエントリ数などの情報を表示します":                                  "Show archive information such as the format and number of entries",
+	"[オプション] <アーカイブファイル...>":                                     "[options] <archive file...>",
+	"アーカイブの内容から作品・形式 (製品版/体験版) を判定します":                           "Identify the game, format and edition (product/trial) from the archive contents",
+	"未知の Kanako (THA1) アーカイブの暗号化パラメータを総当たりで探索し、パラメータ表を出力します":     "Brute-force the crypt parameters of an unknown Kanako (THA1) archive and print the parameter table",
+	"アーカイブの内容を端末上で閲覧・プレビューし、選択したエントリを抽出します":                      "Browse and preview an archive in the terminal and extract the selected entries",
+	"[オプション] <アーカイブファイル> <エントリ名...>":                             "[options] <archive file> <entry name...>",
+	"指定したエントリの内容を標準出力に書き出します":                                    "Write the contents of the given entries to standard output",
+	"[オプション] <アーカイブファイル> [エントリ名...]":                             "[options] <archive file> [entry name...]",
+	"-o <アーカイブファイル> [オプション] <ファイル|ディレクトリ...>":                    "-o <archive file> [options] <file|directory...>",
+	"ファイルから PBG3 形式 (東方紅魔郷) のアーカイブを作成します":                        "Create a PBG3 (th06) archive from files",
+	"アーカイブの内容を tar または zip 形式で書き出します":                            "Write the archive contents as a tar or zip file",
+	"アーカイブ内の全エントリを展開して破損がないか検証します":                               "Decompress every entry in an archive and check for corruption",
+	"[オプション] <比較元アーカイブ> <比較先アーカイブ>":                              "[options] <old archive> <new archive>",
+	"2つのアーカイブのエントリの追加・削除・変更を表示します":                               "Show entries added, removed and changed between two archives",
+	"アーカイブを HTTP (とオプションで WebDAV) で公開し、ブラウザから閲覧・ダウンロードできるようにします": "Serve archives over HTTP (and optionally WebDAV) for browsing and downloading",
+	"バージョン情報を表示します":                                              "Show version information",
+	"[サブコマンド]":          "[subcommand]",
+	"サブコマンドの使用方法を表示します": "Show usage for a subcommand",
+
+	// legacy.go
+	"       brightmoon [オプション] <アーカイブファイル> [抽出ファイル...]": "       brightmoon [options] <archive file> [files to extract...]",
+	"オプション (サブコマンドなしの場合):":                              "Options (without a subcommand):",
+
+	// batch.go
+	"\n%d 個のアーカイブを抽出中...\n":              "\nExtracting %d archives...\n",
+	"\n処理を中断しました (書き込み途中のファイルは削除しました)\n": "\nInterrupted (partially written files were removed)\n",
+	"一部のアーカイブで抽出に失敗しました":                 "extraction failed for some archives",
+	"全てのアーカイブで抽出に失敗しました: %w":             "extraction failed for all archives: %w",
+	"glob パターンが不正です %q: %v":              "invalid glob pattern %q: %v",
+	"ファイルにアクセスできません: %w":                 "cannot access file: %w",
+	"ディレクトリを走査できません: %w":                 "cannot walk directory: %w",
+	"抽出するアーカイブが見つかりませんでした":               "no archives to extract were found",
+	"アーカイブ": "Archive",
+	"ゲーム":   "Game",
+	"形式":    "Format",
+	"エントリ":  "Entries",
+	"バイト数":  "Bytes",
+	"スキップ":  "Skipped",
+	"失敗":    "Failed",
+	"形式を検出できませんでした":       "format could not be detected",
+	"アーカイブではないためスキップしました": "skipped (not an archive)",
+	"中断したため開いていません":       "not opened (interrupted)",
+	"合計": "Total",
+	"アーカイブを開けません %s: %v\n":          "cannot open archive %s: %v\n",
+	"警告: パターンに一致するファイルがありません: %s\n": "warning: no files match the pattern: %s\n",
+	"[%s] 抽出に失敗しました: %s - %v\n":     "[%s] extraction failed: %s - %v\n",
+	"既存のファイルを確認できません %s: %v\n":      "cannot check existing file %s: %v\n",
+	"抽出結果:": "Extraction results:",
+
+	// browse.go
+	"browse は端末から実行してください":      "browse must be run from a terminal",
+	"端末を設定できません: %v":            "cannot configure the terminal: %v",
+	"抽出に失敗しました: %s":             "extraction failed: %s",
+	"抽出するエントリを Space で選択してください": "Select entries to extract with Space",
+	"%d 個のファイルを %s に抽出しました":     "Extracted %d files to %s",
+	" (%d 個は失敗しました)":            " (%d failed)",
+	"%s (%s, %d エントリ)":          "%s (%s, %d entries)",
+	"名前":                        "Name",
+	"元サイズ":                      "Size",
+	"圧縮サイズ":                     "Compressed",
+	"圧縮率":                       "Ratio",
+	"↑↓:移動 Enter/→:開く ←:閉じる Space:選択 a:全選択 t:テキスト x:16進 e:選択を抽出 q:終了": "↑↓:move Enter/→:open ←:close Space:mark a:mark all t:text x:hex e:extract marked q:quit",
+	"テキスト (Shift-JIS)": "text (Shift-JIS)",
+	"16進ダンプ":           "hex dump",
+	"%s  %s  %d バイト":   "%s  %s  %d bytes",
+	"%d-%d/%d 行  ↑↓/PgUp/PgDn:スクロール t:テキスト x:16進 q/Esc:戻る": "lines %d-%d/%d  ↑↓/PgUp/PgDn:scroll t:text x:hex q/Esc:back",
+
+	// cat.go
+	"一部のエントリを出力できませんでした": "some entries could not be written",
+	"エントリが見つかりません: %s\n": "entry not found: %s\n",
+
+	// cryptdef.go
+	"暗号化パラメータの定義を読み込めません: %w":      "cannot load crypt parameter definition: %w",
+	"暗号化パラメータの定義を登録できません (%s): %w": "cannot register crypt parameter definition (%s): %w",
+
+	// cryptscan.go
+	"--max-limit には 0x100 以上の値を指定してください: %#x":         "--max-limit must be 0x100 or greater: %#x",
+	"不明な出力形式です: %s (text, json, toml のいずれかを指定してください)": "unknown output format: %s (use text, json or toml)",
+	"Kanako (THA1) アーカイブとして開けません: %w":                 "cannot open as a Kanako (THA1) archive: %w",
+	"一部のスロットの暗号化パラメータを特定できませんでした":                     "crypt parameters could not be determined for some slots",
+	"%s から推定した暗号化パラメータ":                               "crypt parameters estimated from %s",
+	"%s の暗号化パラメータを探索しています...\n":                       "Searching crypt parameters of %s...\n",
+	"ファイル: %s\n":          "File: %s\n",
+	"スロット %d: ":           "Slot %d: ",
+	"エントリなし":              "no entries",
+	"見つかりません (エントリ %d)\n": "not found (%d entries)\n",
+	"limit=不明":            "limit=unknown",
+	"limit>=%#04x (未確定)":  "limit>=%#04x (not determined)",
+	" 得点 %d":              " score %d",
+	" 展開できたエントリ %d/%d\n":  " decompressed entries %d/%d\n",
+	"既知のパラメータ表 (--format kanako --subtype %s) と一致します。\n":                                          "Matches the known parameter table (--format kanako --subtype %s).\n",
+	"既知のパラメータ表とは一致しません。--format json の出力を --crypt-def で読み込むか、以下を pkg/pbgarc/kanako.go に追加してください:": "Does not match any known parameter table. Load the --format json output with --crypt-def, or add the following to pkg/pbgarc/kanako.go:",
+	"// %s から推定した暗号化パラメータ\n":                                                                      "// crypt parameters estimated from %s\n",
+	" // 不明":                " // unknown",
+	" // limit 不明":          " // limit unknown",
+	" // limit 未確定 (この値以上)": " // limit not determined (at least this value)",
+
+	// diff.go
+	"不明な出力形式です: %s (text, json のいずれかを指定してください)": "unknown output format: %s (use text or json)",
+	"- %s (%d バイト)\n":                      "- %s (%d bytes)\n",
+	"+ %s (%d バイト)\n":                      "+ %s (%d bytes)\n",
+	"M %s (%d → %d バイト)\n":                 "M %s (%d → %d bytes)\n",
+	"M %s (%d バイト, 内容のみ変更)\n":              "M %s (%d bytes, content changed)\n",
+	"差分はありません":                             "No differences",
+	"\n追加: %d, 削除: %d, 変更: %d, 変更なし: %d\n": "\nAdded: %d, removed: %d, changed: %d, unchanged: %d\n",
+
+	// export.go
+	"不明な出力形式です: %s (tar, zip のいずれかを指定してください)": "unknown output format: %s (use tar or zip)",
+	"アーカイブにファイルがありません":                        "the archive contains no files",
+	"%s を追加できません: %v":                         "cannot add %s: %v",
+	"展開後のサイズが一致しません: %s (期待値 %d, 実際 %d)":      "decompressed size mismatch: %s (expected %d, got %d)",
+	"出力ファイルを作成できません: %v":                      "cannot create output file: %v",
+	"安全でないエントリ名のためスキップしました: %v\n":             "skipped unsafe entry name: %v\n",
+	"警告: 一致するエントリが見つかりませんでした: %s\n":           "warning: no matching entry was found: %s\n",
+	"%d 個のファイルを %s 形式で書き出しました\n":              "Wrote %d files in %s format\n",
+
+	// create.go
+	"エントリ名が重複しています: %s (%s, %s)":                 "duplicate entry name: %s (%s, %s)",
+	"アーカイブに追加するファイルがありません":                       "no files to add to the archive",
+	"%d 個のファイルから %s を作成しました (%d バイト → %d バイト)\n": "Created an archive from %d files: %s (%d bytes -> %d bytes)\n",
+
+	// extract.go
+	"指定された条件に一致するファイルを抽出中...":        "Extracting files matching the given conditions...",
+	"%d 個の指定されたファイルを抽出中...\n":        "Extracting %d specified files...\n",
+	"アーカイブ内の全ファイルを抽出中...":            "Extracting all files in the archive...",
+	"  抽出済み: %d 個\n":                 "  Extracted: %d\n",
+	"  スキップ: %d 個\n":                 "  Skipped:   %d\n",
+	"  未処理:   %d 個\n":                "  Pending:   %d\n",
+	"\n%d 個のファイルを抽出しました\n":           "\nExtracted %d files\n",
+	"%d 個のファイルは既存のファイルのためスキップしました\n": "Skipped %d files that already exist\n",
+	"マニフェストを書き出しました: %s\n":           "Wrote manifest: %s\n",
+	"ファイルを抽出できませんでした: %w":            "files could not be extracted: %w",
+	"一部のファイルを抽出できませんでした: %w":         "some files could not be extracted: %w",
+	"出力ディレクトリを作成できません: %w":           "cannot create output directory: %w",
+	"抽出エラー: %s (%w)":                 "extraction error: %s (%w)",
+	"安全でないエントリ名: %s":                 "unsafe entry name: %s",
+	"ディレクトリ作成エラー: %s: %w":            "directory creation error: %s: %w",
+	"%s skipped (既存のファイル)\n":         "%s skipped (existing file)\n",
+	"抽出失敗: %s (%w)":                  "extraction failed: %s (%w)",
+	"抽出処理中にエラーが発生しました: %v\n":         "an error occurred during extraction: %v\n",
+	"\n警告: 指定されたファイル・パターンのうち、以下に一致するエントリは見つかりませんでした:\n": "\nwarning: no entries matched the following files or patterns:\n",
+	"抽出に失敗しました: %s - %v\n":    "extraction failed: %s - %v\n",
+	"ディレクトリを作成できません %s: %v\n": "cannot create directory %s: %v\n",
+
+	// filter.go
+	"正規表現が不正です %q: %v": "invalid regular expression %q: %v",
+
+	// games.go
+	"不明なアーカイブ形式です: %s (remilia, yukari, yumemi, suica, hinanawi, marisa, kaguya, kanako, kokoro のいずれかを指定してください)": "unknown archive format: %s (use remilia, yukari, yumemi, suica, hinanawi, marisa, kaguya, kanako or kokoro)",
+	"%s 形式には --subtype の指定が必要です (%s)":                                    "the %s format requires --subtype (%s)",
+	"%s 形式にはサブタイプがありません":                                                 "the %s format has no subtypes",
+	"%s 形式の不明なサブタイプです: %s (%s のいずれかを指定してください)":                           "unknown subtype for the %s format: %s (use one of %s)",
+	"不明なゲームです: %s (%s のいずれか、または東方花映塚などのタイトルを指定してください)":                   "unknown game: %s (use one of %s, or a title such as 東方花映塚)",
+	"--game th08 (または --format kaguya --subtype in)":                     "--game th08 (or --format kaguya --subtype in)",
+	"--game th09 (または --format kaguya --subtype legacy)":                 "--game th09 (or --format kaguya --subtype legacy)",
+	"--game th13 など (または --format kanako --subtype td)":                  "--game th13 etc. (or --format kanako --subtype td)",
+	"指定されたアーカイブタイプ %d は不明か、タイプ指定不要な形式です (--game または --format を使用してください)": "archive type %d is unknown or belongs to a format without types (use --game or --format)",
+
+	// identify.go
+	"%d 個のファイルで作品を特定できませんでした": "the game could not be identified for %d files",
+	"ファイルを読み込めません: %w":        "cannot read file: %w",
+	" 製品版": " (full version)",
+	" 体験版": " (trial)",
+	"アーカイブの内容 (BGM 定義ファイル)":      "archive contents (BGM definition file)",
+	"アーカイブ形式":                    "archive format",
+	"ファイル名":                      "file name",
+	"サイズ: %d バイト\n":              "Size: %d bytes\n",
+	"フィンガープリント: %s\n":            "Fingerprint: %s\n",
+	"判定: 不明 (対応する作品が見つかりませんでした)": "Result: unknown (no matching game was found)",
+	"判定: %s\n":                   "Result: %s\n",
+	"判定方法: %s\n":                 "Method: %s\n",
+	"形式: %s (%s)\n":              "Format: %s (%s)\n",
+	"形式: %s\n":                   "Format: %s\n",
+
+	// info.go
+	"ファイル情報の取得に失敗: %w":  "failed to get file information: %w",
+	"サブタイプ: %s\n":       "Subtype: %s\n",
+	"エントリ数: %d\n":       "Entries: %d\n",
+	"元サイズ合計: %d バイト\n":  "Total size: %d bytes\n",
+	"圧縮サイズ合計: %d バイト\n": "Total compressed size: %d bytes\n",
+	"圧縮率: %.1f%%\n":     "Compression ratio: %.1f%%\n",
+
+	// list.go
+	"不明な出力形式です: %s (table, json, csv, tsv のいずれかを指定してください)": "unknown output format: %s (use table, json, csv or tsv)",
+	"アーカイブ内のファイル一覧:":                                       "Files in the archive:",
+	"ファイルがありません":                                           "No files",
+
+	// manifest.go
+	"マニフェストを作成できません: %v":                "cannot create manifest: %v",
+	"マニフェストを書き込めません: %v":                "cannot write manifest: %v",
+	"マニフェストを読み込めません: %v":                "cannot read manifest: %v",
+	"JSON マニフェストの形式が不正です: %v":           "invalid JSON manifest: %v",
+	"マニフェストの %d 行目の形式が不正です":             "invalid manifest format at line %d",
+	"マニフェストの %d 行目のハッシュが不正です":           "invalid hash in manifest at line %d",
+	"サイズがマニフェストと一致しません (期待値 %d, 実際 %d)": "size does not match the manifest (expected %d, got %d)",
+	"SHA-256 がマニフェストと一致しません":            "SHA-256 does not match the manifest",
+	"ファイルを開けません: %v":                    "cannot open file: %v",
+	"ファイルを読み込めません: %v":                  "cannot read file: %v",
+
+	// open.go
+	"--game, --format (--archive-format), -t は同時に指定できません":     "--game, --format (--archive-format) and -t cannot be used together",
+	"--subtype は --format (--archive-format) と一緒に指定してください":    "--subtype must be used with --format (--archive-format)",
+	"TFPK (.pak) アーカイブの RSA 公開鍵は同梱していないため、-k で鍵ファイルを指定してください": "no RSA public key for TFPK (.pak) archives is built in; specify a key file with -k",
+	"異常のあるエントリがあります":                                          "some entries are invalid",
+	"ディレクトリを検証するには --manifest を指定してください":                      "specify --manifest to verify a directory",
+	"展開できなかったエントリがあるため、一部のエントリを比較できませんでした":                    "some entries could not be extracted, so they were not compared",
+	"鍵ファイルを開けません: %w":                                         "cannot open key file: %w",
+	"鍵ファイルを読み込めません %s: %w":                                    "cannot read key file %s: %w",
+	"名前リストを開けません: %w":                                         "cannot open name list: %w",
+	"指定されたアーカイブ形式 %s に対応する実装が見つかりません":                         "no implementation found for archive format %s",
+	"%s としてアーカイブを開けませんでした: %w":                                "cannot open the archive as %s: %w",
+	"アーカイブファイルにアクセスできません: %w":                                 "cannot access the archive file: %w",
+	"%s としてアーカイブを開きましたが、無効か空のようです":                            "opened the archive as %s, but it appears to be invalid or empty",
+	"ファイル名からゲームバージョンを特定できませんでした":                              "could not determine the game version from the file name",
+	"- %s: 開けましたが無効か空のようです (EnumFirst failed)":                "- %s: opened, but appears to be invalid or empty (EnumFirst failed)",
+	"対応するアーカイブ形式が見つかりませんでした。":                                 "no matching archive format was found.",
+	"\n検出時のエラー詳細:\n":                                          "\nDetection error details:\n",
+	"複数の形式候補が見つかりましたが、ファイル名から形式を特定できませんでした: %w。 `--game` または `--format` オプションで形式を明示的に指定してください":       "multiple format candidates were found, but the format could not be determined from the file name: %w. Specify the format explicitly with `--game` or `--format`",
+	"複数の形式候補が見つかりましたが、ファイル名から推測された形式 (%s) が候補内にありません。 `--game` または `--format` オプションで形式を明示的に指定してください": "multiple format candidates were found, but the format guessed from the file name (%s) is not among them. Specify the format explicitly with `--game` or `--format`",
+	"選択された形式はサブタイプ指定が必要ですが、ファイル名から自動特定できませんでした。":                                                     "the selected format requires a subtype, but it could not be determined from the file name.",
+	" (エラー: %v)": " (error: %v)",
+	"%s `--game` または `--format` と `--subtype` でタイプを明示的に指定してください":                          "%s Specify the type explicitly with `--game`, or `--format` and `--subtype`",
+	"内部エラー: KaguyaArchive への型アサーションに失敗しました":                                               "internal error: type assertion to KaguyaArchive failed",
+	"内部エラー: ファイル名から推測された Kanako サブタイプ %d が無効です":                                           "internal error: Kanako subtype %d guessed from the file name is invalid",
+	"内部エラー: KanakoArchive への型アサーションに失敗しました":                                               "internal error: type assertion to KanakoArchive failed",
+	"警告: -t %d は非推奨です (0/1 は Kaguya、2 は Kanako と解釈され、形式によって意味が異なります)。代わりに %s を使用してください\n": "warning: -t %d is deprecated (0/1 mean Kaguya and 2 means Kanako, with different meanings per format). Use %s instead\n",
+	"暗号化パラメータの定義 %s を使用します\n":                                                             "Using crypt parameter definition %s\n",
+	"%s アーカイブを開きました: %s\n":                                                                "Opened %s archive: %s\n",
+	"アーカイブ形式を自動検出中...":                                                                    "Detecting the archive format...",
+	"- %s: 候補として検出\n":                                                                     "- %s: detected as a candidate\n",
+	"形式 %s を検出しました。\n":                                                                    "Detected format %s.\n",
+	"警告: 検出された形式 (%s) はファイル名から推測される形式 (%s) と異なります。\n":                                     "warning: the detected format (%s) differs from the format guessed from the file name (%s).\n",
+	"\n複数の候補が見つかりました:":                                                                    "\nMultiple candidates were found:",
+	"ファイル名から %s 形式と推測します...\n":                                                            "Guessing the %s format from the file name...\n",
+	"%s を選択しました。\n":                                                                       "Selected %s.\n",
+	"Kaguya サブタイプを %d (ファイル名から自動設定) に設定しました。\n":                                           "Set the Kaguya subtype to %d (from the file name).\n",
+	"Kanako サブタイプを %d (%s) (ファイル名から自動設定) に設定しました。\n":                                      "Set the Kanako subtype to %d (%s) (from the file name).\n",
+	"%s アーカイブとして開きました: %s\n":                                                              "Opened as %s archive: %s\n",
+
+	// output.go
+	"不明な上書き方針です: %s (always, never, newer, if-different のいずれかを指定してください)": "unknown overwrite policy: %s (use always, never, newer or if-different)",
+	"ファイルを作成できません: %w":           "cannot create file: %w",
+	"抽出に失敗しました":                  "extraction failed",
+	"ファイル書き込み(Flush)に失敗しました: %w": "failed to write file (Flush): %w",
+	"ファイル書き込み(Close)に失敗しました: %w": "failed to write file (Close): %w",
+	"ファイルを配置できません: %w":           "cannot move file into place: %w",
+	"警告: 大文字小文字のみが異なるエントリ名が重複しているため、%s を %s として書き出します\n": "warning: entry names differ only in case, writing %s as %s\n",
+
+	// progress.go
+	"%d/%d エントリ  %s/%s  %s/s  残り %s": "%d/%d entries  %s/%s  %s/s  %s left",
+	"進捗: %s\n": "Progress: %s\n",
+
+	// serve.go
+	"http://%s/ で公開しています (Ctrl+C で終了)\n": "Serving on http://%s/ (press Ctrl+C to stop)\n",
+	"  /a/%s/  %s (%s, %d エントリ)\n":       "  /a/%s/  %s (%s, %d entries)\n",
+	"展開中に異常が発生しました: %v":                  "an unexpected error occurred during decompression: %v",
+	"%d エントリ":  "%d entries",
+	"読み取り専用です": "read-only",
+	"安全でないエントリ名のため公開しません: %v\n": "not serving unsafe entry name: %v\n",
+	"HTML を書き出せません: %v\n":       "cannot write HTML: %v\n",
+	"JSON を書き出せません: %v\n":       "cannot write JSON: %v\n",
+	"WebDAV の応答を書き出せません: %v\n":  "cannot write WebDAV response: %v\n",
+	"サイズ": "Size",
+
+	// tty_other.go
+	"この OS では端末の操作に対応していません": "terminal control is not supported on this OS",
+
+	// verify.go
+	"\n%d 個中 %d 個が正常です\n":                          "\n%d of %d are OK\n",
+	"検証に失敗したアーカイブがあります":                            "some archives failed verification",
+	"検証中: %s (抽出済みディレクトリ)\n":                       "Verifying: %s (extracted directory)\n",
+	"検証中: %s (%s)\n":                               "Verifying: %s (%s)\n",
+	"結果: %d 個中 %d 個のエントリが正常です\n":                   "Result: %d of %d entries are OK\n",
+	"展開に失敗しました":                                    "decompression failed",
+	"ファイルにアクセスできません: %v\n":                         "cannot access file: %v\n",
+	"ディレクトリを検証するには --manifest を指定してください: %s\n":     "use --manifest to verify a directory: %s\n",
+	"データ領域 (オフセット %d, サイズ %d) がファイルサイズ %d を超えています": "data region (offset %d, size %d) exceeds the file size %d",
+	"展開後のサイズが一致しません (期待値 %d, 実際 %d)":               "decompressed size mismatch (expected %d, got %d)",
+	"マニフェストに含まれていません":                              "not in the manifest",
+	"データ領域が %s と重複しています (オフセット %d)":                "data region overlaps %s (offset %d)",
+	"アーカイブに存在しません":                                 "not in the archive",
+
+	// pkg/pbgarc (Kanako の抽出時のコールバック)
+	"データ読込エラー!\r\n":     "data read error!\r\n",
+	"暗号化解除に失敗しました。\r\n": "decryption failed.\r\n",
+	"書き込みエラー!\r\n":      "write error!\r\n",
+	"解凍エラー!\r\n":        "decompression error!\r\n",
+}
@@ -0,0 +1,30 @@
+package i18n
+
+// commonMessages は両方のコマンドで使用するメッセージ (i18n, ログの設定, internal/userconfig, internal/outcome) の英訳
+var commonMessages = map[string]string{
+	// i18n
+	"対応していない表示言語です: %s (ja, en)": "unsupported language: %s (ja, en)",
+	"エラー [%s]: %v\n":             "error [%s]: %v\n",
+	"エラー: %v\n":                  "error: %v\n",
+
+	// ログ (cmd/brightmoon/logging.go, internal/titles/config)
+	"不明なログレベルです: %s (debug, info, warn, error)": "unknown log level: %s (debug, info, warn, error)",
+	"不明なログ形式です: %s (text, json)":                "unknown log format: %s (text, json)",
+
+	// outcome
+	"レポートを書き出せません: %w": "cannot write report: %w",
+
+	// userconfig
+	"プロファイル %s を指定しましたが、設定ファイルがありません (%s)": "profile %s was specified, but there is no configuration file (%s)",
+	"設定ファイルを読み込めません: %w":                   "cannot read configuration file: %w",
+	"%s: [%s] はテーブルで指定してください":              "%s: [%s] must be a table",
+	"%s: [profile.<名前>] の形式で指定してください":      "%s: use the form [profile.<name>]",
+	"%s: [profile.%s] はテーブルで指定してください":      "%s: [profile.%s] must be a table",
+	"%s: 不明な設定項目です: %s":                    "%s: unknown setting: %s",
+	"%s: プロファイル %s がありません (%s)":            "%s: profile %s does not exist (%s)",
+	"%s: 不明な設定項目です":                        "%s: unknown setting",
+	"配列には文字列・整数・真偽値のみ指定できます":               "arrays may contain only strings, integers and booleans",
+	"対応していない値です: %v":                       "unsupported value: %v",
+	"環境変数 %s":                              "environment variable %s",
+	"%s: 値 %q を -%s に設定できません: %w":          "%s: cannot set value %q for -%s: %w",
+}
@@ -0,0 +1,40 @@
+package i18n
+
+// titlesMessages は titles_th (cmd/titles_th, internal/titles) のメッセージの英訳
+// 出力する曲データファイルの内容 (コメント行など) はファイルの形式の一部のため翻訳しません
+var titlesMessages = map[string]string{
+	// app
+	"警告: 補足情報の読み込みに失敗しました: %v\n":                                          "warning: failed to load additional information: %v\n",
+	"THFmtの解析に失敗しました":                                                     "failed to parse THFmt",
+	"MusicCmtの解析に失敗しました":                                                  "failed to parse MusicCmt",
+	"ファイルの保存に失敗しました":                                                      "failed to save file",
+	"必要なファイルが見つかりませんでした":                                                  "required files were not found",
+	"ファイルの読み込みに失敗しました":                                                    "failed to read file",
+	"thbgm.fmt、musiccmt.txt または thbgm_tr.fmt、musiccmt_tr.txt のファイルがありません": "thbgm.fmt and musiccmt.txt, or thbgm_tr.fmt and musiccmt_tr.txt, were not found",
+
+	// archive
+	"ファイルサイズが0です":                "file size is 0",
+	"ファイルの展開に失敗しました":             "failed to extract file",
+	"アーカイブ内にファイルが見つかりません":        "no files found in the archive",
+	"サポートされていないアーカイブ形式です":        "unsupported archive format",
+	"指定されたアーカイブタイプが不明または不正です":    "the specified archive type is unknown or invalid",
+	"アーカイブを開けませんでした":             "failed to open the archive",
+	"アーカイブが無効か空のようです":            "the archive appears to be invalid or empty",
+	"アーカイブからのファイル抽出中にエラーが発生しました": "an error occurred while extracting files from the archive",
+
+	// fileutil
+	"出力先ディレクトリの作成に失敗しました":                                "failed to create the output directory",
+	"ファイルの作成に失敗しました":                                     "failed to create file",
+	"BOMの書き込みに失敗しました":                                    "failed to write BOM",
+	"内容の書き込みに失敗しました":                                     "failed to write content",
+	"カレントディレクトリを取得できませんでした":                              "failed to get the current directory",
+	"実行ファイルのパスを取得できませんでした":                               "failed to get the executable path",
+	"ディレクトリ内のファイル一覧を取得できませんでした":                          "failed to list files in the directory",
+	"複数の.datファイルが見つかりました。-archive フラグで使用するファイルを指定してください": "multiple .dat files were found. Specify the file to use with the -archive flag",
+
+	// parser
+	"文字コード変換エラー":                "character encoding conversion error",
+	"スキャンエラー":                   "scan error",
+	"readme.txtの読み込みに失敗しました":    "failed to read readme.txt",
+	"readme.txtの文字コード変換に失敗しました": "failed to convert the character encoding of readme.txt",
+}
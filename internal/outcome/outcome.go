@@ -0,0 +1,142 @@
+// Package outcome はコマンドの実行結果 (エラーの種類・終了コード・実行レポート) を扱います
+//
+// エラーは種類 (Kind) で分類し、brightmoon と titles_th は種類に応じた終了コードで終了します。
+// 各パッケージのエラー (errors.go) は New で種類と表示言語によらない識別子を持たせて定義します。
+package outcome
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+// Kind はエラーの種類
+type Kind string
+
+// エラーの種類 (レポートの kind に出力するため、値は変更しません)
+const (
+	KindUsage     Kind = "usage"     // 引数・フラグ・設定の誤り
+	KindFormat    Kind = "format"    // アーカイブやデータの形式を認識できない
+	KindPartial   Kind = "partial"   // 一部のアーカイブ・エントリの処理に失敗した
+	KindIO        Kind = "io"        // ファイルの読み書きに失敗した
+	KindCancelled Kind = "cancelled" // 処理が中断された
+	KindOther     Kind = "other"     // 上記以外
+)
+
+// 終了コード
+const (
+	ExitOK        = 0   // 成功
+	ExitFailure   = 1   // 上記以外のエラー
+	ExitUsage     = 2   // 引数・フラグ・設定の誤り
+	ExitFormat    = 3   // 形式を認識できない
+	ExitPartial   = 4   // 一部の処理に失敗した
+	ExitIO        = 5   // 読み書きに失敗した
+	ExitCancelled = 130 // 中断された (128 + SIGINT)
+)
+
+// ExitCode は種類に対応する終了コードを返します
+func (k Kind) ExitCode() int {
+	switch k {
+	case KindUsage:
+		return ExitUsage
+	case KindFormat:
+		return ExitFormat
+	case KindPartial:
+		return ExitPartial
+	case KindIO:
+		return ExitIO
+	case KindCancelled:
+		return ExitCancelled
+	default:
+		return ExitFailure
+	}
+}
+
+// Error は種類を持つエラー
+// Kind が空の場合は、ラップしたエラーの種類を使用します
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+// Error はエラーメッセージを返します
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap は元のエラーを返します
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New は種類と識別子を持つエラーを作成します (各パッケージのエラーの定義に使用します)
+// 識別子とメッセージは i18n.NewError と同じ規則で指定します
+func New(kind Kind, id, msg string) *Error {
+	return &Error{Kind: kind, Err: i18n.NewError(id, msg)}
+}
+
+// Wrap はエラーに種類を設定します (err が nil の場合は nil を返します)
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// Default は種類が分類されていないエラーに種類を設定します
+// すでに種類を持つエラー (ファイルの読み書きのエラーを含む) はそのまま返します
+func Default(kind Kind, err error) error {
+	if err == nil || KindOf(err) != KindOther {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf はエラーの種類を返します (err が nil の場合は空文字列)
+// 中断 (context.Canceled) とファイルの読み書きのエラー (*fs.PathError) は Error でなくても分類します
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return KindCancelled
+	}
+	if kind := declaredKind(err); kind != "" {
+		return kind
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return KindIO
+	}
+	return KindOther
+}
+
+// declaredKind はエラーの連鎖 (errors.Is と同じ順序) で最初に見つかった Error の種類を返します
+func declaredKind(err error) Kind {
+	if e, ok := err.(*Error); ok && e.Kind != "" {
+		return e.Kind
+	}
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := x.Unwrap(); inner != nil {
+			return declaredKind(inner)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range x.Unwrap() {
+			if kind := declaredKind(inner); kind != "" {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+// ExitCode はエラーの種類に対応する終了コードを返します (err が nil の場合は ExitOK)
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return KindOf(err).ExitCode()
+}
@@ -0,0 +1,129 @@
+package outcome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+var errTestFormat = New(KindFormat, "test.bad_header", "ヘッダが不正です")
+
+func TestKindOf(t *testing.T) {
+	_, errMissing := os.Open(filepath.Join(t.TempDir(), "missing.dat"))
+	wrapper := New("", "test.wrapper", "処理中にエラーが発生しました")
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+		code int
+	}{
+		{"nil", nil, "", ExitOK},
+		{"sentinel", errTestFormat, KindFormat, ExitFormat},
+		{"wrapped sentinel", fmt.Errorf("th08.dat: %w", errTestFormat), KindFormat, ExitFormat},
+		{"wrapper without kind", fmt.Errorf("%w: %w", wrapper, errTestFormat), KindFormat, ExitFormat},
+		{"outer kind wins", Wrap(KindPartial, errTestFormat), KindPartial, ExitPartial},
+		{"path error", fmt.Errorf("open: %w", errMissing), KindIO, ExitIO},
+		{"canceled", fmt.Errorf("extract: %w", context.Canceled), KindCancelled, ExitCancelled},
+		{"usage", Wrap(KindUsage, errors.New("bad flag")), KindUsage, ExitUsage},
+		{"plain", errors.New("plain"), KindOther, ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf() = %q, want %q", got, tt.want)
+			}
+			if got := ExitCode(tt.err); got != tt.code {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	err := fmt.Errorf("%w: th08.dat", errTestFormat)
+	if !errors.Is(err, errTestFormat) {
+		t.Error("errors.Is() should match the sentinel")
+	}
+	if got := i18n.ErrorID(err); got != "test.bad_header" {
+		t.Errorf("ErrorID() = %q, want test.bad_header", got)
+	}
+	if got := err.Error(); got != "ヘッダが不正です: th08.dat" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if got := KindOf(Default(KindFormat, errors.New("plain"))); got != KindFormat {
+		t.Errorf("Default() of unclassified error = %q, want format", got)
+	}
+	if got := KindOf(Default(KindFormat, Wrap(KindUsage, errors.New("bad flag")))); got != KindUsage {
+		t.Errorf("Default() should keep the kind, got %q", got)
+	}
+	if Default(KindFormat, nil) != nil || Wrap(KindFormat, nil) != nil {
+		t.Error("Default() and Wrap() should return nil for nil")
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := NewReport("brightmoon", "1.0", "extract")
+	ok := report.AddArchive("th08.dat")
+	ok.SetFormat("Kaguya", "IN")
+	ok.AddEntry("a.txt", StatusOK, 10, nil)
+	ok.AddEntry("b.txt", StatusSkipped, 0, nil)
+
+	partial := report.AddArchive("th10.dat")
+	partial.AddEntry("a.txt", StatusOK, 10, nil)
+	partial.AddEntry("b.txt", StatusFailed, 0, fmt.Errorf("b.txt: %w", errTestFormat))
+
+	report.AddArchive("bad.dat").Fail(errTestFormat)
+	report.AddArchive("notes.dat").Skip(nil)
+
+	// nil のレポートでも結果を集計できる
+	var none *Report
+	standalone := none.AddArchive("th06.dat")
+	standalone.AddEntry("x", StatusFailed, 0, errors.New("plain"))
+	if standalone.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", standalone.Failed())
+	}
+
+	report.Finish(Wrap(KindPartial, errors.New("partial")))
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := report.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if got.Tool != "brightmoon" || got.Command != "extract" || got.ExitCode != ExitPartial || got.Error.Kind != KindPartial {
+		t.Errorf("report = %s", data)
+	}
+	wantStatus := []Status{StatusOK, StatusPartial, StatusFailed, StatusSkipped}
+	if len(got.Archives) != len(wantStatus) {
+		t.Fatalf("got %d archives, want %d", len(got.Archives), len(wantStatus))
+	}
+	for i, want := range wantStatus {
+		if got.Archives[i].Status != want {
+			t.Errorf("archive %s status = %q, want %q", got.Archives[i].Path, got.Archives[i].Status, want)
+		}
+	}
+	if s := got.Archives[0].Summary; s != (Summary{OK: 1, Skipped: 1}) {
+		t.Errorf("summary = %+v", s)
+	}
+	failed := got.Archives[1].Entries[1]
+	if failed.Error == nil || failed.Error.Kind != KindFormat || failed.Error.ID != "test.bad_header" {
+		t.Errorf("failed entry = %+v", failed)
+	}
+}
@@ -0,0 +1,175 @@
+package outcome
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+// Status はアーカイブ・エントリの処理結果
+type Status string
+
+// 処理結果 (レポートの status に出力するため、値は変更しません)
+const (
+	StatusOK      Status = "ok"      // 成功
+	StatusSkipped Status = "skipped" // 既存のファイルなどのため処理しなかった
+	StatusFailed  Status = "failed"  // 失敗
+	StatusPartial Status = "partial" // 一部のエントリの処理に失敗した (アーカイブのみ)
+)
+
+// ErrorInfo はレポートに出力するエラー
+type ErrorInfo struct {
+	Kind    Kind   `json:"kind"`
+	ID      string `json:"id,omitempty"` // 表示言語によらない識別子 (ある場合のみ)
+	Message string `json:"message"`      // 表示言語のメッセージ
+}
+
+// NewErrorInfo はエラーからレポートに出力するエラーを作成します (err が nil の場合は nil)
+func NewErrorInfo(err error) *ErrorInfo {
+	if err == nil {
+		return nil
+	}
+	return &ErrorInfo{Kind: KindOf(err), ID: i18n.ErrorID(err), Message: err.Error()}
+}
+
+// EntryResult はエントリ1件の処理結果
+type EntryResult struct {
+	Name   string     `json:"name"`
+	Status Status     `json:"status"`
+	Size   int64      `json:"size,omitempty"` // 書き出したバイト数
+	Error  *ErrorInfo `json:"error,omitempty"`
+}
+
+// Summary はアーカイブのエントリの処理結果の件数
+type Summary struct {
+	OK      int `json:"ok"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// ArchiveResult はアーカイブ1件の処理結果
+// エントリの結果は複数の goroutine から追加できます
+type ArchiveResult struct {
+	Path    string        `json:"path"`
+	Format  string        `json:"format,omitempty"`
+	SubType string        `json:"subtype,omitempty"`
+	Status  Status        `json:"status"`
+	Error   *ErrorInfo    `json:"error,omitempty"`
+	Summary Summary       `json:"summary"`
+	Entries []EntryResult `json:"entries"`
+
+	mu sync.Mutex
+}
+
+// SetFormat はアーカイブの形式とサブタイプを設定します
+func (a *ArchiveResult) SetFormat(format, subType string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Format, a.SubType = format, subType
+}
+
+// Fail はアーカイブ全体の処理に失敗したことを記録します
+func (a *ArchiveResult) Fail(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Status, a.Error = StatusFailed, NewErrorInfo(err)
+}
+
+// Skip はアーカイブを処理しなかったことを記録します (ディレクトリ内のアーカイブでないファイルなど)
+func (a *ArchiveResult) Skip(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Status, a.Error = StatusSkipped, NewErrorInfo(err)
+}
+
+// AddEntry はエントリの処理結果を追加します
+func (a *ArchiveResult) AddEntry(name string, status Status, size int64, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Entries = append(a.Entries, EntryResult{Name: name, Status: status, Size: size, Error: NewErrorInfo(err)})
+	switch status {
+	case StatusOK:
+		a.Summary.OK++
+	case StatusSkipped:
+		a.Summary.Skipped++
+	case StatusFailed:
+		a.Summary.Failed++
+	}
+}
+
+// Failed は処理に失敗したエントリ数を返します
+func (a *ArchiveResult) Failed() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Summary.Failed
+}
+
+// finish はエントリの結果からアーカイブの処理結果を決定します (Fail・Skip で記録済みの場合は変更しません)
+func (a *ArchiveResult) finish() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case a.Status != "":
+	case a.Summary.Failed == 0:
+		a.Status = StatusOK
+	case a.Summary.OK+a.Summary.Skipped > 0:
+		a.Status = StatusPartial
+	default:
+		a.Status = StatusFailed
+	}
+}
+
+// Report は --report で書き出す実行レポート
+type Report struct {
+	Tool     string           `json:"tool"`
+	Version  string           `json:"version"`
+	Command  string           `json:"command,omitempty"`
+	ExitCode int              `json:"exit_code"`
+	Error    *ErrorInfo       `json:"error,omitempty"`
+	Archives []*ArchiveResult `json:"archives"`
+
+	mu sync.Mutex
+}
+
+// NewReport は実行レポートを作成します
+func NewReport(tool, version, command string) *Report {
+	return &Report{Tool: tool, Version: version, Command: command, Archives: []*ArchiveResult{}}
+}
+
+// AddArchive はアーカイブの処理結果を追加して返します
+// r が nil の場合はレポートに追加せずに返します (レポートを書き出さない場合も結果を集計できます)
+func (r *Report) AddArchive(path string) *ArchiveResult {
+	a := &ArchiveResult{Path: path, Entries: []EntryResult{}}
+	if r != nil {
+		r.mu.Lock()
+		r.Archives = append(r.Archives, a)
+		r.mu.Unlock()
+	}
+	return a
+}
+
+// Finish はコマンドの結果 (エラー) と終了コードを記録します
+func (r *Report) Finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ExitCode, r.Error = ExitCode(err), NewErrorInfo(err)
+	for _, a := range r.Archives {
+		a.finish()
+	}
+}
+
+// WriteFile はレポートを JSON で書き出します
+func (r *Report) WriteFile(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return i18n.Errorf("レポートを書き出せません: %w", err)
+	}
+	return nil
+}
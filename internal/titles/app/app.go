@@ -3,28 +3,35 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
 	"github.com/shiroemons/go-brightmoon/internal/titles/archive"
 	"github.com/shiroemons/go-brightmoon/internal/titles/config"
 	"github.com/shiroemons/go-brightmoon/internal/titles/fileutil"
 	"github.com/shiroemons/go-brightmoon/internal/titles/interfaces"
 	"github.com/shiroemons/go-brightmoon/internal/titles/models"
 	"github.com/shiroemons/go-brightmoon/internal/titles/parser"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
+	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
 )
 
 // App はアプリケーションのメインロジックを管理します
 type App struct {
 	config               *config.Config
-	logger               *config.DebugLogger
+	logger               *slog.Logger
 	extractor            interfaces.Extractor
 	thbgmParser          *parser.THBGMParser
 	additionalInfoParser *parser.AdditionalInfoParser
 	datFileFinder        interfaces.DatFileFinder
 	fs                   interfaces.FileSystem
+	report               *outcome.Report // --report を指定した場合のみ
 }
 
 // Options はAppの設定オプション
@@ -32,6 +39,7 @@ type Options struct {
 	FileSystem    interfaces.FileSystem
 	Extractor     interfaces.Extractor
 	DatFileFinder interfaces.DatFileFinder
+	Logger        *slog.Logger // nil の場合は設定のログレベルと形式で標準エラー出力に出力する
 }
 
 // New は新しいAppを作成します
@@ -41,7 +49,14 @@ func New(cfg *config.Config) *App {
 
 // NewWithOptions は新しいAppをオプション付きで作成します
 func NewWithOptions(cfg *config.Config, opts Options) *App {
-	logger := config.NewDebugLogger(cfg.DebugMode)
+	logger := opts.Logger
+	if logger == nil {
+		var err error
+		// ログレベルと形式は config.ParseFlags で検証済みのため、不正な値の場合はログを出力しない
+		if logger, err = config.NewLogger(os.Stderr, cfg); err != nil {
+			logger = slog.New(slog.DiscardHandler)
+		}
+	}
 
 	// デフォルトのファイルシステムを設定
 	fs := opts.FileSystem
@@ -77,7 +92,22 @@ func NewWithOptions(cfg *config.Config, opts Options) *App {
 }
 
 // Run はアプリケーションを実行します
+// --report を指定した場合は、エラーの有無によらず実行レポートを書き出します
 func (a *App) Run(ctx context.Context) error {
+	if a.config.ReportPath == "" {
+		return a.run(ctx)
+	}
+	a.report = outcome.NewReport("titles_th", config.Version, "")
+	err := a.run(ctx)
+	a.report.Finish(err)
+	if werr := a.report.WriteFile(a.config.ReportPath); werr != nil {
+		return errors.Join(err, werr)
+	}
+	return err
+}
+
+// run は曲目ファイルを生成します
+func (a *App) run(ctx context.Context) error {
 	var extractedData models.ExtractedData
 	var err error
 
@@ -108,7 +138,7 @@ func (a *App) Run(ctx context.Context) error {
 	// 補足情報の取得
 	additionalInfo := a.additionalInfoParser.CheckAdditionalInfo(extractedData.InputFile)
 	if additionalInfo.Error != nil {
-		fmt.Fprintf(os.Stderr, "警告: 補足情報の読み込みに失敗しました: %v\n", additionalInfo.Error)
+		i18n.Fprintf(os.Stderr, "警告: 補足情報の読み込みに失敗しました: %v\n", additionalInfo.Error)
 	}
 
 	// 出力の生成
@@ -123,7 +153,7 @@ func (a *App) Run(ctx context.Context) error {
 			return fmt.Errorf("%w: %w", ErrSaveFile, err)
 		}
 
-		a.logger.Printf("データを %s に保存しました\n", outputPath)
+		a.logger.Info("output saved", "path", outputPath)
 	}
 
 	// 標準出力にも表示
@@ -140,28 +170,36 @@ func (a *App) processArchive(ctx context.Context, archivePath string) (models.Ex
 		return models.ExtractedData{}, ctx.Err()
 	default:
 	}
-	a.logger.Printf("アーカイブファイル %s からデータを読み込みます...\n", archivePath)
+	a.logger.Info("reading archive", pbgarc.LogKeyArchive, archivePath)
 
 	// アーカイブが体験版かどうか判定
 	isTrial := fileutil.IsTrialVersion(archivePath)
 
-	// 検索するファイル名
-	var targetFiles []string
-	var fmtFile, cmtFile string
+	// 検索するファイル名 (作品カタログにない場合は thbgm.fmt と musiccmt.txt)
+	bgm := catalog.StreamBGM
+	if game, ok := catalog.FromFileName(archivePath); ok && game.BGM.Format != "" {
+		bgm = game.BGM
+	}
 	if isTrial {
-		targetFiles = []string{"thbgm_tr.fmt", "musiccmt_tr.txt"}
-		fmtFile = "thbgm_tr.fmt"
-		cmtFile = "musiccmt_tr.txt"
-	} else {
-		targetFiles = []string{"thbgm.fmt", "musiccmt.txt"}
-		fmtFile = "thbgm.fmt"
-		cmtFile = "musiccmt.txt"
+		bgm = bgm.Trial()
 	}
+	fmtFile, cmtFile := bgm.Format, bgm.Comment
+	targetFiles := []string{fmtFile, cmtFile}
 
 	// アーカイブからファイルを抽出
+	result := a.report.AddArchive(archivePath)
 	fileData, err := a.extractor.ExtractFiles(ctx, archivePath, a.config.ArchiveType, targetFiles)
 	if err != nil {
-		return models.ExtractedData{}, fmt.Errorf("アーカイブからのファイル抽出中にエラーが発生しました: %w", err)
+		err = fmt.Errorf("%w: %w", archive.ErrFileExtraction, err)
+		result.Fail(err)
+		return models.ExtractedData{}, err
+	}
+	for _, name := range targetFiles {
+		if data, ok := fileData[name]; ok && len(data) > 0 {
+			result.AddEntry(name, outcome.StatusOK, int64(len(data)), nil)
+		} else {
+			result.AddEntry(name, outcome.StatusFailed, 0, fmt.Errorf("%w: %s", ErrFileNotFound, name))
+		}
 	}
 
 	// データの取得
@@ -202,7 +240,7 @@ func (a *App) processAutoDetect(ctx context.Context) (models.ExtractedData, erro
 
 	if datFile != "" {
 		// .datファイルが見つかった場合
-		a.logger.Printf("自動検出したアーカイブファイル %s からデータを読み込みます...\n", filepath.Base(datFile))
+		a.logger.Debug("archive file found", pbgarc.LogKeyArchive, datFile)
 		return a.processArchive(ctx, datFile)
 	}
 
@@ -2,10 +2,14 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
 	"github.com/shiroemons/go-brightmoon/internal/titles/config"
 	"github.com/shiroemons/go-brightmoon/internal/titles/mocks"
 	"github.com/shiroemons/go-brightmoon/internal/titles/models"
@@ -183,7 +187,7 @@ func TestApp_Run(t *testing.T) {
 		{
 			name: "ローカルファイル処理が成功",
 			config: &config.Config{
-				OutputDir: ".",
+				OutputDir: t.TempDir(),
 			},
 			setupMock: func() *mocks.MockFileSystem {
 				fs := mocks.NewMockFileSystem()
@@ -203,7 +207,7 @@ func TestApp_Run(t *testing.T) {
 		{
 			name: "ローカルファイルが見つからない",
 			config: &config.Config{
-				OutputDir: ".",
+				OutputDir: t.TempDir(),
 			},
 			setupMock: func() *mocks.MockFileSystem {
 				fs := mocks.NewMockFileSystem()
@@ -276,7 +280,7 @@ func TestApp_Run_WithArchive(t *testing.T) {
 	cfg := &config.Config{
 		ArchivePath: "test.dat",
 		ArchiveType: 6,
-		OutputDir:   ".",
+		OutputDir:   t.TempDir(),
 	}
 
 	app := NewWithOptions(cfg, Options{
@@ -291,6 +295,50 @@ func TestApp_Run_WithArchive(t *testing.T) {
 	}
 }
 
+func TestApp_Run_Report(t *testing.T) {
+	mockExtractor := &mocks.MockExtractor{
+		ExtractedFiles: map[string][]byte{"thbgm.fmt": make([]byte, 52)},
+	}
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	cfg := &config.Config{
+		ArchivePath: "th06.dat",
+		ArchiveType: -1,
+		OutputDir:   t.TempDir(),
+		ReportPath:  reportPath,
+	}
+
+	app := NewWithOptions(cfg, Options{
+		FileSystem: mocks.NewMockFileSystem(),
+		Extractor:  mockExtractor,
+	})
+	err := app.Run(context.Background())
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("Run() error = %v, want ErrFileNotFound", err)
+	}
+	if code := outcome.ExitCode(err); code != outcome.ExitFormat {
+		t.Errorf("ExitCode() = %d, want %d", code, outcome.ExitFormat)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var report outcome.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid report: %v", err)
+	}
+	if report.Tool != "titles_th" || report.ExitCode != outcome.ExitFormat || report.Error == nil || report.Error.ID != "app.file_not_found" {
+		t.Errorf("report = %s", data)
+	}
+	if len(report.Archives) != 1 {
+		t.Fatalf("got %d archives, want 1", len(report.Archives))
+	}
+	result := report.Archives[0]
+	if result.Path != "th06.dat" || result.Status != outcome.StatusPartial || result.Summary != (outcome.Summary{OK: 1, Failed: 1}) {
+		t.Errorf("archive = %s", data)
+	}
+}
+
 func TestApp_processArchive(t *testing.T) {
 	tests := []struct {
 		name          string
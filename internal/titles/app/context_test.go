@@ -62,7 +62,7 @@ func TestApp_Run_ContextCancellation(t *testing.T) {
 
 			fs := tt.setupMock()
 			cfg := &config.Config{
-				OutputDir: ".",
+				OutputDir: t.TempDir(),
 			}
 			app := NewWithOptions(cfg, Options{
 				FileSystem: fs,
@@ -221,7 +221,7 @@ func TestApp_Run_WithValue(t *testing.T) {
 	}
 
 	cfg := &config.Config{
-		OutputDir: ".",
+		OutputDir: t.TempDir(),
 	}
 	app := NewWithOptions(cfg, Options{
 		FileSystem: fs,
@@ -1,23 +1,23 @@
 package app
 
-import "errors"
+import "github.com/shiroemons/go-brightmoon/internal/outcome"
 
 var (
 	// ErrParseTHFmt はTHFmtの解析に失敗した場合のエラー
-	ErrParseTHFmt = errors.New("THFmtの解析に失敗しました")
+	ErrParseTHFmt = outcome.New(outcome.KindFormat, "app.parse_thfmt", "THFmtの解析に失敗しました")
 
 	// ErrParseMusicCmt はMusicCmtの解析に失敗した場合のエラー
-	ErrParseMusicCmt = errors.New("MusicCmtの解析に失敗しました")
+	ErrParseMusicCmt = outcome.New(outcome.KindFormat, "app.parse_music_cmt", "MusicCmtの解析に失敗しました")
 
 	// ErrSaveFile はファイルの保存に失敗した場合のエラー
-	ErrSaveFile = errors.New("ファイルの保存に失敗しました")
+	ErrSaveFile = outcome.New(outcome.KindIO, "app.save_file", "ファイルの保存に失敗しました")
 
 	// ErrFileNotFound は必要なファイルが見つからない場合のエラー
-	ErrFileNotFound = errors.New("必要なファイルが見つかりませんでした")
+	ErrFileNotFound = outcome.New(outcome.KindFormat, "app.file_not_found", "必要なファイルが見つかりませんでした")
 
 	// ErrReadFile はファイルの読み込みに失敗した場合のエラー
-	ErrReadFile = errors.New("ファイルの読み込みに失敗しました")
+	ErrReadFile = outcome.New(outcome.KindIO, "app.read_file", "ファイルの読み込みに失敗しました")
 
 	// ErrNoMusicFiles は音楽ファイルが見つからない場合のエラー
-	ErrNoMusicFiles = errors.New("thbgm.fmt、musiccmt.txt または thbgm_tr.fmt、musiccmt_tr.txt のファイルがありません")
+	ErrNoMusicFiles = outcome.New(outcome.KindIO, "app.no_music_files", "thbgm.fmt、musiccmt.txt または thbgm_tr.fmt、musiccmt_tr.txt のファイルがありません")
 )
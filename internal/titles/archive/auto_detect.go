@@ -1,10 +1,11 @@
 package archive
 
 import (
-	"errors"
 	"fmt"
 
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
 	"github.com/shiroemons/go-brightmoon/internal/titles/fileutil"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
 	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
 )
 
@@ -19,7 +20,8 @@ type ArchiveTypeMapping struct {
 // GetArchiveTypeMappings はアーカイブタイプのマッピングを返します
 func GetArchiveTypeMappings() []ArchiveTypeMapping {
 	return []ArchiveTypeMapping{
-		{"Yukari", pbgarc.NewYukariArchive, false, 0}, // TH07 (PBG4形式)
+		{"Remilia", pbgarc.NewRemiliaArchive, false, 0}, // TH06 (PBG3形式)
+		{"Yukari", pbgarc.NewYukariArchive, false, 0},   // TH07 (PBG4形式)
 		{"Yumemi", pbgarc.NewYumemiArchive, false, 0},
 		{"Kaguya", pbgarc.NewKaguyaArchive, true, 1},
 		{"Suica", pbgarc.NewSuicaArchive, false, 0},
@@ -41,8 +43,8 @@ func (e *Extractor) openSpecificArchive(filename string, archiveType int) (pbgar
 		if mapping.NeedsType {
 			if mapping.BaseType == 1 { // Kaguya
 				if archiveType == 0 || archiveType == 1 {
-					if newFunc, ok := mapping.NewFunc.(func() *pbgarc.KaguyaArchive); ok {
-						targetArchive = newFunc()
+					if newFunc, ok := mapping.NewFunc.(func(...pbgarc.Option) *pbgarc.KaguyaArchive); ok {
+						targetArchive = newFunc(pbgarc.WithLogger(e.logger))
 						targetName = mapping.Name
 						subType = archiveType
 						found = true
@@ -51,8 +53,8 @@ func (e *Extractor) openSpecificArchive(filename string, archiveType int) (pbgar
 				}
 			} else if mapping.BaseType == 2 { // Kanako
 				if archiveType >= 0 && archiveType <= 2 {
-					if newFunc, ok := mapping.NewFunc.(func() *pbgarc.KanakoArchive); ok {
-						targetArchive = newFunc()
+					if newFunc, ok := mapping.NewFunc.(func(...pbgarc.Option) *pbgarc.KanakoArchive); ok {
+						targetArchive = newFunc(pbgarc.WithLogger(e.logger))
 						targetName = mapping.Name
 						subType = archiveType
 						found = true
@@ -64,11 +66,11 @@ func (e *Extractor) openSpecificArchive(filename string, archiveType int) (pbgar
 	}
 
 	if !found && archiveType >= 0 {
-		return nil, fmt.Errorf("指定されたアーカイブタイプ %d は不明か、タイプ指定不要な形式です", archiveType)
+		return nil, fmt.Errorf("%w: %d", ErrInvalidArchiveType, archiveType)
 	}
 
 	if targetArchive == nil {
-		return nil, fmt.Errorf("指定されたアーカイブタイプ %d に対応する実装が見つかりません", archiveType)
+		return nil, fmt.Errorf("%w: %d", ErrInvalidArchiveType, archiveType)
 	}
 
 	// サブタイプを設定 (Kaguya/Kanako)
@@ -81,10 +83,11 @@ func (e *Extractor) openSpecificArchive(filename string, archiveType int) (pbgar
 	// ファイルを開く
 	ok, err := targetArchive.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("%s としてアーカイブを開けませんでした: %w", targetName, err)
+		// ファイルを読めない場合は読み書きのエラー、それ以外は形式のエラーとして扱う
+		return nil, outcome.Default(outcome.KindFormat, fmt.Errorf("%w (%s): %w", ErrArchiveOpenFailed, targetName, err))
 	}
 	if !ok || !targetArchive.EnumFirst() {
-		return nil, fmt.Errorf("%s としてアーカイブを開きましたが、無効か空のようです", targetName)
+		return nil, fmt.Errorf("%w (%s)", ErrArchiveEmpty, targetName)
 	}
 
 	return targetArchive, nil
@@ -100,8 +103,9 @@ type archiveCandidate struct {
 // openArchiveAuto はアーカイブ形式を自動判別してアーカイブを開きます
 func (e *Extractor) openArchiveAuto(filename string) (pbgarc.PBGArchive, error) {
 	candidates := []archiveCandidate{}
+	var ioErr error // ファイルを読めなかった場合のエラー (形式の判別の失敗と区別する)
 
-	e.logger.Printf("アーカイブ形式を自動検出中...\n")
+	e.logger.Debug("detecting archive format", pbgarc.LogKeyArchive, filename)
 	mappings := GetArchiveTypeMappings()
 	for i := range mappings {
 		mapping := &mappings[i]
@@ -109,37 +113,45 @@ func (e *Extractor) openArchiveAuto(filename string) (pbgarc.PBGArchive, error)
 
 		// newFuncの型に応じてインスタンス化
 		switch fn := mapping.NewFunc.(type) {
-		case func() *pbgarc.YukariArchive:
-			archive = fn()
-		case func() *pbgarc.YumemiArchive:
-			archive = fn()
-		case func() *pbgarc.SuicaArchive:
-			archive = fn()
-		case func() *pbgarc.HinanawiArchive:
-			archive = fn()
-		case func() *pbgarc.MarisaArchive:
-			archive = fn()
-		case func() *pbgarc.KaguyaArchive:
-			archive = fn()
-		case func() *pbgarc.KanakoArchive:
-			archive = fn()
+		case func(...pbgarc.Option) *pbgarc.RemiliaArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.YukariArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.YumemiArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.SuicaArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.HinanawiArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.MarisaArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.KaguyaArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
+		case func(...pbgarc.Option) *pbgarc.KanakoArchive:
+			archive = fn(pbgarc.WithLogger(e.logger))
 		default:
 			continue
 		}
 
 		ok, err := archive.Open(filename)
 		if err != nil || !ok {
+			if outcome.KindOf(err) == outcome.KindIO {
+				ioErr = err
+			}
 			continue
 		}
 
 		if archive.EnumFirst() {
-			e.logger.Printf("- %s: 候補として検出\n", mapping.Name)
+			e.logger.Debug("format candidate found", pbgarc.LogKeyArchive, filename, pbgarc.LogKeyFormat, mapping.Name)
 			candidates = append(candidates, archiveCandidate{mapping.Name, archive, mapping})
 		}
 	}
 
 	if len(candidates) == 0 {
-		return nil, errors.New("対応するアーカイブ形式が見つかりませんでした")
+		if ioErr != nil {
+			return nil, fmt.Errorf("%w: %w", ErrArchiveOpenFailed, ioErr)
+		}
+		return nil, ErrUnsupportedArchiveType
 	}
 
 	// ファイル名からタイプを推測
@@ -151,39 +163,41 @@ func (e *Extractor) openArchiveAuto(filename string) (pbgarc.PBGArchive, error)
 
 	// 単一の候補ならそれを使用
 	if len(candidates) == 1 {
-		e.logger.Printf("形式 %s を検出しました\n", candidates[0].name)
+		e.logger.Debug("format detected", pbgarc.LogKeyArchive, filename, pbgarc.LogKeyFormat, candidates[0].name)
 		chosenArchive = candidates[0].archive
 		archiveName = candidates[0].name
 	} else {
 		// 複数候補がある場合、ファイル名から推測
 		chosenArchive, archiveName, archiveType = e.chooseFromCandidates(candidates, gameNum)
 		if chosenArchive == nil {
-			e.logger.Printf("ゲーム番号 %d に基づく自動判別ができませんでした。最初の候補を使用します。\n", gameNum)
 			chosenArchive = candidates[0].archive
 			archiveName = candidates[0].name
+			e.logger.Info("format not chosen by game number, using first candidate",
+				pbgarc.LogKeyArchive, filename, "game", gameNum, pbgarc.LogKeyFormat, archiveName)
 		} else {
-			e.logger.Printf("ゲーム番号 %d に基づいてアーカイブ形式を選択しました\n", gameNum)
+			e.logger.Debug("format chosen by game number",
+				pbgarc.LogKeyArchive, filename, "game", gameNum, pbgarc.LogKeyFormat, archiveName)
 		}
 	}
 
 	// 重要: th20tr.datなどの新しいファイルでは、Kanakoアーカイブのタイプを明示的に再設定
 	if archiveType >= 0 && archiveName != "" {
-		e.logger.Printf("自動判別の結果: %s (Type %d)\n", archiveName, archiveType)
+		e.logger.Debug("subtype selected", pbgarc.LogKeyArchive, filename,
+			pbgarc.LogKeyFormat, archiveName, pbgarc.LogKeySubType, archiveType)
 
 		// タイプ設定後に問題が発生する場合は、明示的に再オープン
-		if archiveName == "Kanako" && gameNum >= 13 {
-			e.logger.Printf("Kanakoアーカイブを再初期化します（タイプ2を適用）\n")
+		if archiveName == "Kanako" && archiveType == catalog.KanakoTD {
 			// 新しいインスタンスを作成
-			newArchive := pbgarc.NewKanakoArchive()
+			newArchive := pbgarc.NewKanakoArchive(pbgarc.WithLogger(e.logger))
 			newArchive.SetArchiveType(2) // 明示的にタイプ2を設定
 
 			// 再度開く
 			ok, err := newArchive.Open(filename)
 			if err != nil || !ok || !newArchive.EnumFirst() {
-				e.logger.Printf("再初期化に失敗しました: %v\n", err)
+				e.logger.Info("reopen archive failed, keeping detected archive", pbgarc.LogKeyArchive, filename,
+					pbgarc.LogKeyFormat, archiveName, pbgarc.LogKeySubType, archiveType, pbgarc.LogKeyError, err)
 				// 元のアーカイブを返す
 			} else {
-				e.logger.Printf("再初期化に成功しました\n")
 				return newArchive, nil // 成功したら新しいアーカイブを返す
 			}
 		}
@@ -193,28 +207,36 @@ func (e *Extractor) openArchiveAuto(filename string) (pbgarc.PBGArchive, error)
 }
 
 // chooseFromCandidates は複数の候補から適切なアーカイブを選択します
+// ゲーム番号から作品カタログを引き、作品のアーカイブ形式に一致する候補を選びます
 func (e *Extractor) chooseFromCandidates(candidates []archiveCandidate, gameNum int) (pbgarc.PBGArchive, string, int) {
 	var chosenArchive pbgarc.PBGArchive
 	var archiveName string
 	var archiveType int = -1
 
-	switch {
-	case gameNum >= 6 && gameNum <= 7:
-		chosenArchive, archiveName = e.chooseOldFormat(candidates, gameNum)
-	case gameNum == 8 || gameNum == 9:
+	game, ok := catalog.ByIDNumber(gameNum)
+	if !ok {
+		return nil, "", -1
+	}
+	switch game.Format {
+	case catalog.FormatKaguya:
 		chosenArchive, archiveName, archiveType = e.chooseKaguya(candidates, gameNum)
-	case gameNum >= 10:
+	case catalog.FormatKanako:
 		chosenArchive, archiveName, archiveType = e.chooseKanako(candidates, gameNum)
+	default:
+		chosenArchive, archiveName = e.chooseOldFormat(candidates, gameNum)
 	}
 
 	return chosenArchive, archiveName, archiveType
 }
 
-// chooseOldFormat は旧形式（th06, th07）のアーカイブを選択します
+// chooseOldFormat は旧形式（th06, th07）などサブタイプのない形式のアーカイブを選択します
 func (e *Extractor) chooseOldFormat(candidates []archiveCandidate, gameNum int) (pbgarc.PBGArchive, string) {
+	game, ok := catalog.ByIDNumber(gameNum)
+	if !ok {
+		return nil, ""
+	}
 	for _, c := range candidates {
-		if (gameNum == 6 && c.name == "Hinanawi") ||
-			(gameNum == 7 && c.name == "Yukari") {
+		if c.name == string(game.Format) {
 			return c.archive, c.name
 		}
 	}
@@ -227,17 +249,11 @@ func (e *Extractor) chooseKaguya(candidates []archiveCandidate, gameNum int) (pb
 		if c.name == "Kaguya" {
 			// サブタイプを設定
 			if kaguyaArchive, ok := c.archive.(*pbgarc.KaguyaArchive); ok {
-				var archiveType int
-				switch gameNum {
-				case 8:
-					archiveType = 0 // Imperishable Night (永夜抄)
-				case 9:
-					archiveType = 2 // Phantasmagoria of Flower View (花映塚)
-				default:
-					archiveType = 1 // Shoot the Bullet (弾幕アマノジャク)
+				archiveType := catalog.KaguyaIN
+				if game, ok := catalog.ByIDNumber(gameNum); ok && game.Format == catalog.FormatKaguya {
+					archiveType = game.SubType
 				}
 				kaguyaArchive.SetArchiveType(archiveType)
-				e.logger.Printf("Kaguya サブタイプを %d に設定しました\n", archiveType)
 				return c.archive, c.name, archiveType
 			}
 			return c.archive, c.name, -1
@@ -246,7 +262,7 @@ func (e *Extractor) chooseKaguya(candidates []archiveCandidate, gameNum int) (pb
 	return nil, "", -1
 }
 
-// chooseKanako はKanako形式（th10以降）のアーカイブを選択し、サブタイプを設定します
+// chooseKanako はKanako形式（th095, th10以降）のアーカイブを選択し、サブタイプを設定します
 func (e *Extractor) chooseKanako(candidates []archiveCandidate, gameNum int) (pbgarc.PBGArchive, string, int) {
 	for _, c := range candidates {
 		if c.name == "Kanako" {
@@ -254,7 +270,6 @@ func (e *Extractor) chooseKanako(candidates []archiveCandidate, gameNum int) (pb
 			if kanakoArchive, ok := c.archive.(*pbgarc.KanakoArchive); ok {
 				archiveType := e.getKanakoSubType(gameNum)
 				kanakoArchive.SetArchiveType(archiveType)
-				e.logger.Printf("Kanako サブタイプを %d に設定しました\n", archiveType)
 				return c.archive, c.name, archiveType
 			}
 			return c.archive, c.name, -1
@@ -264,13 +279,10 @@ func (e *Extractor) chooseKanako(candidates []archiveCandidate, gameNum int) (pb
 }
 
 // getKanakoSubType はゲーム番号からKanakoアーカイブのサブタイプを決定します
+// 作品カタログで Kanako 形式と判定できない場合は風神録と同じタイプを返します
 func (e *Extractor) getKanakoSubType(gameNum int) int {
-	if gameNum >= 10 && gameNum <= 11 || gameNum == 95 {
-		return 0 // MoF/SA
-	} else if gameNum == 12 {
-		return 1 // UFO/DS/FW
-	} else if gameNum >= 13 {
-		return 2 // TD+ (東方神霊廟以降)
+	if game, ok := catalog.ByIDNumber(gameNum); ok && game.Format == catalog.FormatKanako {
+		return game.SubType
 	}
-	return 0
+	return catalog.KanakoMoF
 }
@@ -1,29 +1,29 @@
 package archive
 
-import "errors"
+import "github.com/shiroemons/go-brightmoon/internal/outcome"
 
 var (
 	// ErrEmptyFile はファイルサイズが0の場合のエラー
-	ErrEmptyFile = errors.New("ファイルサイズが0です")
+	ErrEmptyFile = outcome.New(outcome.KindFormat, "archive.empty_file", "ファイルサイズが0です")
 
 	// ErrExtractFailed はファイルの展開に失敗した場合のエラー
-	ErrExtractFailed = errors.New("ファイルの展開に失敗しました")
+	ErrExtractFailed = outcome.New(outcome.KindFormat, "archive.extract_failed", "ファイルの展開に失敗しました")
 
 	// ErrNoFilesFound はアーカイブ内にファイルが見つからない場合のエラー
-	ErrNoFilesFound = errors.New("アーカイブ内にファイルが見つかりません")
+	ErrNoFilesFound = outcome.New(outcome.KindFormat, "archive.no_files_found", "アーカイブ内にファイルが見つかりません")
 
 	// ErrUnsupportedArchiveType はサポートされていないアーカイブタイプの場合のエラー
-	ErrUnsupportedArchiveType = errors.New("サポートされていないアーカイブ形式です")
+	ErrUnsupportedArchiveType = outcome.New(outcome.KindFormat, "archive.unsupported_type", "サポートされていないアーカイブ形式です")
 
 	// ErrInvalidArchiveType は不明または不正なアーカイブタイプのエラー
-	ErrInvalidArchiveType = errors.New("指定されたアーカイブタイプが不明または不正です")
+	ErrInvalidArchiveType = outcome.New(outcome.KindUsage, "archive.invalid_type", "指定されたアーカイブタイプが不明または不正です")
 
-	// ErrArchiveOpenFailed はアーカイブを開けない場合のエラー
-	ErrArchiveOpenFailed = errors.New("アーカイブを開けませんでした")
+	// ErrArchiveOpenFailed はアーカイブを開けない場合のエラー (種類はラップした原因のエラーで決まります)
+	ErrArchiveOpenFailed = outcome.New("", "archive.open_failed", "アーカイブを開けませんでした")
 
 	// ErrArchiveEmpty はアーカイブが空または無効の場合のエラー
-	ErrArchiveEmpty = errors.New("アーカイブが無効か空のようです")
+	ErrArchiveEmpty = outcome.New(outcome.KindFormat, "archive.empty", "アーカイブが無効か空のようです")
 
-	// ErrFileExtraction はファイル抽出中のエラー
-	ErrFileExtraction = errors.New("アーカイブからのファイル抽出中にエラーが発生しました")
+	// ErrFileExtraction はファイル抽出中のエラー (種類はラップした原因のエラーで決まります)
+	ErrFileExtraction = outcome.New("", "archive.file_extraction", "アーカイブからのファイル抽出中にエラーが発生しました")
 )
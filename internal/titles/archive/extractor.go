@@ -4,38 +4,49 @@ package archive
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
-	"github.com/shiroemons/go-brightmoon/internal/titles/config"
 	"github.com/shiroemons/go-brightmoon/internal/titles/fileutil"
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
 	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
 )
 
 // Extractor はアーカイブからファイルを抽出します
 type Extractor struct {
-	logger          *config.DebugLogger
+	logger          *slog.Logger
 	factory         ArchiveFactory
 	memoryExtractor MemoryExtractor
 }
 
 // NewExtractor は新しいExtractorを作成します
-func NewExtractor(logger *config.DebugLogger) *Extractor {
+// logger が nil の場合はログを出力しません
+func NewExtractor(logger *slog.Logger) *Extractor {
+	logger = orDiscard(logger)
 	return &Extractor{
 		logger:          logger,
-		factory:         &DefaultArchiveFactory{},
+		factory:         &DefaultArchiveFactory{Logger: logger},
 		memoryExtractor: &DefaultMemoryExtractor{},
 	}
 }
 
 // NewExtractorWithFactory は新しいExtractorをファクトリー付きで作成します
-func NewExtractorWithFactory(logger *config.DebugLogger, factory ArchiveFactory, extractor MemoryExtractor) *Extractor {
+func NewExtractorWithFactory(logger *slog.Logger, factory ArchiveFactory, extractor MemoryExtractor) *Extractor {
 	return &Extractor{
-		logger:          logger,
+		logger:          orDiscard(logger),
 		factory:         factory,
 		memoryExtractor: extractor,
 	}
 }
 
+// orDiscard は logger が nil の場合にログを出力しないロガーを返します
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return logger
+}
+
 // ExtractFiles は.datアーカイブから特定のファイルをメモリに展開します
 func (e *Extractor) ExtractFiles(ctx context.Context, archivePath string, archiveType int, targetFiles []string) (map[string][]byte, error) {
 	// コンテキストのキャンセルチェック
@@ -81,7 +92,8 @@ func (e *Extractor) ExtractFiles(ctx context.Context, archivePath string, archiv
 
 				results[entryName] = data
 				findCount++
-				e.logger.Printf("ファイル %s をメモリに展開しました（%d バイト）\n", entryName, len(data))
+				e.logger.Debug("entry extracted to memory", pbgarc.LogKeyArchive, archivePath,
+					pbgarc.LogKeyEntry, entryName, pbgarc.LogKeySize, len(data))
 				break
 			}
 		}
@@ -122,68 +134,41 @@ func (e *Extractor) openArchive(archivePath string, archiveType int) (pbgarc.PBG
 }
 
 // openByGameNumber はゲーム番号に基づいてアーカイブを開きます
+// 作品カタログから形式とサブタイプを決定し、開けなかった場合は自動判別を行います
 func (e *Extractor) openByGameNumber(archivePath string, gameNum int) (pbgarc.PBGArchive, error) {
-	switch {
-	case gameNum == 6:
-		e.logger.Printf("Hinanawi形式を強制適用します\n")
-		archive := e.factory.NewHinanawiArchive()
-		ok, err := archive.Open(archivePath)
-		if !ok || err != nil {
-			e.logger.Printf("Hinanawi形式でのオープンに失敗しました: %v\n", err)
-			return e.openArchiveAuto(archivePath)
-		}
-		e.logger.Printf("Hinanawi形式での強制オープンに成功しました\n")
-		return archive, nil
-
-	case gameNum == 7:
-		e.logger.Printf("Yumemi形式を強制適用します\n")
-		archive := e.factory.NewYumemiArchive()
-		ok, err := archive.Open(archivePath)
-		if !ok || err != nil {
-			e.logger.Printf("Yumemi形式でのオープンに失敗しました: %v\n", err)
-			return e.openArchiveAuto(archivePath)
-		}
-		e.logger.Printf("Yumemi形式での強制オープンに成功しました\n")
-		return archive, nil
-
-	case gameNum == 8 || gameNum == 9:
-		e.logger.Printf("Kaguya形式（タイプ %d）を強制適用します\n", gameNum-8)
-		kaguya := e.factory.NewKaguyaArchive()
-		if k, ok := kaguya.(*pbgarc.KaguyaArchive); ok {
-			k.SetArchiveType(gameNum - 8) // 8→0, 9→1
-		}
-		ok, err := kaguya.Open(archivePath)
-		if !ok || err != nil {
-			e.logger.Printf("Kaguya形式でのオープンに失敗しました: %v\n", err)
-			return e.openArchiveAuto(archivePath)
-		}
-		e.logger.Printf("Kaguya形式での強制オープンに成功しました\n")
-		return kaguya, nil
-
-	case gameNum >= 10:
-		var typeNum int
-		if gameNum >= 10 && gameNum <= 11 || gameNum == 95 {
-			typeNum = 0
-		} else if gameNum == 12 {
-			typeNum = 1
-		} else {
-			typeNum = 2 // 13以降はタイプ2
-		}
+	game, ok := catalog.ByIDNumber(gameNum)
+	if !ok {
+		return e.openArchiveAuto(archivePath)
+	}
 
-		e.logger.Printf("Kanako形式（タイプ %d）を強制適用します\n", typeNum)
-		kanako := e.factory.NewKanakoArchive()
-		if k, ok := kanako.(*pbgarc.KanakoArchive); ok {
-			k.SetArchiveType(typeNum)
+	var archive pbgarc.PBGArchive
+	switch game.Format {
+	case catalog.FormatRemilia:
+		archive = e.factory.NewRemiliaArchive()
+	case catalog.FormatYukari:
+		archive = e.factory.NewYukariArchive()
+	case catalog.FormatSuica:
+		archive = e.factory.NewSuicaArchive()
+	case catalog.FormatKaguya:
+		archive = e.factory.NewKaguyaArchive()
+		if k, ok := archive.(*pbgarc.KaguyaArchive); ok {
+			k.SetArchiveType(game.SubType)
 		}
-		ok, err := kanako.Open(archivePath)
-		if !ok || err != nil {
-			e.logger.Printf("Kanako形式でのオープンに失敗しました: %v\n", err)
-			return e.openArchiveAuto(archivePath)
+	case catalog.FormatKanako:
+		archive = e.factory.NewKanakoArchive()
+		if k, ok := archive.(*pbgarc.KanakoArchive); ok {
+			k.SetArchiveType(game.SubType)
 		}
-		e.logger.Printf("Kanako形式での強制オープンに成功しました\n")
-		return kanako, nil
-
 	default:
 		return e.openArchiveAuto(archivePath)
 	}
+
+	log := e.logger.With(pbgarc.LogKeyArchive, archivePath, pbgarc.LogKeyFormat, game.Format, pbgarc.LogKeySubType, game.SubType)
+	ok, err := archive.Open(archivePath)
+	if !ok || err != nil {
+		log.Info("open with catalog format failed, detecting format", pbgarc.LogKeyError, err)
+		return e.openArchiveAuto(archivePath)
+	}
+	log.Debug("opened with catalog format")
+	return archive, nil
 }
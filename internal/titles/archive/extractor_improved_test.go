@@ -3,9 +3,9 @@ package archive
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"testing"
 
-	"github.com/shiroemons/go-brightmoon/internal/titles/config"
 	"github.com/shiroemons/go-brightmoon/internal/titles/mocks"
 )
 
@@ -159,7 +159,7 @@ func TestExtractor_ExtractFiles_Improved(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			factory, memExtractor := tt.setupMock()
-			logger := config.NewDebugLogger(false)
+			logger := slog.New(slog.DiscardHandler)
 			extractor := NewExtractorWithFactory(logger, factory, memExtractor)
 
 			ctx := context.Background()
@@ -275,7 +275,7 @@ func TestExtractor_openArchive_Improved(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			factory := tt.setupMock()
-			logger := config.NewDebugLogger(false)
+			logger := slog.New(slog.DiscardHandler)
 			extractor := NewExtractorWithFactory(logger, factory, &mocks.MockMemoryExtractor{})
 
 			_, err := extractor.openArchive(tt.archivePath, tt.archiveType)
@@ -417,7 +417,7 @@ func TestExtractor_openByGameNumber_Improved(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			factory := tt.setupMock()
-			logger := config.NewDebugLogger(false)
+			logger := slog.New(slog.DiscardHandler)
 			extractor := NewExtractorWithFactory(logger, factory, &mocks.MockMemoryExtractor{})
 
 			_, err := extractor.openByGameNumber(tt.archivePath, tt.gameNum)
@@ -474,7 +474,7 @@ func TestExtractor_extractToMemory(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			archive, memExtractor := tt.setupMock()
-			logger := config.NewDebugLogger(false)
+			logger := slog.New(slog.DiscardHandler)
 			extractor := NewExtractorWithFactory(logger, &mocks.MockArchiveFactory{}, memExtractor)
 
 			data, err := extractor.extractToMemory(archive)
@@ -2,9 +2,12 @@ package archive
 
 import (
 	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/shiroemons/go-brightmoon/internal/titles/config"
+	"github.com/shiroemons/go-brightmoon/internal/outcome"
 	"github.com/shiroemons/go-brightmoon/internal/titles/mocks"
 )
 
@@ -77,7 +80,7 @@ func TestExtractor_ExtractFiles(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			archive, memExtractor := tt.setupMock()
 
-			logger := config.NewDebugLogger(false)
+			logger := slog.New(slog.DiscardHandler)
 			factory := &mocks.MockArchiveFactory{
 				MockArchive: archive,
 			}
@@ -125,14 +128,19 @@ func TestExtractor_openByGameNumber(t *testing.T) {
 		wantType string
 	}{
 		{
-			name:     "ゲーム番号6 - Hinanawi",
+			name:     "ゲーム番号6 - Remilia",
 			gameNum:  6,
-			wantType: "Hinanawi",
+			wantType: "Remilia",
 		},
 		{
-			name:     "ゲーム番号7 - Yumemi",
+			name:     "ゲーム番号7 - Yukari",
 			gameNum:  7,
-			wantType: "Yumemi",
+			wantType: "Yukari",
+		},
+		{
+			name:     "ゲーム番号75 - Suica",
+			gameNum:  75,
+			wantType: "Suica",
 		},
 		{
 			name:     "ゲーム番号8 - Kaguya",
@@ -161,7 +169,7 @@ func TestExtractor_openByGameNumber(t *testing.T) {
 				MockArchive: archive,
 			}
 
-			logger := config.NewDebugLogger(false)
+			logger := slog.New(slog.DiscardHandler)
 			extractor := NewExtractorWithFactory(logger, factory, &mocks.MockMemoryExtractor{})
 
 			result, err := extractor.openByGameNumber("test.dat", tt.gameNum)
@@ -172,6 +180,38 @@ func TestExtractor_openByGameNumber(t *testing.T) {
 			if result == nil {
 				t.Error("Expected archive but got nil")
 			}
+			if len(factory.Created) == 0 || factory.Created[0] != tt.wantType {
+				t.Errorf("created archives = %v, want %s first", factory.Created, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestExtractor_openArchiveAuto_Errors(t *testing.T) {
+	dir := t.TempDir()
+	junk := filepath.Join(dir, "junk.dat")
+	if err := os.WriteFile(junk, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		wantErr  error
+		wantKind outcome.Kind
+	}{
+		{"存在しないファイル", filepath.Join(dir, "missing.dat"), ErrArchiveOpenFailed, outcome.KindIO},
+		{"形式を判別できないファイル", junk, ErrUnsupportedArchiveType, outcome.KindFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewExtractor(nil).openArchiveAuto(tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("openArchiveAuto() error = %v, want %v", err, tt.wantErr)
+			}
+			if kind := outcome.KindOf(err); kind != tt.wantKind {
+				t.Errorf("KindOf() = %q, want %q", kind, tt.wantKind)
+			}
 		})
 	}
 }
@@ -179,11 +219,11 @@ func TestExtractor_openByGameNumber(t *testing.T) {
 func TestGetArchiveTypeMappings(t *testing.T) {
 	mappings := GetArchiveTypeMappings()
 
-	if len(mappings) != 7 {
-		t.Errorf("Expected 7 archive type mappings, got %d", len(mappings))
+	if len(mappings) != 8 {
+		t.Errorf("Expected 8 archive type mappings, got %d", len(mappings))
 	}
 
-	expectedNames := []string{"Yukari", "Yumemi", "Kaguya", "Suica", "Hinanawi", "Marisa", "Kanako"}
+	expectedNames := []string{"Remilia", "Yukari", "Yumemi", "Kaguya", "Suica", "Hinanawi", "Marisa", "Kanako"}
 	for _, expected := range expectedNames {
 		found := false
 		for _, mapping := range mappings {
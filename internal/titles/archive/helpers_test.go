@@ -1,16 +1,16 @@
 package archive
 
 import (
+	"log/slog"
 	"testing"
 
-	"github.com/shiroemons/go-brightmoon/internal/titles/config"
 	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
 )
 
 // テスト用のヘルパー関数
 
 func TestChooseOldFormat(t *testing.T) {
-	logger := config.NewDebugLogger(false)
+	logger := slog.New(slog.DiscardHandler)
 	extractor := NewExtractor(logger)
 
 	tests := []struct {
@@ -21,13 +21,13 @@ func TestChooseOldFormat(t *testing.T) {
 		wantNil    bool
 	}{
 		{
-			name: "th06でHinanawi選択",
+			name: "th06でRemilia選択",
 			candidates: []archiveCandidate{
 				{name: "Hinanawi", archive: &pbgarc.HinanawiArchive{}},
-				{name: "Yumemi", archive: &pbgarc.YumemiArchive{}},
+				{name: "Remilia", archive: &pbgarc.RemiliaArchive{}},
 			},
 			gameNum:  6,
-			wantName: "Hinanawi",
+			wantName: "Remilia",
 		},
 		{
 			name: "th07でYukari選択",
@@ -75,7 +75,7 @@ func TestChooseOldFormat(t *testing.T) {
 }
 
 func TestChooseKaguya(t *testing.T) {
-	logger := config.NewDebugLogger(false)
+	logger := slog.New(slog.DiscardHandler)
 	extractor := NewExtractor(logger)
 
 	tests := []struct {
@@ -133,7 +133,7 @@ func TestChooseKaguya(t *testing.T) {
 }
 
 func TestChooseKanako(t *testing.T) {
-	logger := config.NewDebugLogger(false)
+	logger := slog.New(slog.DiscardHandler)
 	extractor := NewExtractor(logger)
 
 	tests := []struct {
@@ -209,7 +209,7 @@ func TestChooseKanako(t *testing.T) {
 }
 
 func TestGetKanakoSubType(t *testing.T) {
-	logger := config.NewDebugLogger(false)
+	logger := slog.New(slog.DiscardHandler)
 	extractor := NewExtractor(logger)
 
 	tests := []struct {
@@ -221,7 +221,10 @@ func TestGetKanakoSubType(t *testing.T) {
 		{"th11", 11, 0},
 		{"th95", 95, 0},
 		{"th12", 12, 1},
+		{"th125", 125, 1},
+		{"th128", 128, 1},
 		{"th13", 13, 2},
+		{"th143", 143, 2},
 		{"th14", 14, 2},
 		{"th15", 15, 2},
 		{"th16", 16, 2},
@@ -243,7 +246,7 @@ func TestGetKanakoSubType(t *testing.T) {
 }
 
 func TestChooseFromCandidates(t *testing.T) {
-	logger := config.NewDebugLogger(false)
+	logger := slog.New(slog.DiscardHandler)
 	extractor := NewExtractor(logger)
 
 	tests := []struct {
@@ -254,12 +257,12 @@ func TestChooseFromCandidates(t *testing.T) {
 		wantType   int
 	}{
 		{
-			name: "th06でHinanawi選択",
+			name: "th06でRemilia選択",
 			candidates: []archiveCandidate{
-				{name: "Hinanawi", archive: &pbgarc.HinanawiArchive{}},
+				{name: "Remilia", archive: &pbgarc.RemiliaArchive{}},
 			},
 			gameNum:  6,
-			wantName: "Hinanawi",
+			wantName: "Remilia",
 			wantType: -1,
 		},
 		{
@@ -289,6 +292,24 @@ func TestChooseFromCandidates(t *testing.T) {
 			wantName: "Kanako",
 			wantType: 0,
 		},
+		{
+			name: "th09でKaguyaタイプ2選択",
+			candidates: []archiveCandidate{
+				{name: "Kaguya", archive: pbgarc.NewKaguyaArchive()},
+			},
+			gameNum:  9,
+			wantName: "Kaguya",
+			wantType: 2,
+		},
+		{
+			name: "th128でKanakoタイプ1選択",
+			candidates: []archiveCandidate{
+				{name: "Kanako", archive: pbgarc.NewKanakoArchive()},
+			},
+			gameNum:  128,
+			wantName: "Kanako",
+			wantType: 1,
+		},
 		{
 			name: "複数候補から正しく選択",
 			candidates: []archiveCandidate{
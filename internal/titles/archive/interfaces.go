@@ -1,6 +1,8 @@
 package archive
 
 import (
+	"log/slog"
+
 	"github.com/shiroemons/go-brightmoon/pkg/pbgarc"
 )
 
@@ -13,6 +15,8 @@ type ArchiveOpener interface {
 
 // ArchiveFactory はアーカイブインスタンスを生成するインターフェース
 type ArchiveFactory interface {
+	NewRemiliaArchive() pbgarc.PBGArchive
+	NewYukariArchive() pbgarc.PBGArchive
 	NewYumemiArchive() pbgarc.PBGArchive
 	NewKaguyaArchive() pbgarc.PBGArchive
 	NewSuicaArchive() pbgarc.PBGArchive
@@ -22,30 +26,48 @@ type ArchiveFactory interface {
 }
 
 // DefaultArchiveFactory はデフォルトのアーカイブファクトリ実装
-type DefaultArchiveFactory struct{}
+type DefaultArchiveFactory struct {
+	Logger *slog.Logger // 作成したアーカイブのログの出力先 (nil の場合は出力しない)
+}
+
+// options はアーカイブの作成時に指定する設定を返します
+func (f *DefaultArchiveFactory) options() []pbgarc.Option {
+	if f.Logger == nil {
+		return nil
+	}
+	return []pbgarc.Option{pbgarc.WithLogger(f.Logger)}
+}
+
+func (f *DefaultArchiveFactory) NewRemiliaArchive() pbgarc.PBGArchive {
+	return pbgarc.NewRemiliaArchive(f.options()...)
+}
+
+func (f *DefaultArchiveFactory) NewYukariArchive() pbgarc.PBGArchive {
+	return pbgarc.NewYukariArchive(f.options()...)
+}
 
 func (f *DefaultArchiveFactory) NewYumemiArchive() pbgarc.PBGArchive {
-	return pbgarc.NewYumemiArchive()
+	return pbgarc.NewYumemiArchive(f.options()...)
 }
 
 func (f *DefaultArchiveFactory) NewKaguyaArchive() pbgarc.PBGArchive {
-	return pbgarc.NewKaguyaArchive()
+	return pbgarc.NewKaguyaArchive(f.options()...)
 }
 
 func (f *DefaultArchiveFactory) NewSuicaArchive() pbgarc.PBGArchive {
-	return pbgarc.NewSuicaArchive()
+	return pbgarc.NewSuicaArchive(f.options()...)
 }
 
 func (f *DefaultArchiveFactory) NewHinanawiArchive() pbgarc.PBGArchive {
-	return pbgarc.NewHinanawiArchive()
+	return pbgarc.NewHinanawiArchive(f.options()...)
 }
 
 func (f *DefaultArchiveFactory) NewMarisaArchive() pbgarc.PBGArchive {
-	return pbgarc.NewMarisaArchive()
+	return pbgarc.NewMarisaArchive(f.options()...)
 }
 
 func (f *DefaultArchiveFactory) NewKanakoArchive() pbgarc.PBGArchive {
-	return pbgarc.NewKanakoArchive()
+	return pbgarc.NewKanakoArchive(f.options()...)
 }
 
 // MemoryExtractor はメモリへの抽出を行うインターフェース
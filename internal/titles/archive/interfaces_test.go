@@ -1,6 +1,10 @@
 package archive
 
 import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -11,6 +15,7 @@ func TestDefaultArchiveFactory(t *testing.T) {
 		name    string
 		newFunc func() interface{}
 	}{
+		{"NewYukariArchive", func() interface{} { return factory.NewYukariArchive() }},
 		{"NewYumemiArchive", func() interface{} { return factory.NewYumemiArchive() }},
 		{"NewKaguyaArchive", func() interface{} { return factory.NewKaguyaArchive() }},
 		{"NewSuicaArchive", func() interface{} { return factory.NewSuicaArchive() }},
@@ -29,6 +34,24 @@ func TestDefaultArchiveFactory(t *testing.T) {
 	}
 }
 
+func TestDefaultArchiveFactory_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	factory := &DefaultArchiveFactory{
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	archive := factory.NewKanakoArchive()
+	if _, err := archive.Open(filepath.Join(t.TempDir(), "missing.dat")); err == nil {
+		t.Fatal("Open() should return error for missing file")
+	}
+	output := buf.String()
+	for _, want := range []string{`msg="open archive failed"`, "format=Kanako", "missing.dat"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("log output does not contain %q: %s", want, output)
+		}
+	}
+}
+
 func TestMemoryWriter_Write(t *testing.T) {
 	buf := make([]byte, 0)
 	writer := &memoryWriter{buf: &buf}
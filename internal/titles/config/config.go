@@ -4,7 +4,13 @@ package config
 import (
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+	"github.com/shiroemons/go-brightmoon/internal/userconfig"
 )
 
 const Version = "0.0.4"
@@ -17,9 +23,25 @@ type Config struct {
 	DebugMode   bool
 	DryRun      bool
 	ShowVersion bool
+	Lang        string
+	LogLevel    string
+	LogFormat   string
+	ReportPath  string
+}
+
+// configBindings は設定ファイル・環境変数で指定できる titles_th のフラグ
+var configBindings = []userconfig.Binding{
+	{Key: "archive", Flags: []string{"archive", "a"}},
+	{Key: "type", Flags: []string{"t"}},
+	{Key: "out", Flags: []string{"o"}},
+	{Key: "debug", Flags: []string{"debug", "d"}},
+	{Key: "dry_run", Flags: []string{"dry-run", "n"}},
+	{Key: "log_level", Flags: []string{"log-level"}},
+	{Key: "log_format", Flags: []string{"log-format"}},
 }
 
 // ParseFlags はコマンドライン引数を解析して設定を返します
+// コマンドラインで指定されていない項目は、環境変数 (TITLES_TH_*)、設定ファイルの順に値を読み込みます
 func ParseFlags() *Config {
 	config := &Config{}
 
@@ -33,6 +55,12 @@ func ParseFlags() *Config {
 		fmt.Fprintln(flag.CommandLine.Output(), "  --debug")
 		fmt.Fprintln(flag.CommandLine.Output(), "    \tenable debug output")
 		fmt.Fprintln(flag.CommandLine.Output(), "  -d\tenable debug output (shorthand)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --log-level string")
+		fmt.Fprintln(flag.CommandLine.Output(), "    \tlog level written to stderr (debug, info, warn, error) (default \"warn\")")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --log-format string")
+		fmt.Fprintln(flag.CommandLine.Output(), "    \tlog format (text, json) (default \"text\")")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --report string")
+		fmt.Fprintln(flag.CommandLine.Output(), "    \twrite a JSON report of the run (archive, entries, error kind) to this file")
 		fmt.Fprintln(flag.CommandLine.Output(), "  -o string")
 		fmt.Fprintln(flag.CommandLine.Output(), "    \toutput directory for the generated files (default \".\")")
 		fmt.Fprintln(flag.CommandLine.Output(), "  -t int")
@@ -43,6 +71,12 @@ func ParseFlags() *Config {
 		fmt.Fprintln(flag.CommandLine.Output(), "  --version")
 		fmt.Fprintln(flag.CommandLine.Output(), "    \tshow version information")
 		fmt.Fprintln(flag.CommandLine.Output(), "  -v\tshow version information (shorthand)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --config string")
+		fmt.Fprintln(flag.CommandLine.Output(), "    \tconfiguration file (default: brightmoon/config.toml in the user configuration directory)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --profile string")
+		fmt.Fprintln(flag.CommandLine.Output(), "    \tconfiguration profile name ([profile.<name>] in the configuration file)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --lang string")
+		fmt.Fprintln(flag.CommandLine.Output(), "    \tmessage language (ja, en) (default: from LC_ALL, LC_MESSAGES or LANG)")
 	}
 
 	// アーカイブフラグ
@@ -59,6 +93,13 @@ func ParseFlags() *Config {
 	flag.BoolVar(&config.DebugMode, "debug", false, "enable debug output")
 	flag.BoolVar(&config.DebugMode, "d", false, "enable debug output (shorthand)")
 
+	// ログ (標準エラー出力)
+	flag.StringVar(&config.LogLevel, "log-level", "warn", "log level written to stderr (debug, info, warn, error)")
+	flag.StringVar(&config.LogFormat, "log-format", "text", "log format (text, json)")
+
+	// 実行レポート
+	flag.StringVar(&config.ReportPath, "report", "", "write a JSON report of the run (archive, entries, error kind) to this file")
+
 	// ドライランモード
 	flag.BoolVar(&config.DryRun, "dry-run", false, "perform a dry run without writing output files")
 	flag.BoolVar(&config.DryRun, "n", false, "perform a dry run without writing output files (shorthand)")
@@ -67,11 +108,36 @@ func ParseFlags() *Config {
 	flag.BoolVar(&config.ShowVersion, "version", false, "show version information")
 	flag.BoolVar(&config.ShowVersion, "v", false, "show version information (shorthand)")
 
+	// 表示言語 (main でフラグの解析前に設定済み)
+	flag.StringVar(&config.Lang, "lang", "", "message language (ja, en)")
+
+	// 設定ファイルとプロファイル
+	loader := &userconfig.Loader{Tool: "titles_th", EnvPrefix: "TITLES_TH_"}
+	loader.RegisterFlags(flag.CommandLine)
+
 	flag.Parse()
 
+	if err := applyUserConfig(flag.CommandLine, loader); err != nil {
+		i18n.PrintError(os.Stderr, err)
+		os.Exit(2)
+	}
+	if _, err := NewLogger(io.Discard, config); err != nil {
+		i18n.PrintError(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	return config
 }
 
+// applyUserConfig はコマンドラインで指定されていないフラグに環境変数・設定ファイルの値を適用します
+func applyUserConfig(fs *flag.FlagSet, loader *userconfig.Loader) error {
+	file, env, err := loader.Load(nil)
+	if err != nil {
+		return err
+	}
+	return userconfig.Apply(fs, configBindings, file, env)
+}
+
 // HandleVersion はバージョン表示を処理します
 func HandleVersion(showVersion bool) {
 	if showVersion {
@@ -80,19 +146,25 @@ func HandleVersion(showVersion bool) {
 	}
 }
 
-// DebugLogger はデバッグ出力を管理します
-type DebugLogger struct {
-	enabled bool
-}
-
-// NewDebugLogger は新しいDebugLoggerを作成します
-func NewDebugLogger(enabled bool) *DebugLogger {
-	return &DebugLogger{enabled: enabled}
-}
-
-// Printf はデバッグモードが有効な場合のみメッセージを表示します
-func (d *DebugLogger) Printf(format string, a ...any) {
-	if d.enabled {
-		fmt.Printf(format, a...)
+// NewLogger は設定のログレベルと形式で w に出力するロガーを作成します
+// --debug を指定した場合はログレベルによらずデバッグレベルのログも出力します
+func NewLogger(w io.Writer, cfg *Config) (*slog.Logger, error) {
+	level := slog.LevelWarn
+	if cfg.LogLevel != "" {
+		if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+			return nil, i18n.Errorf("不明なログレベルです: %s (debug, info, warn, error)", cfg.LogLevel)
+		}
+	}
+	if cfg.DebugMode {
+		level = min(level, slog.LevelDebug)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.LogFormat) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, i18n.Errorf("不明なログ形式です: %s (text, json)", cfg.LogFormat)
 	}
 }
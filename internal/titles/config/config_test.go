@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -32,6 +33,59 @@ func TestParseFlags(t *testing.T) {
 	}
 }
 
+func TestParseFlags_UserConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `out = "./common"
+debug = true
+
+[titles_th]
+out = "./titles"
+
+[profile.th17]
+archive = "th17.dat"
+type = 2
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	t.Setenv("TITLES_TH_CONFIG", path)
+	t.Setenv("TITLES_TH_DRY_RUN", "1")
+
+	// フラグ > 環境変数 > 設定ファイル (プロファイル > ツール別セクション > 共通)
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	os.Args = []string{"cmd", "--profile", "th17", "-t", "1"}
+	cfg := ParseFlags()
+
+	if cfg.ArchivePath != "th17.dat" {
+		t.Errorf("Expected ArchivePath 'th17.dat', got '%s'", cfg.ArchivePath)
+	}
+	if cfg.ArchiveType != 1 {
+		t.Errorf("Expected ArchiveType 1 from the flag, got %d", cfg.ArchiveType)
+	}
+	if cfg.OutputDir != "./titles" {
+		t.Errorf("Expected OutputDir './titles', got '%s'", cfg.OutputDir)
+	}
+	if !cfg.DebugMode || !cfg.DryRun {
+		t.Errorf("Expected DebugMode and DryRun to be true, got %v, %v", cfg.DebugMode, cfg.DryRun)
+	}
+
+	// 短縮形のフラグで指定した場合も設定ファイルの値より優先する
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	os.Args = []string{"cmd", "--profile", "th17", "-a", "other.dat", "-d=false"}
+	cfg = ParseFlags()
+
+	if cfg.ArchivePath != "other.dat" {
+		t.Errorf("Expected ArchivePath 'other.dat', got '%s'", cfg.ArchivePath)
+	}
+	if cfg.ArchiveType != 2 {
+		t.Errorf("Expected ArchiveType 2 from the profile, got %d", cfg.ArchiveType)
+	}
+	if cfg.DebugMode {
+		t.Error("Expected DebugMode to be false")
+	}
+}
+
 func TestParseFlagsWithUsage(t *testing.T) {
 	// フラグをリセット
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
@@ -64,6 +118,11 @@ func TestParseFlagsWithUsage(t *testing.T) {
 		"output directory for the generated files",
 		"-t int",
 		"archive type",
+		"--config string",
+		"--profile string",
+		"--lang string",
+		"--log-level string",
+		"--log-format string",
 	}
 
 	for _, expected := range expectedStrings {
@@ -73,46 +132,45 @@ func TestParseFlagsWithUsage(t *testing.T) {
 	}
 }
 
-func TestDebugLogger(t *testing.T) {
-	// 出力をキャプチャするためのバッファ
-	var buf bytes.Buffer
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	// デバッグモード有効
-	logger := NewDebugLogger(true)
-	logger.Printf("test message %d\n", 123)
-
-	w.Close()
-	os.Stdout = oldStdout
-
-	// 出力を読み取り
-	outputBytes := make([]byte, 1024)
-	n, _ := r.Read(outputBytes)
-	output := string(outputBytes[:n])
-
-	if !strings.Contains(output, "test message 123") {
-		t.Errorf("Expected debug output to contain 'test message 123', got '%s'", output)
-	}
-
-	// デバッグモード無効
-	buf.Reset()
-	logger = NewDebugLogger(false)
-	r, w, _ = os.Pipe()
-	os.Stdout = w
-
-	logger.Printf("should not appear\n")
-
-	w.Close()
-	os.Stdout = oldStdout
-
-	outputBytes = make([]byte, 1024)
-	n, _ = r.Read(outputBytes)
-	output = string(outputBytes[:n])
-
-	if strings.Contains(output, "should not appear") {
-		t.Error("Debug output should not appear when debug mode is disabled")
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		wantDebug bool
+		wantWarn  bool
+		wantJSON  bool
+		wantErr   bool
+	}{
+		{name: "default", cfg: Config{}, wantWarn: true},
+		{name: "level error", cfg: Config{LogLevel: "error"}},
+		{name: "debug flag", cfg: Config{LogLevel: "error", DebugMode: true}, wantDebug: true, wantWarn: true},
+		{name: "json", cfg: Config{LogLevel: "debug", LogFormat: "json"}, wantDebug: true, wantWarn: true, wantJSON: true},
+		{name: "invalid level", cfg: Config{LogLevel: "verbose"}, wantErr: true},
+		{name: "invalid format", cfg: Config{LogFormat: "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := NewLogger(&buf, &tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLogger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			logger.Debug("debug message", "archive", "th08.dat")
+			logger.Warn("warn message")
+			output := buf.String()
+			if got := strings.Contains(output, "debug message"); got != tt.wantDebug {
+				t.Errorf("debug message written = %v, want %v: %q", got, tt.wantDebug, output)
+			}
+			if got := strings.Contains(output, "warn message"); got != tt.wantWarn {
+				t.Errorf("warn message written = %v, want %v: %q", got, tt.wantWarn, output)
+			}
+			if got := strings.HasPrefix(output, "{"); output != "" && got != tt.wantJSON {
+				t.Errorf("JSON output = %v, want %v: %q", got, tt.wantJSON, output)
+			}
+		})
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/shiroemons/go-brightmoon/pkg/catalog"
 	"golang.org/x/text/encoding/japanese"
 	"golang.org/x/text/transform"
 )
@@ -74,24 +75,14 @@ func GenerateOutputFilename(inputPath string) string {
 	return fmt.Sprintf("titles_%s.txt", baseName)
 }
 
-// ExtractGameNumber はファイル名からゲーム番号を抽出します
+// ExtractGameNumber はファイル名からゲーム番号 (ゲームIDの数字部分。例: th128.dat -> 128) を抽出します
+// 作品カタログで判定できないファイル名の場合は -1 を返します
 func ExtractGameNumber(filename string) int {
-	baseName := strings.ToLower(filepath.Base(filename))
-	gameNum := -1
-
-	// thで始まるdatファイルなら、ゲーム番号を抽出
-	if strings.HasPrefix(baseName, "th") {
-		re := regexp.MustCompile(`^th(\d+)`)
-		matches := re.FindStringSubmatch(baseName)
-		if len(matches) > 1 {
-			gameNum = 0
-			for _, c := range matches[1] {
-				gameNum = gameNum*10 + int(c-'0')
-			}
-		}
+	game, ok := catalog.FromFileName(filename)
+	if !ok {
+		return -1
 	}
-
-	return gameNum
+	return game.IDNumber()
 }
 
 // IsTrialVersion はファイル名から体験版かどうかを判定します
@@ -36,9 +36,11 @@ func TestExtractGameNumber(t *testing.T) {
 		{"th08.dat", 8},
 		{"th09.dat", 9},
 		{"th10.dat", 10},
+		{"th095.dat", 95},
 		{"th128.dat", 128},
 		{"th06tr.dat", 6},
 		{"th20tr.dat", 20},
+		{"紅魔郷ST.DAT", 6},
 		{"thbgm.dat", -1},
 		{"notth.dat", -1},
 		{"test.dat", -1},
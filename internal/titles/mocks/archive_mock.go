@@ -11,9 +11,12 @@ import (
 type MockArchiveFactory struct {
 	MockArchive pbgarc.PBGArchive
 	Error       error
+	Created     []string // 呼び出されたコンストラクタの形式名 (呼び出し順)
 }
 
-func (f *MockArchiveFactory) NewYumemiArchive() pbgarc.PBGArchive {
+// newArchive は形式名を記録し、テスト用のアーカイブを返します
+func (f *MockArchiveFactory) newArchive(format string) pbgarc.PBGArchive {
+	f.Created = append(f.Created, format)
 	if f.Error != nil {
 		return nil
 	}
@@ -23,54 +26,36 @@ func (f *MockArchiveFactory) NewYumemiArchive() pbgarc.PBGArchive {
 	return NewSimpleMockArchive(map[string][]byte{})
 }
 
+func (f *MockArchiveFactory) NewRemiliaArchive() pbgarc.PBGArchive {
+	return f.newArchive("Remilia")
+}
+
+func (f *MockArchiveFactory) NewYukariArchive() pbgarc.PBGArchive {
+	return f.newArchive("Yukari")
+}
+
+func (f *MockArchiveFactory) NewYumemiArchive() pbgarc.PBGArchive {
+	return f.newArchive("Yumemi")
+}
+
 func (f *MockArchiveFactory) NewKaguyaArchive() pbgarc.PBGArchive {
-	if f.Error != nil {
-		return nil
-	}
-	if f.MockArchive != nil {
-		return f.MockArchive
-	}
-	return NewSimpleMockArchive(map[string][]byte{})
+	return f.newArchive("Kaguya")
 }
 
 func (f *MockArchiveFactory) NewSuicaArchive() pbgarc.PBGArchive {
-	if f.Error != nil {
-		return nil
-	}
-	if f.MockArchive != nil {
-		return f.MockArchive
-	}
-	return NewSimpleMockArchive(map[string][]byte{})
+	return f.newArchive("Suica")
 }
 
 func (f *MockArchiveFactory) NewHinanawiArchive() pbgarc.PBGArchive {
-	if f.Error != nil {
-		return nil
-	}
-	if f.MockArchive != nil {
-		return f.MockArchive
-	}
-	return NewSimpleMockArchive(map[string][]byte{})
+	return f.newArchive("Hinanawi")
 }
 
 func (f *MockArchiveFactory) NewMarisaArchive() pbgarc.PBGArchive {
-	if f.Error != nil {
-		return nil
-	}
-	if f.MockArchive != nil {
-		return f.MockArchive
-	}
-	return NewSimpleMockArchive(map[string][]byte{})
+	return f.newArchive("Marisa")
 }
 
 func (f *MockArchiveFactory) NewKanakoArchive() pbgarc.PBGArchive {
-	if f.Error != nil {
-		return nil
-	}
-	if f.MockArchive != nil {
-		return f.MockArchive
-	}
-	return NewSimpleMockArchive(map[string][]byte{})
+	return f.newArchive("Kanako")
 }
 
 // MockMemoryExtractor はテスト用のメモリ抽出モック
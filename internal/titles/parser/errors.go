@@ -1,17 +1,17 @@
 package parser
 
-import "errors"
+import "github.com/shiroemons/go-brightmoon/internal/outcome"
 
 var (
 	// ErrCharacterEncoding は文字コード変換エラー
-	ErrCharacterEncoding = errors.New("文字コード変換エラー")
+	ErrCharacterEncoding = outcome.New(outcome.KindFormat, "parser.character_encoding", "文字コード変換エラー")
 
 	// ErrScanError はスキャンエラー
-	ErrScanError = errors.New("スキャンエラー")
+	ErrScanError = outcome.New(outcome.KindFormat, "parser.scan", "スキャンエラー")
 
 	// ErrReadmeRead はreadme.txtの読み込みに失敗した場合のエラー
-	ErrReadmeRead = errors.New("readme.txtの読み込みに失敗しました")
+	ErrReadmeRead = outcome.New(outcome.KindIO, "parser.readme_read", "readme.txtの読み込みに失敗しました")
 
 	// ErrReadmeEncodingConversion はreadme.txtの文字コード変換に失敗した場合のエラー
-	ErrReadmeEncodingConversion = errors.New("readme.txtの文字コード変換に失敗しました")
+	ErrReadmeEncodingConversion = outcome.New(outcome.KindFormat, "parser.readme_encoding_conversion", "readme.txtの文字コード変換に失敗しました")
 )
@@ -0,0 +1,382 @@
+// Package userconfig は brightmoon と titles_th が共有する設定ファイル (TOML) と環境変数を扱います
+//
+// 設定ファイルでは全ツール共通の既定値、ツール別のセクション ([brightmoon], [titles_th])、
+// --profile で選択するプロファイル ([profile.<名前>]) を指定できます
+// 値の優先順位はコマンドラインのフラグ > 環境変数 > 設定ファイルです
+package userconfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shiroemons/go-brightmoon/internal/i18n"
+)
+
+// 設定ファイルの既定の場所 (os.UserConfigDir()/brightmoon/config.toml)
+const (
+	DirName  = "brightmoon"
+	FileName = "config.toml"
+)
+
+// Keys は設定ファイルと環境変数で指定できるキー
+// ツールが対応していないキーは無視されます
+var Keys = []string{
+	"archive",        // アーカイブファイル
+	"out",            // 出力先
+	"game",           // ゲーム ID またはタイトル
+	"archive_format", // アーカイブ形式
+	"subtype",        // アーカイブ形式のサブタイプ
+	"type",           // 数値のアーカイブタイプ
+	"workers",        // ワーカー数
+	"parallel",       // 並列処理
+	"overwrite",      // 既存ファイルの扱い
+	"crypt_def",      // 暗号化パラメータの定義ファイル (複数可)
+	"key_file",       // TFPK の RSA 公開鍵ファイル
+	"name_list",      // TFPK の名前リスト
+	"debug",          // デバッグ表示
+	"dry_run",        // ドライラン
+	"log_level",      // ログレベル
+	"log_format",     // ログの形式
+}
+
+// selectionKeys はアーカイブ形式を選択するキー
+// 同時に指定できないため、設定ファイルのセクションや環境変数、コマンドラインで1つでも指定した場合は
+// 優先順位の低い指定元の値をまとめて無視します
+var selectionKeys = map[string]bool{"game": true, "archive_format": true, "subtype": true, "type": true}
+
+// Value は設定値とその指定元 (エラーメッセージ用)
+type Value struct {
+	Items  []string // 値 (複数指定できるキー以外は1つ)
+	Source string   // 設定ファイルのパスと項目名、または環境変数名
+}
+
+// Values はキーごとの設定値
+type Values map[string]Value
+
+// Loader は設定ファイルと環境変数の読み込み方法を保持します
+type Loader struct {
+	Tool      string // 設定ファイルのツール別セクション名 (brightmoon, titles_th)
+	EnvPrefix string // 環境変数の接頭辞 (BRIGHTMOON_ など)
+
+	path    string // --config
+	profile string // --profile
+}
+
+// RegisterFlags は --config と --profile をフラグセットに登録します
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&l.path, "config", "", "configuration `file` (default: "+displayDefaultPath()+")")
+	fs.StringVar(&l.profile, "profile", "", "configuration profile `name` ([profile.<name>] in the configuration file)")
+}
+
+// Load は設定ファイルと環境変数を読み込み、それぞれの設定値を返します
+// 設定ファイルの場所は --config、<接頭辞>CONFIG、既定の場所の順に、プロファイルは --profile、<接頭辞>PROFILE の順に決定します
+// 既定の場所に設定ファイルがない場合は、設定ファイルの値を空として扱います
+func (l *Loader) Load(lookupEnv func(string) (string, bool)) (file, env Values, err error) {
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	path, explicit := l.path, l.path != ""
+	if !explicit {
+		if p, ok := lookupEnv(l.EnvPrefix + "CONFIG"); ok && p != "" {
+			path, explicit = p, true
+		}
+	}
+	if !explicit {
+		if path, err = DefaultPath(); err != nil {
+			path = ""
+		}
+	}
+	profile := l.profile
+	if profile == "" {
+		profile, _ = lookupEnv(l.EnvPrefix + "PROFILE")
+	}
+
+	if path != "" && !explicit {
+		if _, statErr := os.Stat(path); statErr != nil {
+			path = ""
+		}
+	}
+	if path == "" {
+		if profile != "" {
+			return nil, nil, i18n.Errorf("プロファイル %s を指定しましたが、設定ファイルがありません (%s)", profile, displayDefaultPath())
+		}
+	} else if file, err = LoadFile(path, l.Tool, profile); err != nil {
+		return nil, nil, err
+	}
+	return file, FromEnv(l.EnvPrefix, lookupEnv), nil
+}
+
+// DefaultPath は設定ファイルの既定の場所を返します
+// Linux などでは $XDG_CONFIG_HOME/brightmoon/config.toml (未設定の場合は ~/.config/brightmoon/config.toml) です
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DirName, FileName), nil
+}
+
+// displayDefaultPath は使用方法に表示する既定の場所を返します
+func displayDefaultPath() string {
+	if path, err := DefaultPath(); err == nil {
+		return path
+	}
+	return filepath.Join("$XDG_CONFIG_HOME", DirName, FileName)
+}
+
+// LoadFile は設定ファイルを読み込み、共通の値・tool のセクション・プロファイルの値をこの順に重ねた設定値を返します
+// profile が空でない場合、設定ファイルにそのプロファイルがなければエラーを返します
+func LoadFile(path, tool, profile string) (Values, error) {
+	var doc map[string]any
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		return nil, i18n.Errorf("設定ファイルを読み込めません: %w", err)
+	}
+
+	values := make(Values)
+	var toolSection, profileSection map[string]any
+	for _, key := range sortedKeys(doc) {
+		switch v := doc[key]; key {
+		case "brightmoon", "titles_th":
+			table, ok := v.(map[string]any)
+			if !ok {
+				return nil, i18n.Errorf("%s: [%s] はテーブルで指定してください", path, key)
+			}
+			if key == tool {
+				toolSection = table
+			}
+		case "profile":
+			profiles, ok := v.(map[string]any)
+			if !ok {
+				return nil, i18n.Errorf("%s: [profile.<名前>] の形式で指定してください", path)
+			}
+			for _, name := range sortedKeys(profiles) {
+				table, ok := profiles[name].(map[string]any)
+				if !ok {
+					return nil, i18n.Errorf("%s: [profile.%s] はテーブルで指定してください", path, name)
+				}
+				// 選択していないプロファイルの書き間違いも検出する
+				if err := make(Values).merge(path, "profile."+name+".", table, nil); err != nil {
+					return nil, err
+				}
+				if name == profile {
+					profileSection = table
+				}
+			}
+		default:
+			if !isKey(key) {
+				return nil, i18n.Errorf("%s: 不明な設定項目です: %s", path, key)
+			}
+		}
+	}
+	if profile != "" && profileSection == nil {
+		return nil, i18n.Errorf("%s: プロファイル %s がありません (%s)", path, profile, strings.Join(profileNames(doc), ", "))
+	}
+
+	if err := values.merge(path, "", doc, map[string]bool{"brightmoon": true, "titles_th": true, "profile": true}); err != nil {
+		return nil, err
+	}
+	if err := values.merge(path, tool+".", toolSection, nil); err != nil {
+		return nil, err
+	}
+	if err := values.merge(path, "profile."+profile+".", profileSection, nil); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// merge はテーブルの値を設定値に上書きします (skip のキーは除外)
+// テーブルにアーカイブ形式を選択するキーがある場合は、既存の選択をまとめて置き換えます
+func (v Values) merge(path, prefix string, table map[string]any, skip map[string]bool) error {
+	if v.hasSelection(table) {
+		for key := range selectionKeys {
+			delete(v, key)
+		}
+	}
+	for _, key := range sortedKeys(table) {
+		if skip[key] {
+			continue
+		}
+		source := fmt.Sprintf("%s (%s%s)", path, prefix, key)
+		if !isKey(key) {
+			return i18n.Errorf("%s: 不明な設定項目です", source)
+		}
+		items, err := tomlItems(table[key])
+		if err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+		v[key] = Value{Items: items, Source: source}
+	}
+	return nil
+}
+
+// tomlItems は TOML の値を文字列に変換します (配列は要素ごと)
+func tomlItems(value any) ([]string, error) {
+	switch x := value.(type) {
+	case string:
+		return []string{x}, nil
+	case int64:
+		return []string{strconv.FormatInt(x, 10)}, nil
+	case bool:
+		return []string{strconv.FormatBool(x)}, nil
+	case []any:
+		var items []string
+		for _, e := range x {
+			item, err := tomlItems(e)
+			if err != nil || len(item) != 1 {
+				return nil, errors.New(i18n.T("配列には文字列・整数・真偽値のみ指定できます"))
+			}
+			items = append(items, item...)
+		}
+		return items, nil
+	}
+	return nil, i18n.Errorf("対応していない値です: %v", value)
+}
+
+// hasSelection はテーブルにアーカイブ形式を選択するキーがあるかを返します
+func (v Values) hasSelection(table map[string]any) bool {
+	for key := range table {
+		if selectionKeys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// FromEnv は <接頭辞><キーの大文字> の環境変数から設定値を読み込みます (例: BRIGHTMOON_OUT)
+// 複数指定できる値はパスの区切り文字 (Unix では :) で区切ります
+func FromEnv(prefix string, lookupEnv func(string) (string, bool)) Values {
+	values := make(Values)
+	for _, key := range Keys {
+		name := prefix + strings.ToUpper(key)
+		s, ok := lookupEnv(name)
+		if !ok || s == "" {
+			continue
+		}
+		items := []string{s}
+		if key == "crypt_def" {
+			items = filepath.SplitList(s)
+		}
+		values[key] = Value{Items: items, Source: i18n.Sprintf("環境変数 %s", name)}
+	}
+	return values
+}
+
+// Binding は設定のキーとフラグの対応
+type Binding struct {
+	Key   string   // 設定のキー
+	Flags []string // 対応するフラグ名 (別名を含む、最初に見つかったフラグに値を設定)
+}
+
+// Apply はコマンドラインで指定されていないフラグに設定値を適用します
+// layers は優先順位の低い順 (設定ファイル、環境変数) に指定します
+// アーカイブ形式を選択するキーは、コマンドラインで1つでも指定されていれば適用せず、
+// それ以外の場合は値を持つ最も優先順位の高い層の値のみを適用します
+func Apply(fs *flag.FlagSet, bindings []Binding, layers ...Values) error {
+	visited := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { visited[f.Name] = true })
+
+	// アーカイブ形式の選択を適用する層を決める (len(layers) はコマンドライン)
+	selectionLayer := -1
+	for _, b := range bindings {
+		if !selectionKeys[b.Key] || b.lookup(fs) == nil {
+			continue
+		}
+		if b.isVisited(visited) {
+			selectionLayer = len(layers)
+			break
+		}
+		for i, layer := range layers {
+			if _, ok := layer[b.Key]; ok && i > selectionLayer {
+				selectionLayer = i
+			}
+		}
+	}
+
+	for _, b := range bindings {
+		f := b.lookup(fs)
+		if f == nil || b.isVisited(visited) {
+			continue
+		}
+		value, ok := Value{}, false
+		if selectionKeys[b.Key] {
+			if selectionLayer >= 0 && selectionLayer < len(layers) {
+				value, ok = layers[selectionLayer][b.Key]
+			}
+		} else {
+			value, ok = Lookup(b.Key, layers...)
+		}
+		if !ok {
+			continue
+		}
+		for _, item := range value.Items {
+			if err := fs.Set(f.Name, item); err != nil {
+				return i18n.Errorf("%s: 値 %q を -%s に設定できません: %w", value.Source, item, f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup は最も優先順位の高い層の設定値を返します (layers は優先順位の低い順)
+func Lookup(key string, layers ...Values) (Value, bool) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		if v, ok := layers[i][key]; ok {
+			return v, true
+		}
+	}
+	return Value{}, false
+}
+
+// lookup はフラグセットに登録されている最初のフラグを返します
+func (b Binding) lookup(fs *flag.FlagSet) *flag.Flag {
+	for _, name := range b.Flags {
+		if f := fs.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// isVisited はフラグ (別名を含む) がコマンドラインで指定されているかを返します
+func (b Binding) isVisited(visited map[string]bool) bool {
+	for _, name := range b.Flags {
+		if visited[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// isKey は設定のキーとして有効かを返します
+func isKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// profileNames は設定ファイルのプロファイル名の一覧を返します
+func profileNames(doc map[string]any) []string {
+	profiles, _ := doc["profile"].(map[string]any)
+	return sortedKeys(profiles)
+}
+
+// sortedKeys はマップのキーを整列して返します
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
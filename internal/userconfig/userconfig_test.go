@@ -0,0 +1,260 @@
+package userconfig
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+out = "./extracted"
+workers = 8
+parallel = true
+game = "th08"
+
+[brightmoon]
+overwrite = "never"
+
+[titles_th]
+out = "./titles"
+
+[profile.th17]
+archive = "/games/th17/th17.dat"
+out = "./th17"
+archive_format = "kanako"
+subtype = "td"
+crypt_def = ["a.json", "b.toml"]
+`
+
+// writeConfig はテスト用の設定ファイルを作成します
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	return path
+}
+
+// items は設定値を比較しやすい形に変換します
+func items(values Values) map[string][]string {
+	m := make(map[string][]string)
+	for k, v := range values {
+		m[k] = v.Items
+	}
+	return m
+}
+
+// env はテスト用の環境変数の検索関数を返します
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfig(t, testConfig)
+
+	tests := []struct {
+		name    string
+		tool    string
+		profile string
+		want    map[string][]string
+	}{
+		{"brightmoon", "brightmoon", "", map[string][]string{
+			"out": {"./extracted"}, "workers": {"8"}, "parallel": {"true"}, "game": {"th08"}, "overwrite": {"never"},
+		}},
+		{"titles_th", "titles_th", "", map[string][]string{
+			"out": {"./titles"}, "workers": {"8"}, "parallel": {"true"}, "game": {"th08"},
+		}},
+		// プロファイルの archive_format/subtype は共通の game をまとめて置き換える
+		{"profile", "brightmoon", "th17", map[string][]string{
+			"out": {"./th17"}, "workers": {"8"}, "parallel": {"true"}, "overwrite": {"never"},
+			"archive": {"/games/th17/th17.dat"}, "archive_format": {"kanako"}, "subtype": {"td"}, "crypt_def": {"a.json", "b.toml"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := LoadFile(path, tt.tool, tt.profile)
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if got := items(values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	values, _ := LoadFile(path, "brightmoon", "th17")
+	if src := values["out"].Source; !strings.Contains(src, "profile.th17.out") {
+		t.Errorf("Source = %q, want profile.th17.out", src)
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		profile string
+		wantErr string
+	}{
+		{"unknown key", "outdir = \"x\"\n", "", "不明な設定項目です: outdir"},
+		{"unknown key in profile", "[profile.a]\nworker = 1\n", "", "profile.a.worker"},
+		{"missing profile", "[profile.a]\nout = \"x\"\n", "b", "プロファイル b がありません (a)"},
+		{"invalid value", "out = 1.5\n", "", "対応していない値です"},
+		{"syntax error", "out = \n", "", "設定ファイルを読み込めません"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFile(writeConfig(t, tt.content), "brightmoon", tt.profile)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoader_Load(t *testing.T) {
+	path := writeConfig(t, testConfig)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// 既定の場所に設定ファイルがない場合は環境変数のみ
+	loader := &Loader{Tool: "brightmoon", EnvPrefix: "BRIGHTMOON_"}
+	file, envValues, err := loader.Load(env(map[string]string{"BRIGHTMOON_WORKERS": "2", "BRIGHTMOON_CRYPT_DEF": "a.json" + string(os.PathListSeparator) + "b.json"}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(file) != 0 {
+		t.Errorf("file values = %v, want empty", file)
+	}
+	if want := map[string][]string{"workers": {"2"}, "crypt_def": {"a.json", "b.json"}}; !reflect.DeepEqual(items(envValues), want) {
+		t.Errorf("env values = %v, want %v", items(envValues), want)
+	}
+
+	// 既定の場所に設定ファイルがない場合にプロファイルを指定するとエラー
+	if _, _, err := loader.Load(env(map[string]string{"BRIGHTMOON_PROFILE": "th17"})); err == nil {
+		t.Error("Load() should return error for a profile without configuration file")
+	}
+
+	// 環境変数で設定ファイルとプロファイルを指定
+	file, _, err = loader.Load(env(map[string]string{"BRIGHTMOON_CONFIG": path, "BRIGHTMOON_PROFILE": "th17"}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := file["out"].Items; len(got) != 1 || got[0] != "./th17" {
+		t.Errorf("out = %v, want ./th17", got)
+	}
+
+	// --config で指定したファイルがない場合はエラー
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--config", filepath.Join(t.TempDir(), "missing.toml")}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, _, err := loader.Load(env(nil)); err == nil {
+		t.Error("Load() should return error for a missing --config file")
+	}
+}
+
+func TestApply(t *testing.T) {
+	bindings := []Binding{
+		{Key: "out", Flags: []string{"o"}},
+		{Key: "workers", Flags: []string{"w"}},
+		{Key: "debug", Flags: []string{"debug", "d"}},
+		{Key: "game", Flags: []string{"game"}},
+		{Key: "archive_format", Flags: []string{"archive-format"}},
+		{Key: "subtype", Flags: []string{"subtype"}},
+		{Key: "crypt_def", Flags: []string{"crypt-def"}},
+		{Key: "dry_run", Flags: []string{"dry-run"}},
+	}
+	file := Values{
+		"out":            {Items: []string{"file-out"}},
+		"workers":        {Items: []string{"8"}},
+		"debug":          {Items: []string{"true"}},
+		"archive_format": {Items: []string{"kanako"}},
+		"subtype":        {Items: []string{"td"}},
+		"crypt_def":      {Items: []string{"a.json", "b.json"}},
+		"dry_run":        {Items: []string{"true"}},
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		env    Values
+		want   map[string]string
+		wantCD []string
+	}{
+		{
+			name: "file only",
+			want: map[string]string{"o": "file-out", "w": "8", "debug": "true", "game": "", "archive-format": "kanako", "subtype": "td"},
+			// 複数指定できるフラグには全ての値を設定する
+			wantCD: []string{"a.json", "b.json"},
+		},
+		{
+			name: "env overrides file",
+			env:  Values{"out": {Items: []string{"env-out"}}, "game": {Items: []string{"th17"}}},
+			// 環境変数の game は設定ファイルの archive_format/subtype をまとめて置き換える
+			want:   map[string]string{"o": "env-out", "w": "8", "game": "th17", "archive-format": "", "subtype": ""},
+			wantCD: []string{"a.json", "b.json"},
+		},
+		{
+			name: "flags override env and file",
+			args: []string{"-o", "flag-out", "-d=false", "-game", "th08", "-crypt-def", "c.json"},
+			env:  Values{"out": {Items: []string{"env-out"}}},
+			want: map[string]string{"o": "flag-out", "w": "8", "debug": "false", "game": "th08", "archive-format": "", "subtype": ""},
+			// コマンドラインで指定したフラグには設定の値を追加しない
+			wantCD: []string{"c.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("o", ".", "")
+			fs.Int("w", 4, "")
+			var debug bool
+			fs.BoolVar(&debug, "debug", false, "")
+			fs.BoolVar(&debug, "d", false, "")
+			fs.String("game", "", "")
+			fs.String("archive-format", "", "")
+			fs.String("subtype", "", "")
+			var cryptDefs listFlag
+			fs.Var(&cryptDefs, "crypt-def", "")
+			// dry-run はこのフラグセットにないため無視される
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if err := Apply(fs, bindings, file, tt.env); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			for name, want := range tt.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("-%s = %q, want %q", name, got, want)
+				}
+			}
+			if !reflect.DeepEqual([]string(cryptDefs), tt.wantCD) {
+				t.Errorf("-crypt-def = %v, want %v", cryptDefs, tt.wantCD)
+			}
+		})
+	}
+}
+
+func TestApply_InvalidValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("w", 4, "")
+	file := Values{"workers": {Items: []string{"many"}, Source: "config.toml (workers)"}}
+	err := Apply(fs, []Binding{{Key: "workers", Flags: []string{"w"}}}, file)
+	if err == nil || !strings.Contains(err.Error(), "config.toml (workers)") {
+		t.Errorf("Apply() error = %v, want error with source", err)
+	}
+}
+
+// listFlag は複数回指定できるテスト用のフラグ
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
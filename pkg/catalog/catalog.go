@@ -0,0 +1,254 @@
+// Package catalog は東方Project作品のアーカイブに関する情報をまとめたカタログです。
+//
+// 作品ごとに ID、タイトル、作品ナンバー、アーカイブのファイル名、アーカイブ形式とサブタイプ、
+// BGM ファイルの構成を保持し、ゲームID・タイトル・ファイル名から作品を検索できます。
+//
+// 基本的な使い方:
+//
+//	if game, ok := catalog.FromFileName("th128.dat"); ok {
+//	    fmt.Println(game.Title, game.Format, game.SubType) // 妖精大戦争 Kanako 1
+//	}
+package catalog
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format はアーカイブ形式 (pbgarc の各アーカイブ実装に対応します)
+type Format string
+
+// アーカイブ形式
+const (
+	FormatRemilia  Format = "Remilia"  // 東方紅魔郷 (PBG3形式)
+	FormatYukari   Format = "Yukari"   // 東方妖々夢 (PBG4形式)
+	FormatYumemi   Format = "Yumemi"   // 8.3形式のファイル名を持つ旧形式
+	FormatSuica    Format = "Suica"    // Suica 形式
+	FormatHinanawi Format = "Hinanawi" // 東方緋想天・東方非想天則 (MT 暗号化形式)
+	FormatMarisa   Format = "Marisa"   // Marisa 形式
+	FormatKaguya   Format = "Kaguya"   // 東方永夜抄・東方花映塚 (PBGZ形式)
+	FormatKanako   Format = "Kanako"   // 東方文花帖・東方風神録以降 (THA1形式)
+	FormatKokoro   Format = "Kokoro"   // 東方心綺楼以降の黄昏フロンティア作品 (TFPK形式)
+)
+
+// Kaguya 形式のサブタイプ (pbgarc.KaguyaArchive.SetArchiveType に渡す値)
+const (
+	KaguyaIN     = 0 // 東方永夜抄 (TH08)
+	KaguyaLegacy = 1 // 東方花映塚 (TH09) と同じ表の旧番号 (-t 1 との互換用。カタログのゲームは使用しない)
+	KaguyaPoFV   = 2 // 東方花映塚 (TH09)
+)
+
+// Kanako 形式のサブタイプ (pbgarc.ARCHTYPE_* と同じ値)
+const (
+	KanakoMoF = 0 // 東方文花帖 (TH09.5) / 東方風神録 (TH10) / 東方地霊殿 (TH11)
+	KanakoUFO = 1 // 東方星蓮船 (TH12) / ダブルスポイラー (TH12.5) / 妖精大戦争 (TH12.8)
+	KanakoTD  = 2 // 東方神霊廟 (TH13) 以降
+)
+
+// NoSubType はサブタイプを持たない形式の SubType
+const NoSubType = -1
+
+// BGM は作品の BGM ファイルの構成
+type BGM struct {
+	// Stream は全曲の波形データをまとめたファイル (例: thbgm.dat)
+	// 空の場合、曲はアーカイブ内の個別ファイルとして格納されています
+	Stream string
+	// Format はアーカイブ内のループ位置情報ファイル (例: thbgm.fmt)
+	Format string
+	// Comment はアーカイブ内の曲名・コメントファイル (例: musiccmt.txt)
+	Comment string
+}
+
+// Trial は体験版での BGM ファイル名を返します (thbgm.fmt -> thbgm_tr.fmt)
+func (b BGM) Trial() BGM {
+	return BGM{Stream: trialName(b.Stream), Format: trialName(b.Format), Comment: trialName(b.Comment)}
+}
+
+// trialName はファイル名の拡張子の前に _tr を付けます
+func trialName(name string) string {
+	if name == "" {
+		return ""
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_tr" + ext
+}
+
+// Game は作品の情報
+type Game struct {
+	ID            string   // ゲームID (例: th128)
+	Title         string   // 日本語タイトル (例: 妖精大戦争)
+	TitleEN       string   // 英語タイトル (例: Fairy Wars)
+	Number        string   // 作品ナンバー (例: 12.8)
+	Archives      []string // 製品版のアーカイブファイル名
+	TrialArchives []string // 体験版のアーカイブファイル名
+	Format        Format   // アーカイブ形式
+	SubType       int      // Kaguya/Kanako 形式のサブタイプ (それ以外は NoSubType)
+	SubTypeName   string   // サブタイプの略称 (例: ufo)
+	BGM           BGM      // BGM ファイルの構成
+}
+
+// IDNumber はゲームIDの数字部分を返します (例: th128 -> 128, th095 -> 95)
+func (g Game) IDNumber() int {
+	n, err := strconv.Atoi(strings.TrimPrefix(g.ID, "th"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// StreamBGM は thbgm.dat にまとめられた BGM を持つ作品 (東方妖々夢以降の STG) の構成
+var StreamBGM = BGM{Stream: "thbgm.dat", Format: "thbgm.fmt", Comment: "musiccmt.txt"}
+
+// games は対応作品の一覧 (作品ナンバー順)
+var games = []Game{
+	{"th06", "東方紅魔郷", "the Embodiment of Scarlet Devil", "6",
+		[]string{"紅魔郷CM.DAT", "紅魔郷ED.DAT", "紅魔郷IN.DAT", "紅魔郷MD.DAT", "紅魔郷ST.DAT", "紅魔郷TL.DAT"}, nil,
+		FormatRemilia, NoSubType, "", BGM{}},
+	{"th07", "東方妖々夢", "Perfect Cherry Blossom", "7", []string{"th07.dat"}, []string{"th07tr.dat"},
+		FormatYukari, NoSubType, "", StreamBGM},
+	{"th075", "東方萃夢想", "Immaterial and Missing Power", "7.5", []string{"th075.dat"}, nil,
+		FormatSuica, NoSubType, "", BGM{}},
+	{"th08", "東方永夜抄", "Imperishable Night", "8", []string{"th08.dat"}, []string{"th08tr.dat"},
+		FormatKaguya, KaguyaIN, "in", StreamBGM},
+	{"th09", "東方花映塚", "Phantasmagoria of Flower View", "9", []string{"th09.dat"}, []string{"th09tr.dat"},
+		FormatKaguya, KaguyaPoFV, "pofv", StreamBGM},
+	{"th095", "東方文花帖", "Shoot the Bullet", "9.5", []string{"th095.dat"}, nil,
+		FormatKanako, KanakoMoF, "mof", StreamBGM},
+	{"th10", "東方風神録", "Mountain of Faith", "10", []string{"th10.dat"}, []string{"th10tr.dat"},
+		FormatKanako, KanakoMoF, "mof", StreamBGM},
+	{"th105", "東方緋想天", "Scarlet Weather Rhapsody", "10.5", []string{"th105a.dat", "th105b.dat", "th105c.dat"}, nil,
+		FormatHinanawi, NoSubType, "", BGM{}},
+	{"th11", "東方地霊殿", "Subterranean Animism", "11", []string{"th11.dat"}, []string{"th11tr.dat"},
+		FormatKanako, KanakoMoF, "mof", StreamBGM},
+	{"th12", "東方星蓮船", "Undefined Fantastic Object", "12", []string{"th12.dat"}, []string{"th12tr.dat"},
+		FormatKanako, KanakoUFO, "ufo", StreamBGM},
+	{"th123", "東方非想天則", "Touhou Hisoutensoku", "12.3", []string{"th123a.dat", "th123b.dat", "th123c.dat"}, nil,
+		FormatHinanawi, NoSubType, "", BGM{}},
+	{"th125", "ダブルスポイラー", "Double Spoiler", "12.5", []string{"th125.dat"}, []string{"th125tr.dat"},
+		FormatKanako, KanakoUFO, "ufo", StreamBGM},
+	{"th128", "妖精大戦争", "Fairy Wars", "12.8", []string{"th128.dat"}, []string{"th128tr.dat"},
+		FormatKanako, KanakoUFO, "ufo", StreamBGM},
+	{"th13", "東方神霊廟", "Ten Desires", "13", []string{"th13.dat"}, []string{"th13tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th135", "東方心綺楼", "Hopeless Masquerade", "13.5", []string{"th135.pak"}, nil,
+		FormatKokoro, NoSubType, "", BGM{}},
+	{"th14", "東方輝針城", "Double Dealing Character", "14", []string{"th14.dat"}, []string{"th14tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th143", "弾幕アマノジャク", "Impossible Spell Card", "14.3", []string{"th143.dat"}, []string{"th143tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th145", "東方深秘録", "Urban Legend in Limbo", "14.5", []string{"th145.pak"}, nil,
+		FormatKokoro, NoSubType, "", BGM{}},
+	{"th15", "東方紺珠伝", "Legacy of Lunatic Kingdom", "15", []string{"th15.dat"}, []string{"th15tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th155", "東方憑依華", "Antinomy of Common Flowers", "15.5", []string{"th155.pak"}, nil,
+		FormatKokoro, NoSubType, "", BGM{}},
+	{"th16", "東方天空璋", "Hidden Star in Four Seasons", "16", []string{"th16.dat"}, []string{"th16tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th165", "秘封ナイトメアダイアリー", "Violet Detector", "16.5", []string{"th165.dat"}, []string{"th165tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th17", "東方鬼形獣", "Wily Beast and Weakest Creature", "17", []string{"th17.dat"}, []string{"th17tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th18", "東方虹龍洞", "Unconnected Marketeers", "18", []string{"th18.dat"}, []string{"th18tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th185", "バレットフィリア達の闘市場", "100th Black Market", "18.5", []string{"th185.dat"}, []string{"th185tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th19", "東方獣王園", "Unfinished Dream of All Living Ghost", "19", []string{"th19.dat"}, []string{"th19tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+	{"th20", "東方錦上京", "Fossilized Wonders", "20", []string{"th20.dat"}, []string{"th20tr.dat"},
+		FormatKanako, KanakoTD, "td", StreamBGM},
+}
+
+// latestNumber はカタログに登録されている整数ナンバーの最新作
+// これより新しい作品は Kanako 形式 (神霊廟以降のサブタイプ) とみなします
+const latestNumber = 20
+
+// Games は対応作品の一覧を作品ナンバー順に返します
+func Games() []Game {
+	return append([]Game(nil), games...)
+}
+
+// Lookup はゲームID (大文字小文字を区別しません) または日本語・英語タイトルから作品を検索します
+func Lookup(name string) (Game, bool) {
+	name = strings.TrimSpace(name)
+	for _, g := range games {
+		if strings.EqualFold(g.ID, name) || g.Title == name || strings.EqualFold(g.TitleEN, name) {
+			return g, true
+		}
+	}
+	return Game{}, false
+}
+
+// ByIDNumber はゲームIDの数字部分から作品を検索します (例: 128 -> th128, 95 -> th095)
+// カタログにない 2 桁の新しい作品番号 (例: 21 -> th21) の場合は、神霊廟以降と同じ Kanako 形式の作品として返します
+func ByIDNumber(n int) (Game, bool) {
+	for _, g := range games {
+		if g.IDNumber() == n {
+			return g, true
+		}
+	}
+	if n > latestNumber && n < 100 {
+		id := "th" + strconv.Itoa(n)
+		return Game{
+			ID:            id,
+			Number:        strconv.Itoa(n),
+			Archives:      []string{id + ".dat"},
+			TrialArchives: []string{id + "tr.dat"},
+			Format:        FormatKanako,
+			SubType:       KanakoTD,
+			SubTypeName:   "td",
+			BGM:           StreamBGM,
+		}, true
+	}
+	return Game{}, false
+}
+
+// idPattern はファイル名先頭のゲームID (例: th128tr.dat -> 128)
+var idPattern = regexp.MustCompile(`^th(\d+)`)
+
+// FromFileName はアーカイブのファイル名 (パスを含んでもよい) から作品を検索します
+// 作品のアーカイブ名と一致しない場合は、先頭のゲームID (th128tr.dat -> th128) から検索します
+// カタログにないゲームIDのうち、新作とみなすのは先頭が 0 でない 2 桁の番号 (th21 など) だけです
+// (th085 のような番号は数値にすると th85 と区別できないため、未知の作品として扱います)
+func FromFileName(filename string) (Game, bool) {
+	base := filepath.Base(filename)
+	for _, g := range games {
+		for _, name := range append(append([]string(nil), g.Archives...), g.TrialArchives...) {
+			if strings.EqualFold(name, base) {
+				return g, true
+			}
+		}
+	}
+
+	lower := strings.ToLower(base)
+	if strings.HasPrefix(lower, "紅魔郷") {
+		return Lookup("th06")
+	}
+	matches := idPattern.FindStringSubmatch(lower)
+	if matches == nil {
+		return Game{}, false
+	}
+	if g, ok := Lookup("th" + matches[1]); ok {
+		return g, true
+	}
+	if len(matches[1]) != 2 || matches[1][0] == '0' {
+		return Game{}, false
+	}
+	n, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return Game{}, false
+	}
+	return ByIDNumber(n)
+}
+
+// IsTrialArchive はファイル名が作品の体験版アーカイブかどうかを判定します
+func (g Game) IsTrialArchive(filename string) bool {
+	base := filepath.Base(filename)
+	for _, name := range g.TrialArchives {
+		if strings.EqualFold(name, base) {
+			return true
+		}
+	}
+	return false
+}
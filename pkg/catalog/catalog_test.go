@@ -0,0 +1,118 @@
+package catalog
+
+import "testing"
+
+func TestFromFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		wantID      string
+		wantFormat  Format
+		wantSubType int
+	}{
+		{"紅魔郷", "紅魔郷ST.DAT", "th06", FormatRemilia, NoSubType},
+		{"妖々夢", "th07.dat", "th07", FormatYukari, NoSubType},
+		{"萃夢想", "th075.dat", "th075", FormatSuica, NoSubType},
+		{"永夜抄", "th08.dat", "th08", FormatKaguya, KaguyaIN},
+		{"花映塚", "th09.dat", "th09", FormatKaguya, KaguyaPoFV},
+		{"文花帖", "th095.dat", "th095", FormatKanako, KanakoMoF},
+		{"地霊殿", "TH11.DAT", "th11", FormatKanako, KanakoMoF},
+		{"ダブルスポイラー", "th125.dat", "th125", FormatKanako, KanakoUFO},
+		{"妖精大戦争", "th128.dat", "th128", FormatKanako, KanakoUFO},
+		{"神霊廟", "th13.dat", "th13", FormatKanako, KanakoTD},
+		{"体験版", "/games/th20/th20tr.dat", "th20", FormatKanako, KanakoTD},
+		{"緋想天", "th105b.dat", "th105", FormatHinanawi, NoSubType},
+		{"心綺楼", "th135b.pak", "th135", FormatKokoro, NoSubType},
+		{"未登録の新作", "th21.dat", "th21", FormatKanako, KanakoTD},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, ok := FromFileName(tt.filename)
+			if !ok {
+				t.Fatalf("FromFileName(%q) not found", tt.filename)
+			}
+			if g.ID != tt.wantID || g.Format != tt.wantFormat || g.SubType != tt.wantSubType {
+				t.Errorf("FromFileName(%q) = %s %s %d, want %s %s %d", tt.filename, g.ID, g.Format, g.SubType, tt.wantID, tt.wantFormat, tt.wantSubType)
+			}
+		})
+	}
+
+	// 先頭が 0 の番号や 3 桁の番号から作品を作り出さないこと (th085 -> th85 などの誤判定)
+	for _, filename := range []string{"thbgm.dat", "test.dat", "th5.dat", "th085.dat", "th085tr.dat", "th0751.dat", "th215.dat", "th021.dat"} {
+		if g, ok := FromFileName(filename); ok {
+			t.Errorf("FromFileName(%q) = %s, want not found", filename, g.ID)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"th09", "TH09", "東方花映塚", "Phantasmagoria of Flower View"} {
+		g, ok := Lookup(name)
+		if !ok || g.ID != "th09" {
+			t.Errorf("Lookup(%q) = %s, %v, want th09", name, g.ID, ok)
+		}
+	}
+	if _, ok := Lookup("th99"); ok {
+		t.Error("Lookup(th99) found, want not found")
+	}
+}
+
+func TestByIDNumber(t *testing.T) {
+	tests := []struct {
+		n      int
+		wantID string
+	}{
+		{6, "th06"},
+		{75, "th075"},
+		{95, "th095"},
+		{128, "th128"},
+		{20, "th20"},
+		{99, "th99"},
+	}
+	for _, tt := range tests {
+		g, ok := ByIDNumber(tt.n)
+		if !ok || g.ID != tt.wantID {
+			t.Errorf("ByIDNumber(%d) = %s, %v, want %s", tt.n, g.ID, ok, tt.wantID)
+		}
+	}
+	for _, n := range []int{5, 100, 999} {
+		if g, ok := ByIDNumber(n); ok {
+			t.Errorf("ByIDNumber(%d) = %s, want not found", n, g.ID)
+		}
+	}
+}
+
+func TestGamesConsistency(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, g := range Games() {
+		if seen[g.ID] {
+			t.Errorf("duplicate game ID %s", g.ID)
+		}
+		seen[g.ID] = true
+
+		if g.Title == "" || g.TitleEN == "" || g.Number == "" || len(g.Archives) == 0 {
+			t.Errorf("%s: missing title, number or archive names", g.ID)
+		}
+		hasSubType := g.Format == FormatKaguya || g.Format == FormatKanako
+		if hasSubType != (g.SubType != NoSubType) || hasSubType != (g.SubTypeName != "") {
+			t.Errorf("%s: subtype %d (%q) does not match format %s", g.ID, g.SubType, g.SubTypeName, g.Format)
+		}
+		for _, name := range append(append([]string(nil), g.Archives...), g.TrialArchives...) {
+			if got, ok := FromFileName(name); !ok || got.ID != g.ID {
+				t.Errorf("FromFileName(%q) = %s, want %s", name, got.ID, g.ID)
+			}
+		}
+	}
+}
+
+func TestBGMTrial(t *testing.T) {
+	got := StreamBGM.Trial()
+	want := BGM{Stream: "thbgm_tr.dat", Format: "thbgm_tr.fmt", Comment: "musiccmt_tr.txt"}
+	if got != want {
+		t.Errorf("Trial() = %+v, want %+v", got, want)
+	}
+	if got := (BGM{}).Trial(); got != (BGM{}) {
+		t.Errorf("Trial() of empty BGM = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// フィンガープリントの計算に使う領域の大きさ
+// アーカイブのヘッダはファイルの先頭に、ファイル一覧は多くの形式でファイルの末尾に格納されています
+const (
+	fingerprintHeadSize = 4 << 10  // 先頭 4 KiB (ヘッダ)
+	fingerprintTailSize = 64 << 10 // 末尾 64 KiB (ファイル一覧)
+)
+
+// リリースの種類 (アーカイブの内容から判定します)
+const (
+	EditionProduct = "product" // 製品版
+	EditionTrial   = "trial"   // 体験版
+)
+
+// Fingerprint はアーカイブファイルのフィンガープリント
+// ファイル名に依存しないため、不具合報告などで名前を変更したファイルでも同じファイルかどうかを確認できます
+type Fingerprint struct {
+	Size   int64  `json:"size"`   // ファイルサイズ
+	SHA256 string `json:"sha256"` // 先頭 4 KiB と末尾 64 KiB の SHA-256 ハッシュ (16進数)
+}
+
+// String はフィンガープリントを "サイズ:ハッシュ" の形式で返します
+func (f Fingerprint) String() string {
+	return fmt.Sprintf("%d:%s", f.Size, f.SHA256)
+}
+
+// ComputeFingerprint はサイズ size のアーカイブのフィンガープリントを計算します
+// 先頭と末尾の領域が重なる小さなファイルは、ファイル全体のハッシュになります
+func ComputeFingerprint(r io.ReaderAt, size int64) (Fingerprint, error) {
+	h := sha256.New()
+	if size <= fingerprintHeadSize+fingerprintTailSize {
+		if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+			return Fingerprint{}, err
+		}
+	} else {
+		if _, err := io.Copy(h, io.NewSectionReader(r, 0, fingerprintHeadSize)); err != nil {
+			return Fingerprint{}, err
+		}
+		if _, err := io.Copy(h, io.NewSectionReader(r, size-fingerprintTailSize, fingerprintTailSize)); err != nil {
+			return Fingerprint{}, err
+		}
+	}
+	return Fingerprint{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// FingerprintFile はファイルのフィンガープリントを計算します
+func FingerprintFile(path string) (Fingerprint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	return ComputeFingerprint(f, info.Size())
+}
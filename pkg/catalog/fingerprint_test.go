@@ -0,0 +1,49 @@
+package catalog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestComputeFingerprint_Small(t *testing.T) {
+	data := []byte("PBG3 small archive")
+	fp, err := ComputeFingerprint(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ComputeFingerprint() error = %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if fp.Size != int64(len(data)) || fp.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("ComputeFingerprint() = %s, want whole-file hash", fp)
+	}
+}
+
+func TestComputeFingerprint_Large(t *testing.T) {
+	data := make([]byte, fingerprintHeadSize+fingerprintTailSize+1024)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	fp, err := ComputeFingerprint(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ComputeFingerprint() error = %v", err)
+	}
+	h := sha256.New()
+	h.Write(data[:fingerprintHeadSize])
+	h.Write(data[len(data)-fingerprintTailSize:])
+	if fp.SHA256 != hex.EncodeToString(h.Sum(nil)) {
+		t.Errorf("ComputeFingerprint() = %s, want head+tail hash", fp)
+	}
+
+	// 中間部分の変更はフィンガープリントに影響しない
+	data[fingerprintHeadSize+10] ^= 0xff
+	fp2, _ := ComputeFingerprint(bytes.NewReader(data), int64(len(data)))
+	if fp2 != fp {
+		t.Errorf("fingerprint changed by middle bytes: %s != %s", fp2, fp)
+	}
+	data[0] ^= 0xff
+	fp3, _ := ComputeFingerprint(bytes.NewReader(data), int64(len(data)))
+	if fp3 == fp {
+		t.Error("fingerprint not changed by header bytes")
+	}
+}
@@ -0,0 +1,46 @@
+package crypto
+
+import (
+	"fmt"
+	"io"
+)
+
+// BitWriter は io.Writer にビット単位でデータを書き込みます。
+// BitReader と同じく、各バイトの上位ビットから順に詰めていきます。
+type BitWriter struct {
+	writer io.Writer
+	buffer byte
+	count  uint // 現在のバッファ内のビット数 (0-7)
+	err    error
+}
+
+// NewBitWriter は新しい BitWriter を作成します。
+func NewBitWriter(w io.Writer) *BitWriter {
+	return &BitWriter{writer: w}
+}
+
+// Write は value の下位 numBits ビットを上位ビットから順に書き込みます。
+// 一度書き込みに失敗すると、以降の書き込みは同じエラーを返します。
+func (bw *BitWriter) Write(value uint32, numBits uint) error {
+	if numBits == 0 || numBits > 32 {
+		return fmt.Errorf("invalid number of bits to write: %d", numBits)
+	}
+	for i := int(numBits) - 1; i >= 0 && bw.err == nil; i-- {
+		bw.buffer = bw.buffer<<1 | byte((value>>uint(i))&1)
+		bw.count++
+		if bw.count == 8 {
+			_, bw.err = bw.writer.Write([]byte{bw.buffer})
+			bw.buffer, bw.count = 0, 0
+		}
+	}
+	return bw.err
+}
+
+// Flush は書きかけのバイトの残りのビットを 0 で埋めて書き込みます。
+func (bw *BitWriter) Flush() error {
+	if bw.err == nil && bw.count > 0 {
+		_, bw.err = bw.writer.Write([]byte{bw.buffer << (8 - bw.count)})
+		bw.buffer, bw.count = 0, 0
+	}
+	return bw.err
+}
@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBitWriter_RoundTrip(t *testing.T) {
+	values := []struct {
+		value   uint32
+		numBits uint
+	}{
+		{1, 1}, {0x41, 8}, {0, 1}, {0x1abc, 13}, {0x5, 4}, {0xdeadbeef, 32}, {0, 13},
+	}
+
+	buf := &bytes.Buffer{}
+	bw := NewBitWriter(buf)
+	for _, v := range values {
+		if err := bw.Write(v.value, v.numBits); err != nil {
+			t.Fatalf("Write(%#x, %d) error = %v", v.value, v.numBits, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	br := NewBitReader(bytes.NewReader(buf.Bytes()))
+	for _, v := range values {
+		got, err := br.Read(v.numBits)
+		if err != nil {
+			t.Fatalf("Read(%d) error = %v", v.numBits, err)
+		}
+		if uint32(got) != v.value {
+			t.Errorf("Read(%d) = %#x, want %#x", v.numBits, got, v.value)
+		}
+	}
+}
+
+func TestBitWriter_Flush(t *testing.T) {
+	// 1 01000001 (9ビット) は 0xA0 0x80 になる (残りは 0 で埋める)
+	buf := &bytes.Buffer{}
+	bw := NewBitWriter(buf)
+	bw.Write(1, 1)
+	bw.Write(0x41, 8)
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), []byte{0xA0, 0x80}) {
+		t.Errorf("output = % x, want a0 80", buf.Bytes())
+	}
+}
+
+func TestBitWriter_InvalidBits(t *testing.T) {
+	bw := NewBitWriter(&bytes.Buffer{})
+	if err := bw.Write(0, 0); err == nil {
+		t.Error("Write(0, 0) should fail")
+	}
+	if err := bw.Write(0, 33); err == nil {
+		t.Error("Write(0, 33) should fail")
+	}
+}
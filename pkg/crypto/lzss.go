@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"bufio"
+	"io"
+)
+
+// LZSS 圧縮の定数 (UNLZSS の形式に合わせる)
+const (
+	lzssOfsBits  = 13                                // 辞書のオフセットのビット数
+	lzssLenBits  = 4                                 // 一致の長さのビット数
+	lzssMinMatch = 3                                 // 一致として符号化する最小の長さ
+	lzssMaxMatch = lzssMinMatch + 1<<lzssLenBits - 1 // 長さ4ビットで表せる最大の長さ (18)
+	lzssMaxDist  = DictSize - 1                      // 辞書を上書きされる前に参照できる最大の距離
+	lzssHashBits = 13                                // 一致候補を探すハッシュ表のビット数
+	lzssMaxChain = 256                               // 一致候補をたどる最大の数
+	lzssNoPos    = -1                                // ハッシュ表の空き
+)
+
+// LZSS はデータを UNLZSS で展開できる形式に圧縮します
+// 一致はフラグ 0 + 辞書のオフセット (13ビット) + 長さ-3 (4ビット)、非圧縮データはフラグ 1 + 8ビットで、
+// 最後にオフセット 0 の終端を書き込みます
+// 辞書のオフセット 0 は終端を表すため、辞書の位置 0 にあるデータは一致として参照しません
+func LZSS(data []byte, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	bw := NewBitWriter(w)
+
+	head := make([]int, 1<<lzssHashBits)
+	for i := range head {
+		head[i] = lzssNoPos
+	}
+	prev := make([]int, len(data))
+	insert := func(pos int) {
+		if pos+lzssMinMatch > len(data) {
+			return
+		}
+		h := lzssHash(data[pos:])
+		prev[pos] = head[h]
+		head[h] = pos
+	}
+
+	for pos := 0; pos < len(data); {
+		bestLen, bestPos := 0, 0
+		if pos+lzssMinMatch <= len(data) {
+			limit := min(lzssMaxMatch, len(data)-pos)
+			for cand, chain := head[lzssHash(data[pos:])], 0; cand != lzssNoPos && pos-cand <= lzssMaxDist && chain < lzssMaxChain; cand, chain = prev[cand], chain+1 {
+				if lzssDictPos(cand) == 0 {
+					continue
+				}
+				n := 0
+				for n < limit && data[cand+n] == data[pos+n] {
+					n++
+				}
+				if n > bestLen {
+					bestLen, bestPos = n, cand
+					if n == limit {
+						break
+					}
+				}
+			}
+		}
+
+		if bestLen >= lzssMinMatch {
+			bw.Write(0, 1)
+			bw.Write(uint32(lzssDictPos(bestPos)), lzssOfsBits)
+			bw.Write(uint32(bestLen-lzssMinMatch), lzssLenBits)
+			for end := pos + bestLen; pos < end; pos++ {
+				insert(pos)
+			}
+			continue
+		}
+		bw.Write(1, 1)
+		bw.Write(uint32(data[pos]), 8)
+		insert(pos)
+		pos++
+	}
+
+	// 終端 (オフセット 0)
+	bw.Write(0, 1)
+	bw.Write(0, lzssOfsBits)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// lzssHash は先頭3バイトからハッシュ表の位置を求めます
+func lzssHash(b []byte) int {
+	v := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+	return int((v * 0x9e3779b1) >> (32 - lzssHashBits))
+}
+
+// lzssDictPos はデータの位置 pos のバイトが格納される UNLZSS の辞書の位置を返します
+func lzssDictPos(pos int) int {
+	return (pos + 1) % DictSize // UNLZSS は辞書の位置 1 から書き込む
+}
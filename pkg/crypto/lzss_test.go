@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestLZSS_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := make([]byte, 20000)
+	for i := range random {
+		random[i] = byte(rng.Uint32())
+	}
+	// 辞書の大きさを超える距離で繰り返すデータ (辞書が一周した後の参照を確認する)
+	wrapped := append(bytes.Repeat([]byte("0123456789abcdef"), 600), random[:DictSize]...)
+	wrapped = append(wrapped, wrapped[:DictSize+100]...)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"空", nil},
+		{"1バイト", []byte("A")},
+		{"短いテキスト", []byte("ECL test data")},
+		{"繰り返し", bytes.Repeat([]byte("abc"), 1000)},
+		{"同じバイトの連続", bytes.Repeat([]byte{0}, 10000)},
+		{"ランダム", random},
+		{"辞書を一周するデータ", wrapped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := &bytes.Buffer{}
+			if err := LZSS(tt.data, compressed); err != nil {
+				t.Fatalf("LZSS() error = %v", err)
+			}
+			out := &bytes.Buffer{}
+			if err := UNLZSS(bytes.NewReader(compressed.Bytes()), out); err != nil {
+				t.Fatalf("UNLZSS() error = %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), tt.data) {
+				t.Errorf("round trip mismatch: got %d bytes, want %d", out.Len(), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestLZSS_Compresses(t *testing.T) {
+	data := bytes.Repeat([]byte("th06_01.wav\x00"), 100)
+	compressed := &bytes.Buffer{}
+	if err := LZSS(data, compressed); err != nil {
+		t.Fatalf("LZSS() error = %v", err)
+	}
+	if compressed.Len() >= len(data)/4 {
+		t.Errorf("compressed size = %d, want less than %d", compressed.Len(), len(data)/4)
+	}
+}
@@ -0,0 +1,432 @@
+package pbgarc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// 暗号化パラメータ定義の形式名
+const (
+	CryptFormatKanako = "kanako"
+	CryptFormatKaguya = "kaguya"
+)
+
+// CryptDefinition は外部ファイル (JSON または TOML) から読み込む暗号化パラメータの定義
+// 新作や派生エンジンのアーカイブを、再コンパイルせずに Kanako/Kaguya 形式として開くために使用します
+// 省略した項目 (マジックナンバー・ヘッダやファイルリストの暗号化パラメータ・補正値) は組み込みの値を使用します
+type CryptDefinition struct {
+	Name        string           `json:"name" toml:"name"`                                   // 定義名 (--subtype で指定する名前)
+	Format      string           `json:"format" toml:"format"`                               // kanako または kaguya
+	Description string           `json:"description,omitempty" toml:"description,omitempty"` // 説明
+	Games       []string         `json:"games,omitempty" toml:"games,omitempty"`             // この定義を使う作品のゲームID (例: th21)
+	Magic       string           `json:"magic,omitempty" toml:"magic,omitempty"`             // マジックナンバー (4文字、例: THA1)
+	Header      *CryptDefParam   `json:"header,omitempty" toml:"header,omitempty"`           // ヘッダの暗号化パラメータ
+	List        *CryptDefParam   `json:"list,omitempty" toml:"list,omitempty"`               // ファイルリストの暗号化パラメータ (Kanako の limit はリスト全体)
+	Offsets     *CryptDefOffsets `json:"offsets,omitempty" toml:"offsets,omitempty"`         // ヘッダ値の補正値
+	Params      []CryptDefParam  `json:"params" toml:"params"`                               // エントリの暗号化パラメータ表
+
+	// ArchiveType は登録時に割り当てられる SetArchiveType の値
+	ArchiveType int `json:"-" toml:"-"`
+}
+
+// CryptDefParam は暗号化パラメータ (THCrypter の key/step/block/limit)
+type CryptDefParam struct {
+	Type  CryptDefValue `json:"type,omitempty" toml:"type,omitzero"` // データ種別 ('M' など、Kaguya のみ)
+	Key   CryptDefValue `json:"key" toml:"key"`
+	Step  CryptDefValue `json:"step" toml:"step"`
+	Block CryptDefValue `json:"block" toml:"block"`
+	Limit CryptDefValue `json:"limit,omitempty" toml:"limit,omitzero"`
+}
+
+// CryptDefOffsets はヘッダ値の補正値 (ヘッダに格納された値から差し引く定数)
+type CryptDefOffsets struct {
+	FileCount    *CryptDefValue `json:"file_count,omitempty" toml:"file_count,omitempty"`         // ファイル数
+	ListSize     *CryptDefValue `json:"list_size,omitempty" toml:"list_size,omitempty"`           // ファイルリストの展開後のサイズ
+	ListCompSize *CryptDefValue `json:"list_comp_size,omitempty" toml:"list_comp_size,omitempty"` // ファイルリストの圧縮サイズ (Kanako のみ)
+	ListOffset   *CryptDefValue `json:"list_offset,omitempty" toml:"list_offset,omitempty"`       // ファイルリストの位置 (Kaguya のみ)
+	OrigSize     *CryptDefValue `json:"orig_size,omitempty" toml:"orig_size,omitempty"`           // エントリの元サイズ (Kaguya のみ)
+}
+
+// CryptDefValue は定義ファイルの数値
+// JSON では数値のほか "0x1b" のような16進数の文字列、"M" のような1文字の文字列も指定できます
+type CryptDefValue int64
+
+// UnmarshalJSON は数値または文字列の値を読み込みます
+func (v *CryptDefValue) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return v.parseString(s)
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*v = CryptDefValue(n)
+	return nil
+}
+
+// UnmarshalTOML は整数または文字列の値を読み込みます (TOML では 0x1b のような16進数も整数として書けます)
+func (v *CryptDefValue) UnmarshalTOML(value any) error {
+	switch x := value.(type) {
+	case int64:
+		*v = CryptDefValue(x)
+		return nil
+	case string:
+		return v.parseString(x)
+	}
+	return fmt.Errorf("invalid value %v", value)
+}
+
+// parseString は "0x1b" などの数値の文字列、または1文字の文字列を読み込みます
+func (v *CryptDefValue) parseString(s string) error {
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		*v = CryptDefValue(n)
+		return nil
+	}
+	if len(s) == 1 {
+		*v = CryptDefValue(s[0])
+		return nil
+	}
+	return fmt.Errorf("invalid value %q", s)
+}
+
+// kanakoTable は Kanako アーカイブの暗号化パラメータ表 (SetArchiveType の値ごと)
+type kanakoTable struct {
+	name        string
+	description string
+	cryprm      []KanakoCryptParam
+	layout      kanakoLayout
+}
+
+// kaguyaTable は Kaguya アーカイブの暗号化パラメータ表 (SetArchiveType の値ごと)
+type kaguyaTable struct {
+	name        string
+	description string
+	cryprm      []CryptParam
+	layout      kaguyaLayout
+}
+
+// 組み込みと登録済みの暗号化パラメータ表 (添字が SetArchiveType の値)
+var (
+	cryptTablesMu sync.RWMutex
+	kanakoTables  = []kanakoTable{
+		{"mof", "TH10 Mountain of Faith / TH11 Subterranean Animism", kanakoCryprm1, defaultKanakoLayout},
+		{"ufo", "TH12 Undefined Fantastic Object / TH12.5 Double Spoiler / TH12.8 Fairy Wars", kanakoCryprm2, defaultKanakoLayout},
+		{"td", "TH13 Ten Desires and later games", kanakoCryprm3, defaultKanakoLayout},
+	}
+	kaguyaTables = []kaguyaTable{
+		{"in", "TH08 Imperishable Night", cryprm1, defaultKaguyaLayout},
+		{"legacy", "TH09 Phantasmagoria of Flower View (legacy -t 1 numbering)", cryprm2, defaultKaguyaLayout},
+		{"pofv", "TH09 Phantasmagoria of Flower View", cryprm3, defaultKaguyaLayout},
+	}
+	cryptDefinitions []*CryptDefinition
+)
+
+// LoadCryptDefinition は暗号化パラメータの定義ファイルを読み込みます
+// 拡張子が .toml の場合は TOML、それ以外は JSON として解釈します
+func LoadCryptDefinition(path string) (*CryptDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def := &CryptDefinition{}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		md, err := toml.Decode(string(data), def)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown key %s", path, undecoded[0])
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(def); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := def.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return def, nil
+}
+
+// RegisterCryptDefinition は暗号化パラメータの定義を組み込みの表に追加し、割り当てたアーカイブタイプを返します
+// 返された値を KanakoArchive/KaguyaArchive の SetArchiveType に渡すと、その定義でアーカイブを開けます
+func RegisterCryptDefinition(def *CryptDefinition) (int, error) {
+	if err := def.validate(); err != nil {
+		return -1, err
+	}
+
+	cryptTablesMu.Lock()
+	defer cryptTablesMu.Unlock()
+
+	name := strings.ToLower(def.Name)
+	switch strings.ToLower(def.Format) {
+	case CryptFormatKanako:
+		for _, t := range kanakoTables {
+			if t.name == name {
+				return -1, fmt.Errorf("crypt definition %q is already registered for kanako", def.Name)
+			}
+		}
+		table, err := def.kanakoTable()
+		if err != nil {
+			return -1, err
+		}
+		def.ArchiveType = len(kanakoTables)
+		kanakoTables = append(kanakoTables, table)
+	case CryptFormatKaguya:
+		for _, t := range kaguyaTables {
+			if t.name == name {
+				return -1, fmt.Errorf("crypt definition %q is already registered for kaguya", def.Name)
+			}
+		}
+		table, err := def.kaguyaTable()
+		if err != nil {
+			return -1, err
+		}
+		def.ArchiveType = len(kaguyaTables)
+		kaguyaTables = append(kaguyaTables, table)
+	}
+	cryptDefinitions = append(cryptDefinitions, def)
+	return def.ArchiveType, nil
+}
+
+// CryptDefinitions は登録済みの暗号化パラメータの定義を登録順に返します (組み込みの表は含みません)
+func CryptDefinitions() []*CryptDefinition {
+	cryptTablesMu.RLock()
+	defer cryptTablesMu.RUnlock()
+	return append([]*CryptDefinition(nil), cryptDefinitions...)
+}
+
+// LookupCryptTable は形式 (kanako/kaguya) と表の名前 (組み込みの mof, td などを含む) からアーカイブタイプを検索します
+func LookupCryptTable(format, name string) (int, bool) {
+	cryptTablesMu.RLock()
+	defer cryptTablesMu.RUnlock()
+	name = strings.ToLower(name)
+	switch strings.ToLower(format) {
+	case CryptFormatKanako:
+		for i, t := range kanakoTables {
+			if t.name == name {
+				return i, true
+			}
+		}
+	case CryptFormatKaguya:
+		for i, t := range kaguyaTables {
+			if t.name == name {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// CryptTableName は形式とアーカイブタイプから表の名前を返します (存在しない場合は空文字列)
+func CryptTableName(format string, archType int) string {
+	cryptTablesMu.RLock()
+	defer cryptTablesMu.RUnlock()
+	switch strings.ToLower(format) {
+	case CryptFormatKanako:
+		if archType >= 0 && archType < len(kanakoTables) {
+			return kanakoTables[archType].name
+		}
+	case CryptFormatKaguya:
+		if archType >= 0 && archType < len(kaguyaTables) {
+			return kaguyaTables[archType].name
+		}
+	}
+	return ""
+}
+
+// lookupKanakoTable はアーカイブタイプの表を返します (不明なタイプは風神録の表)
+func lookupKanakoTable(archType int) kanakoTable {
+	cryptTablesMu.RLock()
+	defer cryptTablesMu.RUnlock()
+	if archType >= 0 && archType < len(kanakoTables) {
+		return kanakoTables[archType]
+	}
+	return kanakoTables[ARCHTYPE_MOF]
+}
+
+// lookupKaguyaTable はアーカイブタイプの表を返します (不明なタイプは永夜抄の表)
+func lookupKaguyaTable(archType int) kaguyaTable {
+	cryptTablesMu.RLock()
+	defer cryptTablesMu.RUnlock()
+	if archType >= 0 && archType < len(kaguyaTables) {
+		return kaguyaTables[archType]
+	}
+	return kaguyaTables[0]
+}
+
+// validate は定義の必須項目と値の範囲を検証します
+func (def *CryptDefinition) validate() error {
+	if def.Name == "" {
+		return errors.New("crypt definition: name is required")
+	}
+	if def.Magic != "" && len(def.Magic) != 4 {
+		return fmt.Errorf("crypt definition %s: magic must be 4 characters: %q", def.Name, def.Magic)
+	}
+	var err error
+	switch strings.ToLower(def.Format) {
+	case CryptFormatKanako:
+		_, err = def.kanakoTable()
+	case CryptFormatKaguya:
+		_, err = def.kaguyaTable()
+	default:
+		return fmt.Errorf("crypt definition %s: unknown format %q (kanako or kaguya)", def.Name, def.Format)
+	}
+	return err
+}
+
+// kanakoTable は定義から Kanako の暗号化パラメータ表を作成します
+func (def *CryptDefinition) kanakoTable() (kanakoTable, error) {
+	if len(def.Params) != kanakoSlotCount {
+		return kanakoTable{}, fmt.Errorf("crypt definition %s: kanako requires %d params, got %d", def.Name, kanakoSlotCount, len(def.Params))
+	}
+	if o := def.Offsets; o != nil && (o.ListOffset != nil || o.OrigSize != nil) {
+		return kanakoTable{}, fmt.Errorf("crypt definition %s: list_offset and orig_size offsets are for kaguya", def.Name)
+	}
+
+	table := kanakoTable{name: strings.ToLower(def.Name), description: def.Description, layout: defaultKanakoLayout}
+	if table.description == "" {
+		table.description = def.Name
+	}
+	for i, p := range def.Params {
+		param, err := p.kanakoParam(true)
+		if err != nil {
+			return kanakoTable{}, fmt.Errorf("crypt definition %s: params[%d]: %w", def.Name, i, err)
+		}
+		table.cryprm = append(table.cryprm, param)
+	}
+
+	layout := &table.layout
+	if def.Magic != "" {
+		layout.magic = binary.LittleEndian.Uint32([]byte(def.Magic))
+	}
+	if def.Header != nil {
+		header, err := def.Header.kanakoParam(true)
+		if err != nil {
+			return kanakoTable{}, fmt.Errorf("crypt definition %s: header: %w", def.Name, err)
+		}
+		layout.header = header
+	}
+	if def.List != nil {
+		list, err := def.List.kanakoParam(false)
+		if err != nil {
+			return kanakoTable{}, fmt.Errorf("crypt definition %s: list: %w", def.Name, err)
+		}
+		layout.list = list
+	}
+	if o := def.Offsets; o != nil {
+		o.FileCount.apply(&layout.fileCountOffset)
+		o.ListSize.apply(&layout.listSizeOffset)
+		o.ListCompSize.apply(&layout.listCompSizeOffset)
+	}
+	return table, nil
+}
+
+// kaguyaTable は定義から Kaguya の暗号化パラメータ表を作成します
+func (def *CryptDefinition) kaguyaTable() (kaguyaTable, error) {
+	if len(def.Params) == 0 {
+		return kaguyaTable{}, fmt.Errorf("crypt definition %s: kaguya requires at least one param", def.Name)
+	}
+	if o := def.Offsets; o != nil && o.ListCompSize != nil {
+		return kaguyaTable{}, fmt.Errorf("crypt definition %s: list_comp_size offset is for kanako", def.Name)
+	}
+
+	table := kaguyaTable{name: strings.ToLower(def.Name), description: def.Description, layout: defaultKaguyaLayout}
+	if table.description == "" {
+		table.description = def.Name
+	}
+	seen := make(map[byte]bool)
+	for i, p := range def.Params {
+		if p.Type < 1 || p.Type > 0xff {
+			return kaguyaTable{}, fmt.Errorf("crypt definition %s: params[%d]: type must be 1-255", def.Name, i)
+		}
+		if seen[byte(p.Type)] {
+			return kaguyaTable{}, fmt.Errorf("crypt definition %s: params[%d]: duplicate type %#x", def.Name, i, p.Type)
+		}
+		seen[byte(p.Type)] = true
+		param, err := p.kanakoParam(true)
+		if err != nil {
+			return kaguyaTable{}, fmt.Errorf("crypt definition %s: params[%d]: %w", def.Name, i, err)
+		}
+		table.cryprm = append(table.cryprm, CryptParam{Type: byte(p.Type), Key: param.Key, Step: param.Step, Block: param.Block, Limit: param.Limit})
+	}
+
+	layout := &table.layout
+	if def.Magic != "" {
+		layout.magic = binary.LittleEndian.Uint32([]byte(def.Magic))
+	}
+	for _, target := range []struct {
+		name  string
+		param *CryptDefParam
+		dst   *KanakoCryptParam
+	}{{"header", def.Header, &layout.header}, {"list", def.List, &layout.list}} {
+		if target.param == nil {
+			continue
+		}
+		param, err := target.param.kanakoParam(true)
+		if err != nil {
+			return kaguyaTable{}, fmt.Errorf("crypt definition %s: %s: %w", def.Name, target.name, err)
+		}
+		*target.dst = param
+	}
+	if o := def.Offsets; o != nil {
+		o.FileCount.apply(&layout.fileCountOffset)
+		o.ListOffset.apply(&layout.listOffsetOffset)
+		o.ListSize.apply(&layout.listSizeOffset)
+		o.OrigSize.apply(&layout.origSizeAdjust)
+	}
+	return table, nil
+}
+
+// kanakoParam は値の範囲を検証して KanakoCryptParam に変換します
+// requireLimit が false の場合、limit の省略 (0) を許可します
+func (p CryptDefParam) kanakoParam(requireLimit bool) (KanakoCryptParam, error) {
+	switch {
+	case p.Key < 0 || p.Key > 0xff:
+		return KanakoCryptParam{}, fmt.Errorf("key must be 0-255: %d", p.Key)
+	case p.Step < 0 || p.Step > 0xff:
+		return KanakoCryptParam{}, fmt.Errorf("step must be 0-255: %d", p.Step)
+	case p.Block < 2 || p.Block > 0x100000:
+		return KanakoCryptParam{}, fmt.Errorf("block must be 2-0x100000: %d", p.Block)
+	case p.Limit < 0 || p.Limit > 0x7fffffff || (requireLimit && p.Limit == 0):
+		return KanakoCryptParam{}, fmt.Errorf("invalid limit: %d", p.Limit)
+	}
+	return KanakoCryptParam{Key: byte(p.Key), Step: byte(p.Step), Block: int(p.Block), Limit: int(p.Limit)}, nil
+}
+
+// apply は補正値が指定されている場合に dst を上書きします
+func (v *CryptDefValue) apply(dst *uint32) {
+	if v != nil {
+		*dst = uint32(*v)
+	}
+}
+
+// NewKanakoCryptDefinition は Kanako のパラメータ表から定義を作成します (crypt-scan の結果の保存などに使用します)
+func NewKanakoCryptDefinition(name string, params []KanakoCryptParam) *CryptDefinition {
+	def := &CryptDefinition{Name: name, Format: CryptFormatKanako}
+	for _, p := range params {
+		def.Params = append(def.Params, CryptDefParam{
+			Key:   CryptDefValue(p.Key),
+			Step:  CryptDefValue(p.Step),
+			Block: CryptDefValue(p.Block),
+			Limit: CryptDefValue(p.Limit),
+		})
+	}
+	return def
+}
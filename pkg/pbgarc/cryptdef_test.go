@@ -0,0 +1,242 @@
+package pbgarc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// restoreCryptTables はテスト終了時に登録済みの定義を元に戻します
+func restoreCryptTables(t *testing.T) {
+	t.Helper()
+	kanako := append([]kanakoTable(nil), kanakoTables...)
+	kaguya := append([]kaguyaTable(nil), kaguyaTables...)
+	defs := append([]*CryptDefinition(nil), cryptDefinitions...)
+	t.Cleanup(func() {
+		kanakoTables = kanako
+		kaguyaTables = kaguya
+		cryptDefinitions = defs
+	})
+}
+
+// writeCryptDef はテスト用の定義ファイルを作成します
+func writeCryptDef(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create definition: %v", err)
+	}
+	return path
+}
+
+// testKanakoCryprm は組み込みの表と異なるテスト用のパラメータ表です
+var testKanakoCryprm = []KanakoCryptParam{
+	{0x2b, 0x51, 0x100, 0x3000},
+	{0x4d, 0x6f, 0x80, 0x2800},
+	{0x1f, 0x33, 0x400, 0x1000},
+	{0x62, 0x85, 0x100, 0x2000},
+	{0xa7, 0x19, 0x200, 0x1800},
+	{0x3c, 0xe1, 0x80, 0x3800},
+	{0x90, 0x47, 0x400, 0x2000},
+	{0x05, 0xbb, 0x100, 0x0800},
+}
+
+const testKanakoCryptDefJSON = `{
+  "name": "test",
+  "format": "kanako",
+  "games": ["th99"],
+  "params": [
+    {"key": "0x2b", "step": "0x51", "block": "0x100", "limit": "0x3000"},
+    {"key": "0x4d", "step": "0x6f", "block": "0x80", "limit": "0x2800"},
+    {"key": "0x1f", "step": "0x33", "block": "0x400", "limit": "0x1000"},
+    {"key": "0x62", "step": "0x85", "block": "0x100", "limit": "0x2000"},
+    {"key": "0xa7", "step": "0x19", "block": "0x200", "limit": "0x1800"},
+    {"key": "0x3c", "step": "0xe1", "block": "0x80", "limit": "0x3800"},
+    {"key": "0x90", "step": "0x47", "block": "0x400", "limit": "0x2000"},
+    {"key": 5, "step": 187, "block": 256, "limit": 2048}
+  ]
+}`
+
+func TestLoadCryptDefinition_JSON(t *testing.T) {
+	def, err := LoadCryptDefinition(writeCryptDef(t, "test.json", testKanakoCryptDefJSON))
+	if err != nil {
+		t.Fatalf("LoadCryptDefinition() error = %v", err)
+	}
+	if def.Name != "test" || def.Format != CryptFormatKanako || len(def.Games) != 1 || def.Games[0] != "th99" {
+		t.Errorf("LoadCryptDefinition() = %+v", def)
+	}
+	table, err := def.kanakoTable()
+	if err != nil {
+		t.Fatalf("kanakoTable() error = %v", err)
+	}
+	for i, p := range table.cryprm {
+		if p != testKanakoCryprm[i] {
+			t.Errorf("params[%d] = %+v, want %+v", i, p, testKanakoCryprm[i])
+		}
+	}
+	if table.layout != defaultKanakoLayout {
+		t.Errorf("layout = %+v, want default", table.layout)
+	}
+}
+
+func TestLoadCryptDefinition_TOML(t *testing.T) {
+	content := `name = "pofv2"
+format = "kaguya"
+magic = "PBGY"
+
+[header]
+key = 0x1b
+step = 0x37
+block = 0x0c
+limit = 0x400
+
+[offsets]
+file_count = 654321
+orig_size = 8
+
+[[params]]
+type = "M"
+key = 0x1b
+step = 0x37
+block = 0x40
+limit = 0x2000
+
+[[params]]
+type = 0x54
+key = 0x51
+step = 0xe9
+block = 0x40
+limit = 0x3000
+`
+	def, err := LoadCryptDefinition(writeCryptDef(t, "pofv2.toml", content))
+	if err != nil {
+		t.Fatalf("LoadCryptDefinition() error = %v", err)
+	}
+	table, err := def.kaguyaTable()
+	if err != nil {
+		t.Fatalf("kaguyaTable() error = %v", err)
+	}
+	want := []CryptParam{{'M', 0x1b, 0x37, 0x40, 0x2000}, {'T', 0x51, 0xe9, 0x40, 0x3000}}
+	if len(table.cryprm) != len(want) || table.cryprm[0] != want[0] || table.cryprm[1] != want[1] {
+		t.Errorf("params = %+v, want %+v", table.cryprm, want)
+	}
+	layout := table.layout
+	if layout.magic != 0x59474250 || layout.fileCountOffset != 654321 || layout.origSizeAdjust != 8 {
+		t.Errorf("layout = %+v", layout)
+	}
+	if layout.listOffsetOffset != defaultKaguyaLayout.listOffsetOffset || layout.list != defaultKaguyaLayout.list {
+		t.Errorf("omitted layout values should keep defaults: %+v", layout)
+	}
+}
+
+func TestLoadCryptDefinition_Errors(t *testing.T) {
+	params := strings.Repeat(`{"key": 1, "step": 2, "block": 64, "limit": 256},`, 7)
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"missing name", "a.json", `{"format": "kanako", "params": []}`, "name is required"},
+		{"unknown format", "a.json", `{"name": "x", "format": "remilia", "params": []}`, "unknown format"},
+		{"param count", "a.json", `{"name": "x", "format": "kanako", "params": [` + params[:len(params)-1] + `]}`, "requires 8 params"},
+		{"magic length", "a.json", `{"name": "x", "format": "kanako", "magic": "TH", "params": []}`, "magic must be 4 characters"},
+		{"key range", "a.json", `{"name": "x", "format": "kanako", "params": [` + params + `{"key": 256, "step": 2, "block": 64, "limit": 256}]}`, "key must be 0-255"},
+		{"missing limit", "a.json", `{"name": "x", "format": "kanako", "params": [` + params + `{"key": 1, "step": 2, "block": 64}]}`, "invalid limit"},
+		{"unknown field", "a.json", `{"name": "x", "format": "kanako", "keys": []}`, "unknown field"},
+		{"kaguya type", "a.toml", "name = \"x\"\nformat = \"kaguya\"\n[[params]]\nkey = 1\nstep = 2\nblock = 64\nlimit = 256\n", "type must be 1-255"},
+		{"unknown toml key", "a.toml", "name = \"x\"\nformat = \"kaguya\"\nkeys = 1\n", "unknown key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCryptDefinition(writeCryptDef(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadCryptDefinition() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterCryptDefinition(t *testing.T) {
+	restoreCryptTables(t)
+
+	def, err := LoadCryptDefinition(writeCryptDef(t, "test.json", testKanakoCryptDefJSON))
+	if err != nil {
+		t.Fatalf("LoadCryptDefinition() error = %v", err)
+	}
+	archType, err := RegisterCryptDefinition(def)
+	if err != nil {
+		t.Fatalf("RegisterCryptDefinition() error = %v", err)
+	}
+	if archType != ARCHTYPE_TD+1 || def.ArchiveType != archType {
+		t.Errorf("RegisterCryptDefinition() = %d, want %d", archType, ARCHTYPE_TD+1)
+	}
+	if _, err := RegisterCryptDefinition(def); err == nil {
+		t.Error("RegisterCryptDefinition() should reject a duplicate name")
+	}
+	if _, err := RegisterCryptDefinition(&CryptDefinition{Name: "TD", Format: "kanako", Params: def.Params}); err == nil {
+		t.Error("RegisterCryptDefinition() should reject a built-in name")
+	}
+
+	if got, ok := LookupCryptTable("Kanako", "TEST"); !ok || got != archType {
+		t.Errorf("LookupCryptTable() = %d, %v, want %d", got, ok, archType)
+	}
+	if got, ok := LookupCryptTable("kanako", "td"); !ok || got != ARCHTYPE_TD {
+		t.Errorf("LookupCryptTable(td) = %d, %v", got, ok)
+	}
+	if _, ok := LookupCryptTable("kaguya", "test"); ok {
+		t.Error("LookupCryptTable() should not find a kanako table for kaguya")
+	}
+	if got := CryptTableName("kanako", archType); got != "test" {
+		t.Errorf("CryptTableName() = %q, want test", got)
+	}
+	if defs := CryptDefinitions(); len(defs) != 1 || defs[0] != def {
+		t.Errorf("CryptDefinitions() = %v", defs)
+	}
+	if got := len(GetArchiveTypeOptions()); got != 3 {
+		t.Errorf("GetArchiveTypeOptions() returned %d options, want 3", got)
+	}
+
+	// 登録した表で暗号化したアーカイブを展開できる
+	files := map[string][]byte{}
+	var names []string
+	for slot := 0; slot < kanakoSlotCount; slot++ {
+		name := kanakoNameForSlot("data", ".txt", slot)
+		files[name] = bytes.Repeat([]byte{byte('a' + slot)}, 0x1000+slot*0x300)
+		names = append(names, name)
+	}
+	path := buildKanakoArchive(t, testKanakoCryprm, files, names)
+
+	archive := NewKanakoArchive()
+	archive.SetArchiveType(archType)
+	if _, err := archive.Open(path); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer archive.Close()
+	count := 0
+	for ok := archive.EnumFirst(); ok; ok = archive.EnumNext() {
+		var buf bytes.Buffer
+		if !archive.GetEntry().Extract(&buf, nil, nil) || !bytes.Equal(buf.Bytes(), files[archive.GetEntryName()]) {
+			t.Errorf("Extract(%s) mismatch", archive.GetEntryName())
+		}
+		count++
+	}
+	if count != len(names) {
+		t.Errorf("extracted %d entries, want %d", count, len(names))
+	}
+}
+
+func TestNewKanakoCryptDefinition(t *testing.T) {
+	def := NewKanakoCryptDefinition("scan", testKanakoCryprm)
+	if err := def.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	table, _ := def.kanakoTable()
+	for i, p := range table.cryprm {
+		if p != testKanakoCryprm[i] {
+			t.Errorf("params[%d] = %+v, want %+v", i, p, testKanakoCryprm[i])
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/shiroemons/go-brightmoon/pkg/crypto"
 )
@@ -45,16 +46,19 @@ func (e *HinanawiEntry) Extract(w io.Writer, callback func(string, interface{})
 
 // HinanawiArchive はHinanawiアーカイブを表します
 type HinanawiArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []HinanawiEntry
 	curIndex int
 }
 
 // NewHinanawiArchive は新しいHinanawiArchiveを作成します
-func NewHinanawiArchive() *HinanawiArchive {
+func NewHinanawiArchive(opts ...Option) *HinanawiArchive {
 	return &HinanawiArchive{
-		entries:  make([]HinanawiEntry, 0),
-		curIndex: -1,
+		archiveLogger: newArchiveLogger("Hinanawi", opts),
+		entries:       make([]HinanawiEntry, 0),
+		curIndex:      -1,
 	}
 }
 
@@ -69,7 +73,9 @@ func (a *HinanawiArchive) Close() error {
 }
 
 // Open はアーカイブファイルを開きます (C++版のロジックに合わせて修正)
-func (a *HinanawiArchive) Open(filename string) (bool, error) {
+func (a *HinanawiArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -278,7 +284,12 @@ func (a *HinanawiArchive) Extract(w io.Writer, callback func(string, interface{}
 }
 
 // ExtractEntry は指定されたエントリを抽出します (C++版 Marisa/Hinanawi と同じ)
-func (a *HinanawiArchive) ExtractEntry(entry *HinanawiEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *HinanawiArchive) ExtractEntry(entry *HinanawiEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -289,11 +300,9 @@ func (a *HinanawiArchive) ExtractEntry(entry *HinanawiEntry, w io.Writer, callba
 	}
 
 	// データを読み込み
+	// ReadAt はファイル位置を共有しないため、複数のエントリを並行して抽出できる
 	data := make([]byte, entry.Size)
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		return false
-	}
-	if _, err := io.ReadFull(a.file, data); err != nil {
+	if _, err := a.file.ReadAt(data, int64(entry.Offset)); err != nil {
 		return false
 	}
 
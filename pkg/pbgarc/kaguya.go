@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/shiroemons/go-brightmoon/pkg/crypto"
 )
@@ -68,6 +69,45 @@ func (e *KaguyaEntry) GetCompressedSize() uint32 {
 	return e.CompSize
 }
 
+// GetDataType はエントリの "edz" ヘッダに格納されたデータタイプを取得します
+// タイプは展開後データの先頭にあるため、先頭4バイトのみを展開して読み取ります
+func (e *KaguyaEntry) GetDataType() (byte, error) {
+	if e.parent == nil {
+		return 0, errors.New("archive is not opened")
+	}
+
+	header := &kaguyaHeaderWriter{}
+	err := crypto.UNLZSS(io.NewSectionReader(e.parent.file, int64(e.Offset), int64(e.CompSize)), header)
+	if err != nil && !errors.Is(err, errKaguyaHeaderFull) {
+		return 0, fmt.Errorf("failed to decompress: %v", err)
+	}
+	if header.n < len(header.buf) {
+		return 0, errors.New("data too short after decompression")
+	}
+	if header.buf[0] != 'e' || header.buf[1] != 'd' || header.buf[2] != 'z' {
+		return 0, errors.New("invalid 'edz' magic")
+	}
+	return header.buf[3], nil
+}
+
+// errKaguyaHeaderFull は先頭4バイトを読み終えたことを示します
+var errKaguyaHeaderFull = errors.New("header full")
+
+// kaguyaHeaderWriter は展開データの先頭4バイトのみを受け取るライター
+type kaguyaHeaderWriter struct {
+	buf [4]byte
+	n   int
+}
+
+func (w *kaguyaHeaderWriter) Write(p []byte) (int, error) {
+	n := copy(w.buf[w.n:], p)
+	w.n += n
+	if w.n == len(w.buf) {
+		return n, errKaguyaHeaderFull
+	}
+	return n, nil
+}
+
 // Extract はエントリを抽出します
 func (e *KaguyaEntry) Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
 	if e.parent == nil {
@@ -77,13 +117,38 @@ func (e *KaguyaEntry) Extract(w io.Writer, callback func(string, interface{}) bo
 	return e.parent.ExtractEntry(e, w, callback, user)
 }
 
+// kaguyaLayout は Kaguya アーカイブのヘッダとファイルリストの形式 (マジックナンバー・補正値・暗号化パラメータ)
+type kaguyaLayout struct {
+	magic            uint32
+	fileCountOffset  uint32
+	listOffsetOffset uint32
+	listSizeOffset   uint32
+	origSizeAdjust   uint32
+	header           KanakoCryptParam
+	list             KanakoCryptParam
+}
+
+// defaultKaguyaLayout は全作品共通のヘッダとファイルリストの形式
+var defaultKaguyaLayout = kaguyaLayout{
+	magic:            KaguyaMagic,
+	fileCountOffset:  kaguyaFileCountOffset,
+	listOffsetOffset: kaguyaListOffsetOffset,
+	listSizeOffset:   kaguyaListSizeOffset,
+	origSizeAdjust:   kaguyaOrigSizeAdjust,
+	header:           KanakoCryptParam{kaguyaHeaderKey, kaguyaHeaderStep, kaguyaHeaderBlock, kaguyaHeaderLimit},
+	list:             KanakoCryptParam{kaguyaListKey, kaguyaListStep, kaguyaListBlock, kaguyaListLimit},
+}
+
 // KaguyaArchive はKaguyaアーカイブを表します
 type KaguyaArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []KaguyaEntry
 	curIndex int
 	cryprm   []CryptParam
-	archType int // 0: 永夜抄, 1: StB (弾幕アマノジャク)
+	layout   kaguyaLayout
+	archType int // 0: 永夜抄, 1: 花映塚 (旧番号), 2: 花映塚
 }
 
 // 永夜抄用暗号化パラメータ（Type=4）
@@ -98,7 +163,8 @@ var cryprm1 = []CryptParam{
 	{0x2a, 0x99, 0x37, 0x400, 0x1000},
 }
 
-// StB用暗号化パラメータ (弾幕アマノジャク TH143)
+// type=1 (legacy) 用暗号化パラメータ
+// 内容は東方花映塚 (TH09) の cryprm3 と同一で、旧来の -t 1 の番号を保つために残しています
 var cryprm2 = []CryptParam{
 	{0x4d, 0x1b, 0x37, 0x40, 0x2800},
 	{0x54, 0x51, 0xe9, 0x40, 0x3000},
@@ -123,12 +189,14 @@ var cryprm3 = []CryptParam{
 }
 
 // NewKaguyaArchive は新しいKaguyaArchiveを作成します
-func NewKaguyaArchive() *KaguyaArchive {
+func NewKaguyaArchive(opts ...Option) *KaguyaArchive {
 	return &KaguyaArchive{
-		entries:  make([]KaguyaEntry, 0),
-		curIndex: -1,
-		cryprm:   cryprm1, // デフォルトは永夜抄
-		archType: 0,
+		archiveLogger: newArchiveLogger("Kaguya", opts),
+		entries:       make([]KaguyaEntry, 0),
+		curIndex:      -1,
+		cryprm:        cryprm1, // デフォルトは永夜抄
+		layout:        defaultKaguyaLayout,
+		archType:      0,
 	}
 }
 
@@ -144,24 +212,25 @@ func (a *KaguyaArchive) Close() error {
 
 // SetArchiveType はアーカイブタイプを設定します
 // type=0: 永夜抄用 (TH08)
-// type=1: StB用 (弾幕アマノジャク TH143)
+// type=1: 花映塚用の旧番号 (cryprm3 と同じ表。旧来の -t 1 との互換用)
 // type=2: 花映塚用 (TH09)
+// 3 以上の値は RegisterCryptDefinition で登録した定義を表します
 func (a *KaguyaArchive) SetArchiveType(archType int) {
 	a.archType = archType
-	switch archType {
-	case 0:
-		a.cryprm = cryprm1 // 永夜抄 (TH08)
-	case 1:
-		a.cryprm = cryprm2 // 弾幕アマノジャク (TH143)
-	case 2:
-		a.cryprm = cryprm3 // 花映塚 (TH09)
-	default:
-		a.cryprm = cryprm1
-	}
+	table := lookupKaguyaTable(archType)
+	a.cryprm = table.cryprm
+	a.layout = table.layout
+}
+
+// GetArchiveType は現在のアーカイブタイプを取得します
+func (a *KaguyaArchive) GetArchiveType() int {
+	return a.archType
 }
 
 // Open はアーカイブファイルを開きます (C++版のロジックに合わせて修正)
-func (a *KaguyaArchive) Open(filename string) (bool, error) {
+func (a *KaguyaArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err, LogKeySubType, a.archType) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -188,13 +257,14 @@ func (a *KaguyaArchive) Open(filename string) (bool, error) {
 	if err := binary.Read(a.file, binary.LittleEndian, &magic); err != nil {
 		return false, fmt.Errorf("failed to read magic number: %w", err)
 	}
-	if magic != KaguyaMagic {
+	if magic != a.layout.magic {
 		return false, errors.New("invalid magic number")
 	}
 
 	// ヘッダを復号
 	headBuf := new(bytes.Buffer)
-	if !crypto.THCrypter(a.file, headBuf, kaguyaHeaderSize, kaguyaHeaderKey, kaguyaHeaderStep, kaguyaHeaderBlock, kaguyaHeaderLimit) {
+	header := a.layout.header
+	if !crypto.THCrypter(a.file, headBuf, kaguyaHeaderSize, header.Key, header.Step, header.Block, header.Limit) {
 		return false, errors.New("failed to decrypt header")
 	}
 
@@ -213,9 +283,9 @@ func (a *KaguyaArchive) Open(filename string) (bool, error) {
 	}
 
 	// 値を調整 (C++版の定数引き算)
-	fileCount -= kaguyaFileCountOffset
-	listOffset -= kaguyaListOffsetOffset
-	listSize -= kaguyaListSizeOffset // listSize は使われていないが、C++版に合わせて調整
+	fileCount -= a.layout.fileCountOffset
+	listOffset -= a.layout.listOffsetOffset
+	listSize -= a.layout.listSizeOffset // listSize は使われていないが、C++版に合わせて調整
 
 	// listOffset の検証
 	if int64(listOffset) >= fileSize {
@@ -231,7 +301,8 @@ func (a *KaguyaArchive) Open(filename string) (bool, error) {
 	compListSize := int(fileSize - int64(listOffset))
 	cryptedListReader := io.LimitReader(a.file, int64(compListSize))
 	compBuf := new(bytes.Buffer)
-	if !crypto.THCrypter(cryptedListReader, compBuf, compListSize, kaguyaListKey, kaguyaListStep, kaguyaListBlock, kaguyaListLimit) {
+	list := a.layout.list
+	if !crypto.THCrypter(cryptedListReader, compBuf, compListSize, list.Key, list.Step, list.Block, list.Limit) {
 		return false, errors.New("failed to decrypt list data")
 	}
 
@@ -274,7 +345,7 @@ func (a *KaguyaArchive) Open(filename string) (bool, error) {
 			return false, fmt.Errorf("failed to read entry metadata for %s: %w", entry.Name, errRead)
 		}
 
-		entry.OrigSize -= kaguyaOrigSizeAdjust // C++版の調整
+		entry.OrigSize -= a.layout.origSizeAdjust // C++版の調整
 
 		// オフセット検証
 		if int64(entry.Offset) >= fileSize {
@@ -356,7 +427,12 @@ func (a *KaguyaArchive) Extract(w io.Writer, callback func(string, interface{})
 }
 
 // ExtractEntry は指定されたエントリを抽出します (C++版のロジックに合わせて修正)
-func (a *KaguyaArchive) ExtractEntry(entry *KaguyaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *KaguyaArchive) ExtractEntry(entry *KaguyaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -366,17 +442,9 @@ func (a *KaguyaArchive) ExtractEntry(entry *KaguyaEntry, w io.Writer, callback f
 		}
 	}
 
-	// ファイルポインタをエントリの開始位置に移動
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		if callback != nil {
-			callback(fmt.Sprintf("failed to seek: %v\r\n", err), user)
-		}
-		return false
-	}
-
 	// 1. データを解凍 (UNLZSS)
-	// LimitReader を使用して CompSize 分だけ読み込む
-	compressedDataReader := io.LimitReader(a.file, int64(entry.CompSize))
+	// SectionReader でエントリの CompSize 分だけ読み込む (ファイル位置を共有しないため、複数のエントリを並行して抽出できる)
+	compressedDataReader := io.NewSectionReader(a.file, int64(entry.Offset), int64(entry.CompSize))
 	crypBuf := new(bytes.Buffer)
 	if err := crypto.UNLZSS(compressedDataReader, crypBuf); err != nil {
 		if callback != nil {
@@ -87,3 +87,54 @@ func TestKaguyaEntry_Methods(t *testing.T) {
 		t.Errorf("GetCompressedSize() = %d, want %d", size, 150)
 	}
 }
+
+func TestKaguyaEntry_GetDataType(t *testing.T) {
+	entry := &KaguyaEntry{Name: "test.txt"}
+	if _, err := entry.GetDataType(); err == nil {
+		t.Error("GetDataType() should return error without parent")
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantType byte
+		wantErr  bool
+	}{
+		{"edzヘッダ", []byte("edz\x4dpayload"), 0x4d, false},
+		{"不正なマジック", []byte("abc\x4dpayload"), 0, true},
+		{"データ不足", []byte("ed"), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := lzssLiteral(tt.data)
+			tmpFile := filepath.Join(t.TempDir(), "th08.dat")
+			if err := os.WriteFile(tmpFile, append(make([]byte, 16), comp...), 0644); err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			f, err := os.Open(tmpFile)
+			if err != nil {
+				t.Fatalf("Failed to open temp file: %v", err)
+			}
+			defer f.Close()
+
+			archive := NewKaguyaArchive()
+			archive.file = f
+			entry := &KaguyaEntry{Offset: 16, CompSize: uint32(len(comp)), parent: archive}
+
+			got, err := entry.GetDataType()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("GetDataType() should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDataType() error = %v", err)
+			}
+			if got != tt.wantType {
+				t.Errorf("GetDataType() = 0x%x, want 0x%x", got, tt.wantType)
+			}
+		})
+	}
+}
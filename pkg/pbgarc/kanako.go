@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/shiroemons/go-brightmoon/pkg/crypto"
 )
@@ -56,6 +57,14 @@ func (e *KanakoEntry) GetCompressedSize() uint32 {
 	return e.CompSize
 }
 
+// GetCryptParamIndex はエントリの復号に使用する暗号化パラメータのインデックス (0-7) を取得します
+func (e *KanakoEntry) GetCryptParamIndex() int {
+	if e.parent == nil {
+		return -1
+	}
+	return e.parent.getCryptParamIndex(e.Name)
+}
+
 // Extract はエントリを抽出します
 func (e *KanakoEntry) Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
 	if e.parent == nil {
@@ -73,12 +82,35 @@ type KanakoCryptParam struct {
 	Limit int
 }
 
+// kanakoLayout は Kanako アーカイブのヘッダとファイルリストの形式 (マジックナンバー・補正値・暗号化パラメータ)
+type kanakoLayout struct {
+	magic              uint32
+	listSizeOffset     uint32
+	listCompSizeOffset uint32
+	fileCountOffset    uint32
+	header             KanakoCryptParam
+	list               KanakoCryptParam // Limit が 0 の場合はリスト全体を復号
+}
+
+// defaultKanakoLayout は全作品共通のヘッダとファイルリストの形式
+var defaultKanakoLayout = kanakoLayout{
+	magic:              KanakoMagic,
+	listSizeOffset:     kanakoListSizeOffset,
+	listCompSizeOffset: kanakoListCompSizeOffset,
+	fileCountOffset:    kanakoFileCountOffset,
+	header:             KanakoCryptParam{kanakoHeaderKey, kanakoHeaderStep, kanakoHeaderBlock, kanakoHeaderLimit},
+	list:               KanakoCryptParam{kanakoListKey, kanakoListStep, kanakoListBlock, 0},
+}
+
 // KanakoArchive はKanakoアーカイブを表します
 type KanakoArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []KanakoEntry
 	curIndex int
 	cryprm   []KanakoCryptParam
+	layout   kanakoLayout
 	archType int
 }
 
@@ -119,12 +151,14 @@ var kanakoCryprm3 = []KanakoCryptParam{
 }
 
 // NewKanakoArchive は新しいKanakoArchiveを作成します
-func NewKanakoArchive() *KanakoArchive {
+func NewKanakoArchive(opts ...Option) *KanakoArchive {
 	return &KanakoArchive{
-		entries:  make([]KanakoEntry, 0),
-		curIndex: -1,
-		cryprm:   kanakoCryprm1, // デフォルトは風神録
-		archType: 0,
+		archiveLogger: newArchiveLogger("Kanako", opts),
+		entries:       make([]KanakoEntry, 0),
+		curIndex:      -1,
+		cryprm:        kanakoCryprm1, // デフォルトは風神録
+		layout:        defaultKanakoLayout,
+		archType:      0,
 	}
 }
 
@@ -155,18 +189,12 @@ func GetArchiveTypeOptions() []string {
 }
 
 // SetArchiveType はアーカイブタイプを設定します
+// ARCHTYPE_TD より大きい値は RegisterCryptDefinition で登録した定義を表します
 func (a *KanakoArchive) SetArchiveType(archType int) {
 	a.archType = archType
-	switch archType {
-	case ARCHTYPE_MOF:
-		a.cryprm = kanakoCryprm1
-	case ARCHTYPE_SA_OR_UFO:
-		a.cryprm = kanakoCryprm2
-	case ARCHTYPE_TD:
-		a.cryprm = kanakoCryprm3
-	default:
-		a.cryprm = kanakoCryprm1
-	}
+	table := lookupKanakoTable(archType)
+	a.cryprm = table.cryprm
+	a.layout = table.layout
 }
 
 // GetArchiveType は現在のアーカイブタイプを取得します
@@ -175,7 +203,9 @@ func (a *KanakoArchive) GetArchiveType() int {
 }
 
 // Open はアーカイブファイルを開きます
-func (a *KanakoArchive) Open(filename string) (bool, error) {
+func (a *KanakoArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err, LogKeySubType, a.archType) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -204,8 +234,9 @@ func (a *KanakoArchive) Open(filename string) (bool, error) {
 	}
 
 	// ヘッダー暗号化解除（固定キー）
+	header := a.layout.header
 	headerReader := io.LimitReader(file, kanakoHeaderSize)
-	if !crypto.THCrypter(headerReader, headerBuf, kanakoHeaderSize, kanakoHeaderKey, kanakoHeaderStep, kanakoHeaderBlock, kanakoHeaderLimit) {
+	if !crypto.THCrypter(headerReader, headerBuf, kanakoHeaderSize, header.Key, header.Step, header.Block, header.Limit) {
 		return false, errors.New("header decryption failed")
 	}
 
@@ -215,7 +246,7 @@ func (a *KanakoArchive) Open(filename string) (bool, error) {
 		return false, err
 	}
 
-	if magic != KanakoMagic {
+	if magic != a.layout.magic {
 		return false, errors.New("invalid magic number")
 	}
 
@@ -232,9 +263,9 @@ func (a *KanakoArchive) Open(filename string) (bool, error) {
 	}
 
 	// 暗号化された値を復元
-	listSize -= kanakoListSizeOffset
-	listCompSize -= kanakoListCompSizeOffset
-	fileCount -= kanakoFileCountOffset
+	listSize -= a.layout.listSizeOffset
+	listCompSize -= a.layout.listCompSizeOffset
+	fileCount -= a.layout.fileCountOffset
 
 	// リストのサイズチェック
 	if listCompSize > uint32(fileSize) {
@@ -250,8 +281,13 @@ func (a *KanakoArchive) Open(filename string) (bool, error) {
 	}
 
 	compBuf := &bytes.Buffer{}
+	list := a.layout.list
+	listLimit := list.Limit
+	if listLimit == 0 {
+		listLimit = int(listCompSize)
+	}
 	listReader := io.LimitReader(file, int64(listCompSize))
-	if !crypto.THCrypter(listReader, compBuf, int(listCompSize), kanakoListKey, kanakoListStep, kanakoListBlock, int(listCompSize)) {
+	if !crypto.THCrypter(listReader, compBuf, int(listCompSize), list.Key, list.Step, list.Block, listLimit) {
 		return false, errors.New("list decryption failed")
 	}
 
@@ -381,7 +417,12 @@ func (a *KanakoArchive) Extract(w io.Writer, callback func(string, interface{})
 }
 
 // ExtractEntry は指定されたエントリを抽出します
-func (a *KanakoArchive) ExtractEntry(entry *KanakoEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *KanakoArchive) ExtractEntry(entry *KanakoEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -391,14 +432,9 @@ func (a *KanakoArchive) ExtractEntry(entry *KanakoEntry, w io.Writer, callback f
 		}
 	}
 
-	// ファイルポインタを移動
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		return false
-	}
-
-	// 圧縮データを読み込み
+	// 圧縮データを読み込み (ReadAt はファイル位置を共有しないため、複数のエントリを並行して抽出できる)
 	compressedData := make([]byte, entry.CompSize)
-	if _, err := io.ReadFull(a.file, compressedData); err != nil {
+	if _, err := a.file.ReadAt(compressedData, int64(entry.Offset)); err != nil {
 		if callback != nil {
 			callback("データ読込エラー!\r\n", user)
 		}
@@ -0,0 +1,708 @@
+package pbgarc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shiroemons/go-brightmoon/pkg/crypto"
+)
+
+// Kanako 形式の暗号化パラメータの探索
+//
+// 新作の Kanako (THA1) アーカイブは、エントリの暗号化パラメータ (KanakoCryptParam) の表が
+// 既存の作品と異なる場合があります。DiscoverKanakoCryptParams はエントリ名の合計値で決まる
+// 8つのスロットごとに key/step/block/limit を総当たりで探索し、復号・展開した内容が
+// 既知のファイル形式 (ANM/ECL のヘッダ・RIFF・PNG・Shift-JIS テキスト) として認識できるものを採用します。
+
+// 探索の設定値
+const (
+	kanakoDiscoverySamples    = 4       // key/step/block の探索に使うスロットあたりのエントリ数
+	kanakoDiscoveryHeadSize   = 96      // 内容の判定に使う先頭のバイト数 (展開後)
+	kanakoDiscoveryCandidates = 4       // limit の探索に進む key/step/block の候補数
+	kanakoDiscoveryMinScore   = 60      // パラメータとして採用する最低の得点
+	kanakoDiscoveryLimitStep  = 0x100   // limit の探索間隔
+	kanakoDefaultMaxLimit     = 0x10000 // limit の探索範囲の上限
+	kanakoSlotCount           = 8       // 暗号化パラメータのスロット数 (名前の合計値 & 7)
+)
+
+// kanakoDiscoveryBlocks は探索するブロックサイズの既定値
+var kanakoDiscoveryBlocks = []int{0x40, 0x80, 0x100, 0x200, 0x400, 0x800, 0x1000}
+
+// errKanakoOverrun は展開後のサイズが元のサイズを超えたことを表します
+var errKanakoOverrun = errors.New("decompressed data exceeds original size")
+
+// KanakoDiscoveryOptions は暗号化パラメータの探索の設定
+type KanakoDiscoveryOptions struct {
+	Blocks   []int // 探索するブロックサイズ (nil の場合は 0x40～0x1000 の2の累乗)
+	MaxLimit int   // 探索する limit の上限 (0 の場合は 0x10000)
+	Workers  int   // 並列に探索するスロット数 (0 以下の場合は 1)
+
+	Logger *slog.Logger // スロットごとの探索結果のログの出力先 (nil の場合は出力しない)
+}
+
+// KanakoSlotDiscovery はスロット (エントリ名の合計値 & 7) ごとの探索結果
+type KanakoSlotDiscovery struct {
+	Index          int              // スロット番号 (0-7)
+	Found          bool             // key/step/block が見つかったか
+	Param          KanakoCryptParam // 推定したパラメータ (Limit が 0 の場合は limit を特定できなかった)
+	LimitConfirmed bool             // limit が確定したか (false の場合は Param.Limit 以上のいずれかの値)
+	Score          int              // 内容の判定の得点
+	Contents       []string         // 認識した内容の種類 (PNG, RIFF など)
+	Entries        int              // スロットに属するエントリ数
+	Compressed     int              // スロットに属する圧縮されたエントリ数
+	Verified       int              // 推定したパラメータで元のサイズどおりに展開できた圧縮エントリ数
+}
+
+// KanakoDiscovery は暗号化パラメータの探索結果
+type KanakoDiscovery struct {
+	Slots       [kanakoSlotCount]KanakoSlotDiscovery
+	MatchedType int // 既知 (組み込みまたは登録済み) のパラメータ表と一致した場合のアーカイブタイプ (一致しない場合は -1)
+}
+
+// Params は探索結果をパラメータ表として返します (見つからなかったスロットはゼロ値)
+func (d *KanakoDiscovery) Params() []KanakoCryptParam {
+	params := make([]KanakoCryptParam, kanakoSlotCount)
+	for i, slot := range d.Slots {
+		if slot.Found {
+			params[i] = slot.Param
+		}
+	}
+	return params
+}
+
+// Complete は全スロットのパラメータ (limit を含む) が確定したかを返します
+func (d *KanakoDiscovery) Complete() bool {
+	for _, slot := range d.Slots {
+		if !slot.Found || !slot.LimitConfirmed {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesKnownTable は探索結果が既知のパラメータ表と矛盾しないかを返します
+// limit が確定していないスロットは、既知の limit が推定値以上であれば一致とみなします
+func (d *KanakoDiscovery) matchesKnownTable(table []KanakoCryptParam) bool {
+	matched := false
+	for i, slot := range d.Slots {
+		if !slot.Found {
+			continue
+		}
+		known := table[i]
+		if known.Key != slot.Param.Key || known.Step != slot.Param.Step || known.Block != slot.Param.Block {
+			return false
+		}
+		if slot.LimitConfirmed && known.Limit != slot.Param.Limit {
+			return false
+		}
+		if !slot.LimitConfirmed && known.Limit < slot.Param.Limit {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// kanakoSample は key/step/block の探索に使うエントリ
+type kanakoSample struct {
+	entry      *KanakoEntry
+	head       []byte // 暗号化されたデータの先頭
+	compressed bool
+}
+
+// kanakoCandidate は key/step/block の候補
+type kanakoCandidate struct {
+	key, step byte
+	block     int
+	score     int
+	contents  []string
+}
+
+// DiscoverKanakoCryptParams は Kanako アーカイブのエントリの暗号化パラメータを総当たりで探索します
+// ヘッダとファイルリストの暗号化は全作品共通のため、パラメータ表が未知の新作でもエントリの一覧は読み込めます
+func DiscoverKanakoCryptParams(ctx context.Context, filename string, opts *KanakoDiscoveryOptions) (*KanakoDiscovery, error) {
+	if opts == nil {
+		opts = &KanakoDiscoveryOptions{}
+	}
+	blocks := opts.Blocks
+	if len(blocks) == 0 {
+		blocks = kanakoDiscoveryBlocks
+	}
+	maxLimit := opts.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = kanakoDefaultMaxLimit
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = discardLogger
+	}
+
+	archive := NewKanakoArchive(WithLogger(logger))
+	if _, err := archive.Open(filename); err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	// エントリをスロットごとに分類
+	var slotEntries [kanakoSlotCount][]*KanakoEntry
+	for i := range archive.entries {
+		entry := &archive.entries[i]
+		idx := archive.getCryptParamIndex(entry.Name)
+		slotEntries[idx] = append(slotEntries[idx], entry)
+	}
+
+	result := &KanakoDiscovery{MatchedType: -1}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range slots {
+				start := time.Now()
+				slot, err := archive.discoverSlot(ctx, idx, slotEntries[idx], blocks, maxLimit)
+				logger.Debug("crypt slot searched", LogKeyArchive, filename, LogKeySlot, idx,
+					LogKeyEntries, slot.Entries, "found", slot.Found, "score", slot.Score, LogKeyDuration, time.Since(start))
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				result.Slots[idx] = slot
+				mu.Unlock()
+			}
+		}()
+	}
+	for idx := 0; idx < kanakoSlotCount; idx++ {
+		slots <- idx
+	}
+	close(slots)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	cryptTablesMu.RLock()
+	tables := append([]kanakoTable(nil), kanakoTables...)
+	cryptTablesMu.RUnlock()
+	for archType, table := range tables {
+		if result.matchesKnownTable(table.cryprm) {
+			result.MatchedType = archType
+			break
+		}
+	}
+	return result, nil
+}
+
+// discoverSlot は1つのスロットの暗号化パラメータを探索します
+func (a *KanakoArchive) discoverSlot(ctx context.Context, idx int, entries []*KanakoEntry, blocks []int, maxLimit int) (KanakoSlotDiscovery, error) {
+	slot := KanakoSlotDiscovery{Index: idx, Entries: len(entries)}
+	for _, e := range entries {
+		if e.CompSize != e.OrigSize {
+			slot.Compressed++
+		}
+	}
+
+	samples, err := a.discoverySamples(entries, blocks)
+	if err != nil || len(samples) == 0 {
+		return slot, err
+	}
+
+	candidates, err := searchKanakoKeys(ctx, samples, blocks)
+	if err != nil || len(candidates) == 0 {
+		return slot, err
+	}
+
+	// 得点の高い候補から順に limit を探索し、圧縮エントリを展開できたものを採用する
+	for i, c := range candidates {
+		limit, confirmed, ok, err := a.searchKanakoLimit(ctx, entries, c, maxLimit)
+		if err != nil {
+			return slot, err
+		}
+		if !ok && i < len(candidates)-1 {
+			continue
+		}
+		if !ok {
+			// どの候補も limit を特定できなかった場合は最も得点の高い候補の key/step/block を報告する
+			c = candidates[0]
+			limit, confirmed = 0, false
+		}
+		slot.Found = true
+		slot.Param = KanakoCryptParam{Key: c.key, Step: c.step, Block: c.block, Limit: limit}
+		slot.LimitConfirmed = confirmed
+		slot.Score = c.score
+		slot.Contents = c.contents
+		break
+	}
+
+	if slot.Param.Limit > 0 {
+		for _, e := range entries {
+			if e.CompSize == e.OrigSize {
+				continue
+			}
+			ok, err := a.kanakoEntryDecodes(e, slot.Param)
+			if err != nil {
+				return slot, err
+			}
+			if ok {
+				slot.Verified++
+			}
+		}
+	}
+	return slot, nil
+}
+
+// discoverySamples はスロットのエントリから key/step/block の探索に使うエントリを選びます
+// 内容を判定しやすい拡張子のエントリと、最初のブロックが欠けない大きなエントリを優先します
+func (a *KanakoArchive) discoverySamples(entries []*KanakoEntry, blocks []int) ([]kanakoSample, error) {
+	maxBlock := 0
+	for _, b := range blocks {
+		if b > maxBlock {
+			maxBlock = b
+		}
+	}
+	headSize := maxBlock
+	if headSize < 2*kanakoDiscoveryHeadSize {
+		headSize = 2 * kanakoDiscoveryHeadSize
+	}
+
+	sorted := make([]*KanakoEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.CompSize >= 16 {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := expectedKanakoContent(sorted[i].Name) != "", expectedKanakoContent(sorted[j].Name) != ""
+		if ki != kj {
+			return ki
+		}
+		return sorted[i].CompSize > sorted[j].CompSize
+	})
+	if len(sorted) > kanakoDiscoverySamples {
+		sorted = sorted[:kanakoDiscoverySamples]
+	}
+
+	samples := make([]kanakoSample, 0, len(sorted))
+	for _, e := range sorted {
+		n := int(e.CompSize)
+		if n > headSize {
+			n = headSize
+		}
+		head := make([]byte, n)
+		if _, err := a.file.ReadAt(head, int64(e.Offset)); err != nil {
+			return nil, err
+		}
+		samples = append(samples, kanakoSample{entry: e, head: head, compressed: e.CompSize != e.OrigSize})
+	}
+	return samples, nil
+}
+
+// searchKanakoKeys は key/step/block の全組み合わせを試し、得点の高い候補を返します
+// limit は block 以上であることを前提に、最初のブロックを復号した内容だけで判定します
+func searchKanakoKeys(ctx context.Context, samples []kanakoSample, blocks []int) ([]kanakoCandidate, error) {
+	var best []kanakoCandidate
+	quick := make([]byte, 8)
+	head := make([]byte, 2*kanakoDiscoveryHeadSize)
+	out := make([]byte, 0, kanakoDiscoveryHeadSize)
+
+	for _, block := range blocks {
+		for key := 0; key < 256; key++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for step := 0; step < 256; step++ {
+				// 先頭の数バイトだけで明らかに無関係な候補を除外する
+				plausible := false
+				for i := range samples {
+					if samples[i].plausible(quick, byte(key), byte(step), block, &out) {
+						plausible = true
+						break
+					}
+				}
+				if !plausible {
+					continue
+				}
+
+				c := kanakoCandidate{key: byte(key), step: byte(step), block: block}
+				for i := range samples {
+					data := samples[i].decode(head, byte(key), byte(step), block, kanakoDiscoveryHeadSize, &out)
+					score, content := scoreKanakoContent(samples[i].entry.Name, data)
+					c.score += score
+					if content != "" {
+						c.contents = append(c.contents, content)
+					}
+				}
+				if c.score < kanakoDiscoveryMinScore {
+					continue
+				}
+				best = insertKanakoCandidate(best, c)
+			}
+		}
+	}
+	return best, nil
+}
+
+// insertKanakoCandidate は得点の順に候補を追加し、上位の候補だけを残します
+func insertKanakoCandidate(best []kanakoCandidate, c kanakoCandidate) []kanakoCandidate {
+	pos := sort.Search(len(best), func(i int) bool { return best[i].score < c.score })
+	if pos >= kanakoDiscoveryCandidates {
+		return best
+	}
+	best = append(best, kanakoCandidate{})
+	copy(best[pos+1:], best[pos:])
+	best[pos] = c
+	if len(best) > kanakoDiscoveryCandidates {
+		best = best[:kanakoDiscoveryCandidates]
+	}
+	return best
+}
+
+// plausible は復号した先頭の数バイトが既知の内容の先頭として成り立つかを返します
+func (s *kanakoSample) plausible(buf []byte, key, step byte, block int, out *[]byte) bool {
+	data := s.decode(buf, key, step, block, 4, out)
+	if len(data) < 4 {
+		return false
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG")), bytes.HasPrefix(data, []byte("RIFF")),
+		bytes.HasPrefix(data, []byte("SCPT")), bytes.HasPrefix(data, []byte("THTX")):
+		return true
+	case data[0] >= 2 && data[0] <= 8 && data[1] == 0 && data[2] == 0 && data[3] == 0:
+		// ANM のバージョン番号
+		return true
+	}
+	return shiftJISTextRatio(data) == 1
+}
+
+// decode はサンプルの先頭を復号 (圧縮されている場合は展開) し、最大 n バイトを返します
+// buf は復号したデータの格納先で、その長さだけ復号します
+func (s *kanakoSample) decode(buf []byte, key, step byte, block, n int, out *[]byte) []byte {
+	size := int(s.entry.CompSize)
+	if !s.compressed && n < len(buf) {
+		buf = buf[:n]
+	} else if s.compressed && 9*n/8+2 < len(buf) {
+		// LZSS は1バイトあたり最大9ビットなので、n バイトの展開には 9n/8 バイトあれば足りる
+		buf = buf[:9*n/8+2]
+	}
+	if len(buf) > len(s.head) {
+		buf = buf[:len(s.head)]
+	}
+	mainSize := thcrypterMainSize(size, block)
+	for p := range buf {
+		b, ok := thcrypterByte(s.head, mainSize, key, step, block, block, p)
+		if !ok {
+			buf = buf[:p]
+			break
+		}
+		buf[p] = b
+	}
+	if !s.compressed {
+		return buf
+	}
+	*out = unlzssHead(buf, (*out)[:0], n)
+	return *out
+}
+
+// searchKanakoLimit は key/step/block を固定して limit を探索します
+// limit より後ろは暗号化されないため、limit より大きいエントリを正しく展開できる値は1つに定まります
+// 探索範囲の全エントリが limit 以下の場合は、展開できる最小の値を確定していない limit として返します
+func (a *KanakoArchive) searchKanakoLimit(ctx context.Context, entries []*KanakoEntry, c kanakoCandidate, maxLimit int) (limit int, confirmed, ok bool, err error) {
+	var alive []int
+	for l := kanakoDiscoveryLimitStep; l <= maxLimit; l += kanakoDiscoveryLimitStep {
+		if l >= c.block {
+			alive = append(alive, l)
+		}
+	}
+
+	// 小さいエントリから順に検証し、展開できない limit を除外していく
+	compressed := make([]*KanakoEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.CompSize != e.OrigSize {
+			compressed = append(compressed, e)
+		}
+	}
+	sort.Slice(compressed, func(i, j int) bool { return compressed[i].CompSize < compressed[j].CompSize })
+	if len(compressed) == 0 {
+		return 0, false, false, nil
+	}
+
+	for _, e := range compressed {
+		if err := ctx.Err(); err != nil {
+			return 0, false, false, err
+		}
+		mainSize := thcrypterMainSize(int(e.CompSize), c.block)
+
+		// mainSize 以上の limit はこのエントリに対してはすべて同じ結果になる
+		next := alive[:0]
+		var beyond, beyondTested bool
+		for _, l := range alive {
+			param := KanakoCryptParam{Key: c.key, Step: c.step, Block: c.block, Limit: l}
+			if l >= mainSize {
+				if !beyondTested {
+					beyond, err = a.kanakoEntryDecodes(e, param)
+					if err != nil {
+						return 0, false, false, err
+					}
+					beyondTested = true
+				}
+				if beyond {
+					next = append(next, l)
+				}
+				continue
+			}
+			ok, err := a.kanakoEntryDecodes(e, param)
+			if err != nil {
+				return 0, false, false, err
+			}
+			if ok {
+				next = append(next, l)
+			}
+		}
+		alive = next
+		if len(alive) == 0 {
+			return 0, false, false, nil
+		}
+		if len(alive) == 1 && alive[0] < mainSize {
+			return alive[0], true, true, nil
+		}
+	}
+	return alive[0], false, true, nil
+}
+
+// kanakoEntryDecodes はエントリをパラメータで復号・展開し、元のサイズどおりに展開できるかを返します
+func (a *KanakoArchive) kanakoEntryDecodes(e *KanakoEntry, param KanakoCryptParam) (bool, error) {
+	data := make([]byte, e.CompSize)
+	if _, err := a.file.ReadAt(data, int64(e.Offset)); err != nil {
+		return false, err
+	}
+	decrypted := bytes.NewBuffer(make([]byte, 0, len(data)))
+	if !crypto.THCrypter(bytes.NewReader(data), decrypted, len(data), param.Key, param.Step, param.Block, param.Limit) {
+		return false, nil
+	}
+	w := &kanakoSizeWriter{max: int(e.OrigSize)}
+	if err := crypto.UNLZSS(decrypted, w); err != nil {
+		return false, nil
+	}
+	return w.n == int(e.OrigSize), nil
+}
+
+// kanakoSizeWriter は書き込まれたバイト数を数え、上限を超えた時点でエラーを返します
+type kanakoSizeWriter struct {
+	n, max int
+}
+
+func (w *kanakoSizeWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	if w.n > w.max {
+		return 0, errKanakoOverrun
+	}
+	return len(p), nil
+}
+
+// thcrypterMainSize は THCrypter が復号の対象とする (末尾の端数を除いた) サイズを返します
+func thcrypterMainSize(size, block int) int {
+	addup := size % block
+	if addup >= block/4 {
+		addup = 0
+	}
+	addup += size % 2
+	return size - addup
+}
+
+// thcrypterByte は crypto.THCrypter で復号したデータの p バイト目を返します
+// src は p を含むブロック全体を含む必要があり、足りない場合は ok=false を返します
+func thcrypterByte(src []byte, mainSize int, key, step byte, block, limit, p int) (byte, bool) {
+	end := mainSize
+	if limit < end {
+		end = limit
+	}
+	if p >= end {
+		// limit を超えた部分と端数は暗号化されていない
+		if p >= len(src) {
+			return 0, false
+		}
+		return src[p], true
+	}
+
+	// ブロック内では入力を前半・後半に分け、出力の末尾から1つおきに書き込む
+	start := p / block * block
+	s := block
+	if end-start < s {
+		s = end - start
+	}
+	q := p - start
+	var pin int
+	if (s-1-q)%2 == 0 {
+		pin = (s - 1 - q) / 2
+	} else {
+		pin = (s+1)/2 + (s-2-q)/2
+	}
+	if start+s > len(src) {
+		return 0, false
+	}
+	// キーは入力1バイトごとに step ずつ増える
+	return src[start+pin] ^ (key + step*byte(start+pin)), true
+}
+
+// unlzssHead は crypto.UNLZSS と同じ形式の LZSS 圧縮データの先頭を最大 n バイト展開し、out に追加します
+// 入力が途中で終わった場合はそこまでの展開結果を返します
+func unlzssHead(src, out []byte, n int) []byte {
+	bit := 0
+	read := func(bits int) (int, bool) {
+		if bit+bits > len(src)*8 {
+			return 0, false
+		}
+		v := 0
+		for i := 0; i < bits; i++ {
+			v = v<<1 | int(src[bit>>3]>>(7-uint(bit&7)))&1
+			bit++
+		}
+		return v, true
+	}
+
+	// 辞書は位置 1 から書き込まれるため、先頭 n バイトの展開中は dict[i] == out[i-1] (未書き込みは 0)
+	for len(out) < n {
+		flag, ok := read(1)
+		if !ok {
+			return out
+		}
+		if flag == 1 {
+			c, ok := read(8)
+			if !ok {
+				return out
+			}
+			out = append(out, byte(c))
+			continue
+		}
+		patOfs, ok := read(13)
+		if !ok || patOfs == 0 {
+			return out
+		}
+		patLen, ok := read(4)
+		if !ok {
+			return out
+		}
+		for i := 0; i < patLen+3 && len(out) < n; i++ {
+			idx := (patOfs + i) % crypto.DictSize
+			var c byte
+			if idx >= 1 && idx <= len(out) {
+				c = out[idx-1]
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// expectedKanakoContent はエントリ名の拡張子から想定される内容の種類を返します
+func expectedKanakoContent(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png":
+		return "PNG"
+	case ".wav":
+		return "RIFF"
+	case ".anm":
+		return "ANM"
+	case ".ecl":
+		return "ECL"
+	case ".txt":
+		return "Shift-JIS"
+	}
+	return ""
+}
+
+// scoreKanakoContent は復号・展開したデータの先頭が既知の形式として認識できるかを得点で返します
+func scoreKanakoContent(name string, data []byte) (int, string) {
+	score, content := 0, ""
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		score, content = 100, "PNG"
+	case bytes.HasPrefix(data, []byte("RIFF")) && len(data) >= 12 && string(data[8:12]) == "WAVE":
+		score, content = 100, "RIFF"
+	case bytes.HasPrefix(data, []byte("RIFF")):
+		score, content = 60, "RIFF"
+	case bytes.HasPrefix(data, []byte("SCPT")):
+		score, content = 100, "ECL"
+	case isANMHeader(data):
+		score, content = 90, "ANM"
+	case bytes.Contains(data, []byte("THTX")):
+		score, content = 80, "ANM"
+	case len(data) >= 16 && shiftJISTextRatio(data) == 1:
+		score, content = 70, "Shift-JIS"
+	case len(data) >= 16 && shiftJISTextRatio(data) >= 0.9:
+		score, content = 30, "Shift-JIS"
+	}
+	if content != "" && content == expectedKanakoContent(name) {
+		score += 20
+	}
+	return score, content
+}
+
+// isANMHeader はデータが ANM (バージョン 2～8) のヘッダとして成り立つかを返します
+// ヘッダの構造: version(u32) sprites(u16) scripts(u16) rt_textureslot(u16) w(u16) h(u16) format(u16) ... 末尾 24 バイトは 0
+func isANMHeader(data []byte) bool {
+	if len(data) < 0x40 {
+		return false
+	}
+	version := binary.LittleEndian.Uint32(data[0:])
+	if version < 2 || version > 8 {
+		return false
+	}
+	switch binary.LittleEndian.Uint16(data[0x0c:]) {
+	case 1, 3, 5, 7: // BGRA8888, RGB565, ARGB4444, GRAY8
+	default:
+		return false
+	}
+	for _, b := range data[0x28:0x40] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// shiftJISTextRatio はデータのうち Shift-JIS のテキストとして有効なバイトの割合を返します
+// 末尾で途切れた2バイト文字の1バイト目は有効とみなします
+func shiftJISTextRatio(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	valid := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\t' || c == '\r' || c == '\n' || (c >= 0x20 && c < 0x7f):
+			valid++
+		case c >= 0xa1 && c <= 0xdf: // 半角カナ
+			valid++
+		case (c >= 0x81 && c <= 0x9f) || (c >= 0xe0 && c <= 0xfc):
+			if i+1 == len(data) {
+				valid++
+				continue
+			}
+			t := data[i+1]
+			if (t >= 0x40 && t <= 0x7e) || (t >= 0x80 && t <= 0xfc) {
+				valid += 2
+				i++
+			}
+		}
+	}
+	return float64(valid) / float64(len(data))
+}
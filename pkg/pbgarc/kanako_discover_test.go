@@ -0,0 +1,248 @@
+package pbgarc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shiroemons/go-brightmoon/pkg/crypto"
+)
+
+// encryptTHCrypter は crypto.THCrypter で復号できるようにデータを暗号化します (テスト用)
+func encryptTHCrypter(plain []byte, key, step byte, block, limit int) []byte {
+	enc := append([]byte(nil), plain...)
+	end := thcrypterMainSize(len(plain), block)
+	if limit < end {
+		end = limit
+	}
+	for start := 0; start < end; start += block {
+		s := block
+		if end-start < s {
+			s = end - start
+		}
+		pin := 0
+		for j := 0; j < 2; j++ {
+			pout := s - j - 1
+			for i := 0; i < (s-j+1)/2; i++ {
+				enc[start+pin] = plain[start+pout] ^ (key + step*byte(start+pin))
+				pin++
+				pout -= 2
+			}
+		}
+	}
+	return enc
+}
+
+// kanakoNameForSlot は名前の合計値 & 7 が slot になるエントリ名を作成します
+func kanakoNameForSlot(base, ext string, slot int) string {
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("%s%d%s", base, i, ext)
+		sum := byte(0)
+		for j := 0; j < len(name); j++ {
+			sum += name[j]
+		}
+		if int(sum&7) == slot {
+			return name
+		}
+	}
+}
+
+// buildKanakoArchive はテスト用の Kanako (THA1) アーカイブを構築します
+// エントリは全てリテラルの LZSS で圧縮し、cryprm のパラメータで暗号化します
+func buildKanakoArchive(t *testing.T, cryprm []KanakoCryptParam, files map[string][]byte, names []string) string {
+	t.Helper()
+	archive := &KanakoArchive{}
+
+	var data, list bytes.Buffer
+	offset := uint32(kanakoHeaderSize)
+	for _, name := range names {
+		comp := lzssLiteral(files[name])
+		p := cryprm[archive.getCryptParamIndex(name)]
+		data.Write(encryptTHCrypter(comp, p.Key, p.Step, p.Block, p.Limit))
+
+		padded := []byte(name)
+		padded = append(padded, make([]byte, 4-len(padded)%4)...)
+		list.Write(padded)
+		binary.Write(&list, binary.LittleEndian, offset)
+		binary.Write(&list, binary.LittleEndian, uint32(len(files[name])))
+		binary.Write(&list, binary.LittleEndian, uint32(0))
+		offset += uint32(len(comp))
+	}
+	listComp := lzssLiteral(list.Bytes())
+
+	header := make([]byte, kanakoHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], KanakoMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(list.Len())+kanakoListSizeOffset)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(listComp))+kanakoListCompSizeOffset)
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(names))+kanakoFileCountOffset)
+
+	var out bytes.Buffer
+	out.Write(encryptTHCrypter(header, kanakoHeaderKey, kanakoHeaderStep, kanakoHeaderBlock, kanakoHeaderLimit))
+	out.Write(data.Bytes())
+	out.Write(encryptTHCrypter(listComp, kanakoListKey, kanakoListStep, kanakoListBlock, len(listComp)))
+
+	path := filepath.Join(t.TempDir(), "th99.dat")
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	return path
+}
+
+func TestEncryptTHCrypter_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 15, 64, 100, 1000, 5000} {
+		plain := make([]byte, size)
+		rng.Read(plain)
+		for _, p := range kanakoCryprm3 {
+			enc := encryptTHCrypter(plain, p.Key, p.Step, p.Block, p.Limit)
+			var out bytes.Buffer
+			if !crypto.THCrypter(bytes.NewReader(enc), &out, size, p.Key, p.Step, p.Block, p.Limit) {
+				t.Fatalf("THCrypter() failed for size %d", size)
+			}
+			if !bytes.Equal(out.Bytes(), plain) {
+				t.Fatalf("round trip mismatch for size %d, param %+v", size, p)
+			}
+		}
+	}
+}
+
+func TestThcrypterByte(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, size := range []int{17, 64, 333, 1024, 3000} {
+		src := make([]byte, size)
+		rng.Read(src)
+		for _, p := range []KanakoCryptParam{{0x1b, 0x73, 0x100, 0x3800}, {0x35, 0x79, 0x400, 0x100}, {0x03, 0x91, 0x80, 0x6400}} {
+			var want bytes.Buffer
+			crypto.THCrypter(bytes.NewReader(src), &want, size, p.Key, p.Step, p.Block, p.Limit)
+			mainSize := thcrypterMainSize(size, p.Block)
+			for i := 0; i < size; i++ {
+				got, ok := thcrypterByte(src, mainSize, p.Key, p.Step, p.Block, p.Limit, i)
+				if !ok || got != want.Bytes()[i] {
+					t.Fatalf("thcrypterByte(size=%d, %+v, %d) = %#x, %v, want %#x", size, p, i, got, ok, want.Bytes()[i])
+				}
+			}
+		}
+	}
+}
+
+func TestUnlzssHead(t *testing.T) {
+	plain := []byte("musiccmt.txt の先頭 0123456789")
+	comp := lzssLiteral(plain)
+	if got := unlzssHead(comp, nil, 10); !bytes.Equal(got, plain[:10]) {
+		t.Errorf("unlzssHead(10) = %q, want %q", got, plain[:10])
+	}
+	if got := unlzssHead(comp, nil, 1000); !bytes.Equal(got, plain) {
+		t.Errorf("unlzssHead(all) = %q, want %q", got, plain)
+	}
+	// 入力が途中で終わった場合はそこまでの結果を返す
+	if got := unlzssHead(comp[:9], nil, 1000); !bytes.Equal(got, plain[:8]) {
+		t.Errorf("unlzssHead(truncated) = %q, want %q", got, plain[:8])
+	}
+}
+
+func TestScoreKanakoContent(t *testing.T) {
+	anm := make([]byte, 0x40)
+	anm[0] = 8
+	anm[0x0c] = 1
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantContent string
+		wantScore   int
+	}{
+		{"title.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "PNG", 120},
+		{"se_ok00.wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "RIFF", 120},
+		{"st01.ecl", []byte("SCPT\x01\x00\x00\x00"), "ECL", 120},
+		{"front.anm", anm, "ANM", 110},
+		{"musiccmt.txt", []byte("@bgm/th99_01\r\n\x81\xf4\x83\x65\x83\x58\x83\x67\x8b\xc8\r\n"), "Shift-JIS", 90},
+		{"data.bin", []byte{0x12, 0xf3, 0x00, 0x9a, 0xff, 0xfe, 0x01, 0x02, 0x80, 0x7f, 0x00, 0x00, 0xfd, 0x11, 0x22, 0x33}, "", 0},
+	}
+	for _, tt := range tests {
+		score, content := scoreKanakoContent(tt.name, tt.data)
+		if content != tt.wantContent || score != tt.wantScore {
+			t.Errorf("scoreKanakoContent(%q) = %d, %q, want %d, %q", tt.name, score, content, tt.wantScore, tt.wantContent)
+		}
+	}
+}
+
+func TestDiscoverKanakoCryptParams(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	random := func(prefix []byte, size int) []byte {
+		data := make([]byte, size)
+		rng.Read(data)
+		copy(data, prefix)
+		return data
+	}
+	anm := make([]byte, 0x40)
+	anm[0] = 8
+	anm[0x0c] = 1
+
+	pngName := kanakoNameForSlot("title", ".png", 0)
+	txtName := kanakoNameForSlot("musiccmt", ".txt", 0)
+	anmName := kanakoNameForSlot("front", ".anm", 2)
+	files := map[string][]byte{
+		pngName: random([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), 0x5000),
+		txtName: bytes.Repeat([]byte("@bgm/th99_01\r\n\x81\xf4\x83\x65\x83\x58\x83\x67\r\n"), 20),
+		anmName: random(anm, 0x5000),
+	}
+	names := []string{pngName, txtName, anmName}
+	path := buildKanakoArchive(t, kanakoCryprm3, files, names)
+
+	opts := &KanakoDiscoveryOptions{Blocks: []int{0x80, 0x100, 0x200, 0x400}, Workers: 4}
+	result, err := DiscoverKanakoCryptParams(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("DiscoverKanakoCryptParams() error = %v", err)
+	}
+
+	for _, idx := range []int{0, 2} {
+		slot := result.Slots[idx]
+		if !slot.Found || !slot.LimitConfirmed || slot.Param != kanakoCryprm3[idx] {
+			t.Errorf("slot %d = %+v (confirmed=%v), want %+v", idx, slot.Param, slot.LimitConfirmed, kanakoCryprm3[idx])
+		}
+		if slot.Verified != slot.Compressed {
+			t.Errorf("slot %d verified %d of %d entries", idx, slot.Verified, slot.Compressed)
+		}
+	}
+	if result.Slots[0].Entries != 2 || result.Slots[2].Entries != 1 {
+		t.Errorf("slot entries = %d, %d, want 2, 1", result.Slots[0].Entries, result.Slots[2].Entries)
+	}
+	if result.Slots[1].Found {
+		t.Errorf("slot 1 without entries found %+v", result.Slots[1].Param)
+	}
+	if result.MatchedType != ARCHTYPE_TD {
+		t.Errorf("MatchedType = %d, want %d", result.MatchedType, ARCHTYPE_TD)
+	}
+	if result.Complete() {
+		t.Error("Complete() = true with empty slots")
+	}
+
+	// 探索したパラメータで実際に展開できる
+	archive := NewKanakoArchive()
+	archive.SetArchiveType(ARCHTYPE_TD)
+	if _, err := archive.Open(path); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer archive.Close()
+	for ok := archive.EnumFirst(); ok; ok = archive.EnumNext() {
+		var buf bytes.Buffer
+		if !archive.GetEntry().Extract(&buf, nil, nil) || !bytes.Equal(buf.Bytes(), files[archive.GetEntryName()]) {
+			t.Errorf("Extract(%s) mismatch", archive.GetEntryName())
+		}
+	}
+}
+
+func TestDiscoverKanakoCryptParams_NotKanako(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.dat")
+	if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	if _, err := DiscoverKanakoCryptParams(context.Background(), path, nil); err == nil {
+		t.Error("DiscoverKanakoCryptParams() should return error for non-Kanako file")
+	}
+}
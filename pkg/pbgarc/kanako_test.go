@@ -96,3 +96,16 @@ func TestKanakoArchive_ArchiveTypeConstants(t *testing.T) {
 		t.Errorf("ARCHTYPE_TD = %d, want 2", ARCHTYPE_TD)
 	}
 }
+
+func TestKanakoEntry_GetCryptParamIndex(t *testing.T) {
+	entry := &KanakoEntry{Name: "ab"}
+	if idx := entry.GetCryptParamIndex(); idx != -1 {
+		t.Errorf("GetCryptParamIndex() without parent = %d, want -1", idx)
+	}
+
+	entry.parent = NewKanakoArchive()
+	// 'a' (0x61) + 'b' (0x62) = 0xc3, 0xc3 & 7 = 3
+	if idx := entry.GetCryptParamIndex(); idx != 3 {
+		t.Errorf("GetCryptParamIndex() = %d, want 3", idx)
+	}
+}
@@ -0,0 +1,595 @@
+package pbgarc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KokoroMagic は Kokoro アーカイブの識別子 'TFPK' (リトルエンディアン)
+const KokoroMagic = 0x4b504654 // "TFPK" in little-endian
+
+// TFPK 形式のバージョン
+const (
+	KOKORO_VERSION_TH135 = 0 // TH13.5 東方心綺楼
+	KOKORO_VERSION_TH145 = 1 // TH14.5 東方深秘録 以降
+)
+
+// TFPK 形式の定数
+const (
+	kokoroDefaultExponent = 65537
+	kokoroKeySize         = 16 // エントリごとのXOR鍵のバイト数
+	kokoroMaxCount        = 0x100000
+)
+
+// ErrKokoroKeyRequired は RSA 公開鍵を設定せずに TFPK アーカイブを開いた場合のエラー
+// 作品ごとの鍵は同梱していないため、開く前に SetKey で指定する必要があります
+var ErrKokoroKeyRequired = errors.New("TFPK header requires an RSA public key; none is built in (see SetKey)")
+
+// KokoroKey は TFPK ヘッダの復号に使う RSA 公開鍵です
+type KokoroKey struct {
+	Modulus  *big.Int
+	Exponent int
+}
+
+// ParseKokoroKey はテキスト形式の RSA 公開鍵を読み込みます。
+// 形式は「16進数のモジュラス [10進数の公開指数]」で、'#' 以降はコメントとして扱います。
+// 公開指数を省略した場合は 65537 を使用します。
+func ParseKokoroKey(r io.Reader) (*KokoroKey, error) {
+	var fields []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields = append(fields, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, errors.New("invalid key format: expected '<modulus hex> [exponent]'")
+	}
+
+	modBytes, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	key := &KokoroKey{
+		Modulus:  new(big.Int).SetBytes(modBytes),
+		Exponent: kokoroDefaultExponent,
+	}
+	if len(fields) == 2 {
+		if key.Exponent, err = strconv.Atoi(fields[1]); err != nil || key.Exponent <= 0 {
+			return nil, fmt.Errorf("invalid exponent: %s", fields[1])
+		}
+	}
+	if key.Modulus.Sign() <= 0 {
+		return nil, errors.New("invalid modulus: must be positive")
+	}
+	return key, nil
+}
+
+// KokoroEntry はKokoro(TFPK)アーカイブ内のエントリを表します
+type KokoroEntry struct {
+	Offset uint32 // データ領域先頭からのオフセット
+	Size   uint32 // データサイズ (非圧縮)
+	Hash   uint64 // ファイル名のハッシュ
+	Key    [kokoroKeySize]byte
+	Name   string // 名前リストで解決されたファイル名 (未解決の場合はハッシュ値から生成)
+	parent *KokoroArchive
+}
+
+// GetEntryName はエントリ名を取得します
+func (e *KokoroEntry) GetEntryName() string {
+	return e.Name
+}
+
+// GetOriginalSize は元のサイズを取得します
+func (e *KokoroEntry) GetOriginalSize() uint32 {
+	return e.Size
+}
+
+// GetCompressedSize は圧縮後のサイズを取得します (TFPK形式は非圧縮)
+func (e *KokoroEntry) GetCompressedSize() uint32 {
+	return e.Size
+}
+
+// Extract はエントリを抽出します
+func (e *KokoroEntry) Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+	if e.parent == nil {
+		return false
+	}
+
+	return e.parent.ExtractEntry(e, w, callback, user)
+}
+
+// KokoroArchive はKokoro(TFPK)アーカイブを表します (東方心綺楼 TH13.5 以降の黄昏フロンティア作品)
+type KokoroArchive struct {
+	archiveLogger
+
+	file       *os.File
+	entries    []KokoroEntry
+	curIndex   int
+	key        *KokoroKey
+	version    byte
+	dataOffset int64
+	dirNames   []string
+	names      []string // ハッシュ解決用のファイル名候補
+}
+
+// NewKokoroArchive は新しいKokoroArchiveを作成します
+func NewKokoroArchive(opts ...Option) *KokoroArchive {
+	return &KokoroArchive{
+		archiveLogger: newArchiveLogger("Kokoro", opts),
+		entries:       make([]KokoroEntry, 0),
+		curIndex:      -1,
+	}
+}
+
+// SetKey はヘッダ復号に使う RSA 公開鍵を設定します (鍵は同梱していないため、Open の前に必ず指定します)
+func (a *KokoroArchive) SetKey(key *KokoroKey) {
+	a.key = key
+}
+
+// GetVersion はヘッダから読み込んだ TFPK のバージョンを取得します
+func (a *KokoroArchive) GetVersion() int {
+	return int(a.version)
+}
+
+// GetDataOffset はデータ領域の開始位置 (エントリのオフセットの基準位置) を取得します
+func (a *KokoroArchive) GetDataOffset() int64 {
+	return a.dataOffset
+}
+
+// GetDirNames はアーカイブに格納されたディレクトリ名の一覧を取得します
+func (a *KokoroArchive) GetDirNames() []string {
+	return a.dirNames
+}
+
+// AddNames はハッシュ解決用のファイル名を追加します。
+// Open 後に呼び出した場合は、未解決のエントリ名を再解決します。
+func (a *KokoroArchive) AddNames(names ...string) {
+	a.names = append(a.names, names...)
+	a.resolveNames()
+}
+
+// LoadNameList はファイル名リスト (1行1ファイル) を読み込みます
+func (a *KokoroArchive) LoadNameList(r io.Reader) error {
+	var names []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		names = append(names, name)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	a.AddNames(names...)
+	return nil
+}
+
+// Close はアーカイブファイルを閉じます
+func (a *KokoroArchive) Close() error {
+	if a.file != nil {
+		err := a.file.Close()
+		a.file = nil
+		return err
+	}
+	return nil
+}
+
+// kokoroHash はバージョンに応じたファイル名のハッシュ値を計算します (FNV-1a)
+func kokoroHash(name string, version byte) uint64 {
+	if version == KOKORO_VERSION_TH135 {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		return uint64(h.Sum32())
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return h.Sum64()
+}
+
+// kokoroNameVariants はハッシュ計算に使う正規化済みのファイル名候補を返します
+func kokoroNameVariants(name string) []string {
+	lower := strings.ToLower(name)
+	slash := strings.ReplaceAll(lower, "\\", "/")
+	backslash := strings.ReplaceAll(lower, "/", "\\")
+	if slash == backslash {
+		return []string{slash}
+	}
+	return []string{slash, backslash}
+}
+
+// resolveNames は名前リストを使ってエントリ名を解決します
+func (a *KokoroArchive) resolveNames() {
+	if len(a.entries) == 0 {
+		return
+	}
+	table := make(map[uint64]string, len(a.names))
+	for _, name := range a.names {
+		for _, v := range kokoroNameVariants(name) {
+			table[kokoroHash(v, a.version)] = strings.ReplaceAll(name, "\\", "/")
+		}
+	}
+	for i := range a.entries {
+		if name, ok := table[a.entries[i].Hash]; ok {
+			a.entries[i].Name = name
+		} else {
+			a.entries[i].Name = fmt.Sprintf("unknown/%016x.bin", a.entries[i].Hash)
+		}
+	}
+}
+
+// readBlock は RSA 暗号化されたブロックを1つ読み込み、パディングを除いたデータを返します
+func (a *KokoroArchive) readBlock(r io.Reader) ([]byte, error) {
+	blockSize := (a.key.Modulus.BitLen() + 7) / 8
+	buf := make([]byte, blockSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+
+	c := new(big.Int).SetBytes(buf)
+	if c.Cmp(a.key.Modulus) >= 0 {
+		return nil, errors.New("RSA block out of range")
+	}
+	m := new(big.Int).Exp(c, big.NewInt(int64(a.key.Exponent)), a.key.Modulus)
+	plain := m.FillBytes(make([]byte, blockSize))
+
+	// PKCS#1 v1.5 パディング (00 01 FF.. 00 / 00 02 xx.. 00) を除去
+	if len(plain) < 11 || plain[0] != 0 || (plain[1] != 1 && plain[1] != 2) {
+		return nil, errors.New("invalid RSA block padding")
+	}
+	sep := bytes.IndexByte(plain[2:], 0)
+	if sep < 8 {
+		return nil, errors.New("invalid RSA block padding")
+	}
+	return plain[2+sep+1:], nil
+}
+
+// readBlockUint32s は RSA ブロックを読み込み、先頭から n 個の uint32 を取り出します
+func (a *KokoroArchive) readBlockUint32s(r io.Reader, n int) ([]uint32, error) {
+	data, err := a.readBlock(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < n*4 {
+		return nil, fmt.Errorf("RSA block too short: %d bytes", len(data))
+	}
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+	return values, nil
+}
+
+// Open はアーカイブファイルを開きます (TFPK形式)
+func (a *KokoroArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	a.file = file
+
+	// エラー時にクリーンアップするためのフラグ
+	success := false
+	defer func() {
+		if !success {
+			a.file.Close()
+			a.file = nil
+		}
+	}()
+
+	// ファイルサイズを取得
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	fileSize := fileInfo.Size()
+
+	// マジックナンバーとバージョンを確認
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(a.file, header); err != nil {
+		return false, fmt.Errorf("failed to read header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(header) != KokoroMagic {
+		return false, errors.New("invalid magic number: not TFPK")
+	}
+	a.version = header[4]
+	if a.version != KOKORO_VERSION_TH135 && a.version != KOKORO_VERSION_TH145 {
+		return false, fmt.Errorf("unsupported TFPK version %d", a.version)
+	}
+	if a.key == nil || a.key.Modulus == nil {
+		return false, ErrKokoroKeyRequired
+	}
+
+	reader := bufio.NewReader(a.file)
+
+	// ディレクトリ情報 (パスのハッシュとファイル数) は読み飛ばす
+	values, err := a.readBlockUint32s(reader, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to read directory count: %w", err)
+	}
+	dirCount := values[0]
+	if dirCount > kokoroMaxCount {
+		return false, fmt.Errorf("invalid directory count %d", dirCount)
+	}
+	for i := uint32(0); i < dirCount; i++ {
+		if _, err := a.readBlockUint32s(reader, 2); err != nil {
+			return false, fmt.Errorf("failed to read directory %d: %w", i, err)
+		}
+	}
+
+	// ディレクトリ名テーブル (zlib圧縮) を読み込み
+	if err := a.readDirNames(reader); err != nil {
+		return false, err
+	}
+
+	// エントリリストを読み込み
+	values, err = a.readBlockUint32s(reader, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file count: %w", err)
+	}
+	fileCount := values[0]
+	if fileCount == 0 || fileCount > kokoroMaxCount {
+		return false, fmt.Errorf("invalid file count %d", fileCount)
+	}
+
+	hashSize := 8
+	if a.version == KOKORO_VERSION_TH135 {
+		hashSize = 4
+	}
+	a.entries = make([]KokoroEntry, 0, fileCount)
+	for i := uint32(0); i < fileCount; i++ {
+		data, err := a.readBlock(reader)
+		if err != nil {
+			return false, fmt.Errorf("failed to read entry %d: %w", i, err)
+		}
+		if len(data) < 8+hashSize+kokoroKeySize {
+			return false, fmt.Errorf("entry %d block too short: %d bytes", i, len(data))
+		}
+
+		entry := KokoroEntry{
+			Size:   binary.LittleEndian.Uint32(data[0:]),
+			Offset: binary.LittleEndian.Uint32(data[4:]),
+			parent: a,
+		}
+		if hashSize == 4 {
+			entry.Hash = uint64(binary.LittleEndian.Uint32(data[8:]))
+		} else {
+			entry.Hash = binary.LittleEndian.Uint64(data[8:])
+		}
+		copy(entry.Key[:], data[8+hashSize:])
+		a.entries = append(a.entries, entry)
+	}
+
+	// データ領域の開始位置 (ヘッダ直後)
+	pos, err := a.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	a.dataOffset = pos - int64(reader.Buffered())
+
+	// オフセットとサイズの検証
+	for i := range a.entries {
+		end := a.dataOffset + int64(a.entries[i].Offset) + int64(a.entries[i].Size)
+		if end > fileSize {
+			return false, fmt.Errorf("invalid entry %d: offset %d + size %d exceeds filesize %d", i, a.entries[i].Offset, a.entries[i].Size, fileSize)
+		}
+	}
+
+	a.resolveNames()
+
+	success = true
+	return true, nil
+}
+
+// readDirNames はディレクトリ名テーブルを読み込みます
+func (a *KokoroArchive) readDirNames(r io.Reader) error {
+	values, err := a.readBlockUint32s(r, 3)
+	if err != nil {
+		return fmt.Errorf("failed to read name table header: %w", err)
+	}
+	compSize, origSize, blockCount := values[0], values[1], values[2]
+	if blockCount > kokoroMaxCount {
+		return fmt.Errorf("invalid name table block count %d", blockCount)
+	}
+
+	var compBuf bytes.Buffer
+	for i := uint32(0); i < blockCount; i++ {
+		data, err := a.readBlock(r)
+		if err != nil {
+			return fmt.Errorf("failed to read name table block %d: %w", i, err)
+		}
+		compBuf.Write(data)
+	}
+	if uint32(compBuf.Len()) < compSize {
+		return fmt.Errorf("name table too short: %d < %d", compBuf.Len(), compSize)
+	}
+	if compSize == 0 {
+		return nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(compBuf.Bytes()[:compSize]))
+	if err != nil {
+		return fmt.Errorf("failed to decompress name table: %w", err)
+	}
+	defer zr.Close()
+	table := make([]byte, origSize)
+	if _, err := io.ReadFull(zr, table); err != nil {
+		return fmt.Errorf("failed to decompress name table: %w", err)
+	}
+
+	a.dirNames = a.dirNames[:0]
+	for _, name := range bytes.Split(table, []byte{0}) {
+		if len(name) > 0 {
+			a.dirNames = append(a.dirNames, string(name))
+		}
+	}
+	return nil
+}
+
+// decryptKokoroData はエントリの鍵でデータを復号します
+// pos はエントリ先頭からの位置で、aux は TH14.5 以降の連鎖用の状態です
+func decryptKokoroData(data []byte, key *[kokoroKeySize]byte, version byte, pos int, aux *[4]byte) {
+	for i := range data {
+		k := key[(pos+i)%kokoroKeySize]
+		if version == KOKORO_VERSION_TH135 {
+			data[i] ^= k
+			continue
+		}
+		c := data[i]
+		data[i] ^= k ^ aux[(pos+i)%4]
+		aux[(pos+i)%4] = c
+	}
+}
+
+// EnumFirst は最初のエントリに移動します
+func (a *KokoroArchive) EnumFirst() bool {
+	if len(a.entries) == 0 {
+		return false
+	}
+	a.curIndex = 0
+	return true
+}
+
+// EnumNext は次のエントリに移動します
+func (a *KokoroArchive) EnumNext() bool {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries)-1 {
+		return false
+	}
+	a.curIndex++
+	return true
+}
+
+// GetEntryName は現在のエントリ名を取得します
+func (a *KokoroArchive) GetEntryName() string {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return ""
+	}
+	return a.entries[a.curIndex].Name
+}
+
+// GetOriginalSize は元のサイズを取得します
+func (a *KokoroArchive) GetOriginalSize() uint32 {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return 0
+	}
+	return a.entries[a.curIndex].Size
+}
+
+// GetCompressedSize は圧縮後のサイズを取得します
+func (a *KokoroArchive) GetCompressedSize() uint32 {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return 0
+	}
+	return a.entries[a.curIndex].Size
+}
+
+// GetEntry は現在のエントリを取得します
+func (a *KokoroArchive) GetEntry() PBGArchiveEntry {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return nil
+	}
+	return &a.entries[a.curIndex]
+}
+
+// Extract は現在のエントリを抽出します
+func (a *KokoroArchive) Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return false
+	}
+	return a.ExtractEntry(&a.entries[a.curIndex], w, callback, user)
+}
+
+// ExtractEntry は指定されたエントリを抽出します
+func (a *KokoroArchive) ExtractEntry(entry *KokoroEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), a.dataOffset+int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
+	if callback != nil {
+		if !callback(entry.GetEntryName(), user) {
+			return false
+		}
+		if !callback(" extracting...", user) {
+			return false
+		}
+	}
+
+	// エントリの範囲だけを読む (ファイル位置を共有しないため、複数のエントリを並行して抽出できる)
+	data := io.NewSectionReader(a.file, a.dataOffset+int64(entry.Offset), int64(entry.Size))
+
+	// バッファサイズ
+	bufSize := uint32(4096)
+	if bufSize > entry.Size {
+		bufSize = entry.Size
+	}
+
+	// データを読み込みながら復号
+	var aux [4]byte
+	copy(aux[:], entry.Key[:4])
+	buffer := make([]byte, bufSize)
+	remaining := entry.Size
+	pos := 0
+	for remaining > 0 {
+		readSize := min(bufSize, remaining)
+
+		if _, err := io.ReadFull(data, buffer[:readSize]); err != nil {
+			return false
+		}
+		decryptKokoroData(buffer[:readSize], &entry.Key, a.version, pos, &aux)
+
+		if _, err := w.Write(buffer[:readSize]); err != nil {
+			return false
+		}
+
+		remaining -= readSize
+		pos += int(readSize)
+	}
+
+	if callback != nil {
+		if !callback("finished.\r\n", user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExtractAll は全てのエントリを抽出します
+func (a *KokoroArchive) ExtractAll(callback func(string, interface{}) bool, user interface{}) bool {
+	if !a.EnumFirst() {
+		return true // Empty archive is success
+	}
+
+	for {
+		if !a.Extract(io.Discard, callback, user) {
+			return false
+		}
+		if !a.EnumNext() {
+			break
+		}
+	}
+
+	return true
+}
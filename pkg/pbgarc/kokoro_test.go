@@ -0,0 +1,239 @@
+package pbgarc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tfpkBuilder はテスト用の TFPK アーカイブを構築します
+type tfpkBuilder struct {
+	t    *testing.T
+	priv *rsa.PrivateKey
+	buf  bytes.Buffer
+}
+
+func (b *tfpkBuilder) block(payload []byte) {
+	b.t.Helper()
+	k := (b.priv.N.BitLen() + 7) / 8
+	if len(payload) > k-11 {
+		b.t.Fatalf("payload too long: %d", len(payload))
+	}
+	plain := make([]byte, k)
+	plain[1] = 1
+	for i := 2; i < k-len(payload)-1; i++ {
+		plain[i] = 0xff
+	}
+	copy(plain[k-len(payload):], payload)
+	c := new(big.Int).Exp(new(big.Int).SetBytes(plain), b.priv.D, b.priv.N)
+	b.buf.Write(c.FillBytes(make([]byte, k)))
+}
+
+func (b *tfpkBuilder) uint32s(values ...uint32) {
+	b.t.Helper()
+	payload := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(payload[i*4:], v)
+	}
+	b.block(payload)
+}
+
+func encryptKokoroData(data []byte, key [kokoroKeySize]byte, version byte) []byte {
+	out := make([]byte, len(data))
+	var aux [4]byte
+	copy(aux[:], key[:4])
+	for i, c := range data {
+		k := key[i%kokoroKeySize]
+		if version == KOKORO_VERSION_TH135 {
+			out[i] = c ^ k
+			continue
+		}
+		out[i] = c ^ k ^ aux[i%4]
+		aux[i%4] = out[i]
+	}
+	return out
+}
+
+func buildTFPKArchive(t *testing.T, version byte, files map[string][]byte, names []string) (string, *KokoroKey) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	b := &tfpkBuilder{t: t, priv: priv}
+	b.buf.WriteString("TFPK")
+	b.buf.WriteByte(version)
+
+	// ディレクトリ情報
+	b.uint32s(1)
+	b.uint32s(0x12345678, uint32(len(names)))
+
+	// ディレクトリ名テーブル
+	var table bytes.Buffer
+	zw := zlib.NewWriter(&table)
+	zw.Write([]byte("data/\x00"))
+	zw.Close()
+	chunks := [][]byte{}
+	for rest := table.Bytes(); len(rest) > 0; {
+		n := min(len(rest), 32)
+		chunks = append(chunks, rest[:n])
+		rest = rest[n:]
+	}
+	b.uint32s(uint32(table.Len()), 6, uint32(len(chunks)))
+	for _, c := range chunks {
+		b.block(c)
+	}
+
+	// エントリリストとデータ
+	b.uint32s(uint32(len(names)))
+	var body bytes.Buffer
+	for i, name := range names {
+		var key [kokoroKeySize]byte
+		for j := range key {
+			key[j] = byte(i*31 + j*7 + 1)
+		}
+		payload := make([]byte, 8, 8+8+kokoroKeySize)
+		binary.LittleEndian.PutUint32(payload[0:], uint32(len(files[name])))
+		binary.LittleEndian.PutUint32(payload[4:], uint32(body.Len()))
+		hash := kokoroHash(strings.ReplaceAll(name, "/", "\\"), version)
+		if version == KOKORO_VERSION_TH135 {
+			payload = binary.LittleEndian.AppendUint32(payload, uint32(hash))
+		} else {
+			payload = binary.LittleEndian.AppendUint64(payload, hash)
+		}
+		payload = append(payload, key[:]...)
+		b.block(payload)
+		body.Write(encryptKokoroData(files[name], key, version))
+	}
+	b.buf.Write(body.Bytes())
+
+	path := filepath.Join(t.TempDir(), "th135.pak")
+	if err := os.WriteFile(path, b.buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	return path, &KokoroKey{Modulus: priv.N, Exponent: priv.E}
+}
+
+func TestKokoroArchive_OpenAndExtract(t *testing.T) {
+	files := map[string][]byte{
+		"data/system/title.nut": []byte("function main() {}"),
+		"data/bgm/bgm.csv":      bytes.Repeat([]byte("0,1,2\n"), 1000),
+	}
+	names := []string{"data/system/title.nut", "data/bgm/bgm.csv"}
+
+	for _, version := range []byte{KOKORO_VERSION_TH135, KOKORO_VERSION_TH145} {
+		t.Run(fmt.Sprintf("version%d", version), func(t *testing.T) {
+			path, key := buildTFPKArchive(t, version, files, names)
+
+			archive := NewKokoroArchive()
+			archive.SetKey(key)
+			archive.AddNames("DATA/SYSTEM/TITLE.NUT")
+			ok, err := archive.Open(path)
+			if !ok || err != nil {
+				t.Fatalf("Open() = %v, %v", ok, err)
+			}
+			defer archive.Close()
+
+			if got := archive.GetVersion(); got != int(version) {
+				t.Errorf("GetVersion() = %d, want %d", got, version)
+			}
+			if dirs := archive.GetDirNames(); len(dirs) != 1 || dirs[0] != "data/" {
+				t.Errorf("GetDirNames() = %v", dirs)
+			}
+
+			// 名前リストにないエントリはハッシュ値の名前になる
+			archive.EnumFirst()
+			if got := archive.GetEntryName(); got != "DATA/SYSTEM/TITLE.NUT" {
+				t.Errorf("GetEntryName() = %q", got)
+			}
+			archive.EnumNext()
+			if got := archive.GetEntryName(); !strings.HasPrefix(got, "unknown/") {
+				t.Errorf("GetEntryName() = %q, want unknown/ prefix", got)
+			}
+
+			// 後から名前リストを読み込むと再解決される
+			if err := archive.LoadNameList(strings.NewReader("# names\ndata/bgm/bgm.csv\n")); err != nil {
+				t.Fatalf("LoadNameList() error = %v", err)
+			}
+			if got := archive.GetEntryName(); got != "data/bgm/bgm.csv" {
+				t.Errorf("GetEntryName() after LoadNameList = %q", got)
+			}
+
+			archive.EnumFirst()
+			for i, name := range names {
+				var out bytes.Buffer
+				if !archive.Extract(&out, nil, nil) {
+					t.Fatalf("Extract(%d) failed", i)
+				}
+				if !bytes.Equal(out.Bytes(), files[name]) {
+					t.Errorf("Extract(%s) returned unexpected data", name)
+				}
+				archive.EnumNext()
+			}
+		})
+	}
+}
+
+func TestKokoroArchive_OpenWithoutKey(t *testing.T) {
+	path, _ := buildTFPKArchive(t, KOKORO_VERSION_TH135, map[string][]byte{"a": []byte("a")}, []string{"a"})
+
+	archive := NewKokoroArchive()
+	if _, err := archive.Open(path); !errors.Is(err, ErrKokoroKeyRequired) {
+		t.Errorf("Open() error = %v, want ErrKokoroKeyRequired", err)
+	}
+}
+
+func TestKokoroArchive_OpenInvalidMagic(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "invalid.pak")
+	if err := os.WriteFile(tmpFile, make([]byte, 32), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	archive := NewKokoroArchive()
+	if _, err := archive.Open(tmpFile); err == nil {
+		t.Error("Open() should return error for invalid magic number")
+	}
+}
+
+func TestParseKokoroKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantExp  int
+		wantMod  int64
+		wantErrs bool
+	}{
+		{"モジュラスのみ", "0x00c5 # comment\n", 65537, 0xc5, false},
+		{"公開指数あり", "c5\n3\n", 3, 0xc5, false},
+		{"空", "# only comment\n", 0, 0, true},
+		{"不正な16進数", "zz", 0, 0, true},
+		{"不正な指数", "c5 -1", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKokoroKey(strings.NewReader(tt.input))
+			if tt.wantErrs {
+				if err == nil {
+					t.Error("ParseKokoroKey() should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKokoroKey() error = %v", err)
+			}
+			if key.Exponent != tt.wantExp || key.Modulus.Int64() != tt.wantMod {
+				t.Errorf("ParseKokoroKey() = (%v, %d), want (%x, %d)", key.Modulus, key.Exponent, tt.wantMod, tt.wantExp)
+			}
+		})
+	}
+}
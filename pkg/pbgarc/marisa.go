@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/shiroemons/go-brightmoon/pkg/crypto"
 )
@@ -46,16 +47,19 @@ func (e *MarisaEntry) Extract(w io.Writer, callback func(string, interface{}) bo
 
 // MarisaArchive はMarisaアーカイブを表します
 type MarisaArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []MarisaEntry
 	curIndex int
 }
 
 // NewMarisaArchive は新しいMarisaArchiveを作成します
-func NewMarisaArchive() *MarisaArchive {
+func NewMarisaArchive(opts ...Option) *MarisaArchive {
 	return &MarisaArchive{
-		entries:  make([]MarisaEntry, 0),
-		curIndex: -1,
+		archiveLogger: newArchiveLogger("Marisa", opts),
+		entries:       make([]MarisaEntry, 0),
+		curIndex:      -1,
 	}
 }
 
@@ -70,7 +74,9 @@ func (a *MarisaArchive) Close() error {
 }
 
 // Open はアーカイブファイルを開きます (C++版のロジックに合わせて修正)
-func (a *MarisaArchive) Open(filename string) (bool, error) {
+func (a *MarisaArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -279,7 +285,12 @@ func (a *MarisaArchive) Extract(w io.Writer, callback func(string, interface{})
 }
 
 // ExtractEntry は指定されたエントリを抽出します (C++版のロジックに合わせて修正)
-func (a *MarisaArchive) ExtractEntry(entry *MarisaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *MarisaArchive) ExtractEntry(entry *MarisaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -290,12 +301,9 @@ func (a *MarisaArchive) ExtractEntry(entry *MarisaEntry, w io.Writer, callback f
 	}
 
 	// データを読み込み
+	// ReadAt はファイル位置を共有しないため、複数のエントリを並行して抽出できる
 	data := make([]byte, entry.Size)
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		// C++版は fail() のみチェック
-		return false
-	}
-	if _, err := io.ReadFull(a.file, data); err != nil {
+	if _, err := a.file.ReadAt(data, int64(entry.Offset)); err != nil {
 		// C++版は eof() || fail() チェック
 		return false
 	}
@@ -0,0 +1,100 @@
+package pbgarc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ログの属性のキー
+// 利用側でログを集計・検索できるよう、キーは変更しません
+const (
+	LogKeyArchive  = "archive"  // アーカイブファイルのパス
+	LogKeyFormat   = "format"   // アーカイブ形式 (Remilia, Kanako など)
+	LogKeySubType  = "subtype"  // サブタイプ (Kaguya/Kanako のアーカイブタイプ)
+	LogKeyEntry    = "entry"    // エントリ名
+	LogKeyOffset   = "offset"   // エントリのデータの開始位置
+	LogKeySize     = "size"     // サイズ (エントリの場合は展開後のサイズ)
+	LogKeyEntries  = "entries"  // エントリ数
+	LogKeyDuration = "duration" // 処理時間
+	LogKeyError    = "error"    // エラー
+	LogKeySlot     = "slot"     // Kanako の暗号化パラメータのスロット番号
+)
+
+// Option はアーカイブの作成時に指定する設定
+type Option func(*archiveConfig)
+
+// archiveConfig は Option で指定された設定
+type archiveConfig struct {
+	logger *slog.Logger
+}
+
+// WithLogger はアーカイブを開いた結果やエントリの抽出結果などのログの出力先を設定します
+// 指定しない場合はログを出力しません
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *archiveConfig) {
+		c.logger = logger
+	}
+}
+
+// archiveLogger はアーカイブの構造体に埋め込んでログを出力します
+// アーカイブを開いた後は、エントリのログにアーカイブファイルのパスを付けます
+type archiveLogger struct {
+	logger  *slog.Logger
+	archive string
+}
+
+// discardLogger は WithLogger を指定しない場合のログの出力先
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// newArchiveLogger は設定を適用し、形式名を属性に持つロガーを作成します
+func newArchiveLogger(format string, opts []Option) archiveLogger {
+	c := archiveConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.logger == nil {
+		return archiveLogger{logger: discardLogger}
+	}
+	return archiveLogger{logger: c.logger.With(LogKeyFormat, format)}
+}
+
+// log はロガーを返します (構造体をゼロ値で作成した場合はログを出力しません)
+func (l archiveLogger) log() *slog.Logger {
+	if l.logger == nil {
+		return discardLogger
+	}
+	return l.logger
+}
+
+// logOpen はアーカイブを開いた結果を出力します
+func (l *archiveLogger) logOpen(filename string, entries int, opened bool, err error, attrs ...any) {
+	log := l.log().With(LogKeyArchive, filename)
+	if opened {
+		l.archive = filename
+	}
+	switch {
+	case err != nil:
+		log.Debug("open archive failed", append(attrs, LogKeyError, err)...)
+	case !opened:
+		log.Debug("open archive failed", attrs...)
+	default:
+		log.Debug("archive opened", append(attrs, LogKeyEntries, entries)...)
+	}
+}
+
+// logExtract はエントリの抽出結果を出力します
+func (l archiveLogger) logExtract(entry string, offset int64, size uint32, start time.Time, extracted bool) {
+	log := l.log()
+	if l.archive != "" {
+		log = log.With(LogKeyArchive, l.archive)
+	}
+	level := slog.LevelDebug
+	msg := "entry extracted"
+	if !extracted {
+		level = slog.LevelWarn
+		msg = "entry extraction failed"
+	}
+	log.Log(context.Background(), level, msg,
+		LogKeyEntry, entry, LogKeyOffset, offset, LogKeySize, size, LogKeyDuration, time.Since(start))
+}
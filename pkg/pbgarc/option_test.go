@@ -0,0 +1,99 @@
+package pbgarc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// decodeLogs は JSON 形式のログを1行ずつ読み込みます
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("failed to decode log: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWithLogger(t *testing.T) {
+	files := map[string][]byte{"ecldata1.ecl": []byte("ECL test data")}
+	path := buildPBG3Archive(t, files, []string{"ecldata1.ecl"}, false)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	archive := NewRemiliaArchive(WithLogger(logger))
+	if ok, err := archive.Open(path); !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+	archive.EnumFirst()
+	if !archive.Extract(&bytes.Buffer{}, nil, nil) {
+		t.Fatal("Extract() failed")
+	}
+
+	records := decodeLogs(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d log records, want 2: %v", len(records), records)
+	}
+	open, extract := records[0], records[1]
+	if open["msg"] != "archive opened" || open[LogKeyArchive] != path || open[LogKeyFormat] != "Remilia" || open[LogKeyEntries] != 1.0 {
+		t.Errorf("open record = %v", open)
+	}
+	if extract["msg"] != "entry extracted" || extract[LogKeyArchive] != path || extract[LogKeyEntry] != "ecldata1.ecl" ||
+		extract[LogKeySize] != float64(len(files["ecldata1.ecl"])) {
+		t.Errorf("extract record = %v", extract)
+	}
+	for _, key := range []string{LogKeyOffset, LogKeyDuration} {
+		if _, ok := extract[key]; !ok {
+			t.Errorf("extract record has no %q: %v", key, extract)
+		}
+	}
+}
+
+func TestWithLogger_Failure(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "invalid.dat")
+	if err := os.WriteFile(tmpFile, make([]byte, 32), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	archive := NewKanakoArchive(WithLogger(logger))
+	archive.SetArchiveType(1)
+	if _, err := archive.Open(tmpFile); err == nil {
+		t.Fatal("Open() should return error for invalid magic number")
+	}
+
+	records := decodeLogs(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("got %d log records, want 1: %v", len(records), records)
+	}
+	if r := records[0]; r["msg"] != "open archive failed" || r[LogKeySubType] != 1.0 || r[LogKeyError] == nil {
+		t.Errorf("record = %v", r)
+	}
+}
+
+func TestWithLogger_Default(t *testing.T) {
+	// ロガーを指定しない場合やゼロ値の構造体でもログの出力で失敗しない
+	files := map[string][]byte{"a.txt": []byte("hello")}
+	path := buildPBG3Archive(t, files, []string{"a.txt"}, false)
+	for _, archive := range []*RemiliaArchive{NewRemiliaArchive(), {curIndex: -1}} {
+		if ok, err := archive.Open(path); !ok || err != nil {
+			t.Fatalf("Open() = %v, %v", ok, err)
+		}
+		archive.EnumFirst()
+		if !archive.Extract(&bytes.Buffer{}, nil, nil) {
+			t.Error("Extract() failed")
+		}
+		archive.Close()
+	}
+}
@@ -1,12 +1,15 @@
 // Package pbgarc は東方Projectのアーカイブファイル（.datファイル）を読み込むためのパッケージです。
 //
 // サポートするアーカイブ形式:
-//   - Hinanawi: 東方紅魔郷 (TH06)
-//   - Yumemi: 東方妖々夢 (TH07)
-//   - Kaguya: 東方永夜抄 (TH08)、東方花映塚 (TH09)、弾幕アマノジャク (TH14.3)
+//   - Remilia: 東方紅魔郷 (TH06) - PBG3形式
+//   - Yukari: 東方妖々夢 (TH07) - PBG4形式
+//   - Hinanawi: 東方緋想天 (TH10.5) 系の MT 暗号化形式
+//   - Yumemi: 8.3形式のファイル名を持つ旧形式
+//   - Kaguya: 東方永夜抄 (TH08)、東方花映塚 (TH09)
 //   - Marisa: 東方文花帖 (TH09.5)
 //   - Kanako: 東方風神録 (TH10) 以降の作品
-//   - Suica: 東方風神録の別形式
+//   - Suica: 東方萃夢想 (TH07.5)
+//   - Kokoro: 東方心綺楼 (TH13.5) 以降の黄昏フロンティア作品 - TFPK形式 (.pak)
 //
 // 基本的な使い方:
 //
@@ -22,6 +25,14 @@
 //	        }
 //	    }
 //	}
+//
+// ログ:
+//
+// WithLogger で log/slog のロガーを指定すると、アーカイブを開いた結果とエントリの抽出結果を
+// デバッグレベルで出力します (抽出の失敗は警告レベル)。属性のキーは LogKeyArchive などの定数です。
+//
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//	archive := pbgarc.NewKanakoArchive(pbgarc.WithLogger(logger))
 package pbgarc
 
 import "io"
@@ -76,5 +87,6 @@ type PBGArchiveEntry interface {
 	GetCompressedSize() uint32
 
 	// Extract はエントリを抽出します
+	// 同じアーカイブの異なるエントリの Extract は、複数の goroutine から同時に呼び出せます
 	Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool
 }
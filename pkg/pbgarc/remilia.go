@@ -0,0 +1,367 @@
+package pbgarc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shiroemons/go-brightmoon/pkg/crypto"
+)
+
+// RemiliaMagic は Remilia アーカイブの識別子 'PBG3' (リトルエンディアン)
+const RemiliaMagic = 0x33474250 // "PBG3" in little-endian
+
+// PBG3 形式の定数
+const (
+	remiliaMaxNameLen = 255 // エントリ名の最大長
+)
+
+// RemiliaEntry はRemilia(PBG3)アーカイブ内のエントリを表します
+type RemiliaEntry struct {
+	Offset   uint32 // ファイルデータの開始位置
+	Size     uint32 // 展開後サイズ
+	ZSize    uint32 // 圧縮サイズ（次のoffset - 現在のoffset）
+	Checksum uint32 // 圧縮データのバイト総和
+	Name     string // ファイル名
+	parent   *RemiliaArchive
+}
+
+// GetEntryName はエントリ名を取得します
+func (e *RemiliaEntry) GetEntryName() string {
+	return e.Name
+}
+
+// GetOriginalSize は元のサイズを取得します
+func (e *RemiliaEntry) GetOriginalSize() uint32 {
+	return e.Size
+}
+
+// GetCompressedSize は圧縮後のサイズを取得します
+func (e *RemiliaEntry) GetCompressedSize() uint32 {
+	return e.ZSize
+}
+
+// Extract はエントリを抽出します
+func (e *RemiliaEntry) Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+	if e.parent == nil {
+		return false
+	}
+
+	return e.parent.ExtractEntry(e, w, callback, user)
+}
+
+// RemiliaArchive はRemilia(PBG3)アーカイブを表します (東方紅魔郷 TH06)
+type RemiliaArchive struct {
+	archiveLogger
+
+	file     *os.File
+	entries  []RemiliaEntry
+	curIndex int
+}
+
+// NewRemiliaArchive は新しいRemiliaArchiveを作成します
+func NewRemiliaArchive(opts ...Option) *RemiliaArchive {
+	return &RemiliaArchive{
+		archiveLogger: newArchiveLogger("Remilia", opts),
+		entries:       make([]RemiliaEntry, 0),
+		curIndex:      -1,
+	}
+}
+
+// Close はアーカイブファイルを閉じます
+func (a *RemiliaArchive) Close() error {
+	if a.file != nil {
+		err := a.file.Close()
+		a.file = nil
+		return err
+	}
+	return nil
+}
+
+// readPBG3Uint32 は PBG3 形式の可変長整数を読み込みます
+// 先頭2ビットでバイト数-1を示し、続く (n+1)*8 ビットが値になります
+func readPBG3Uint32(br *crypto.BitReader) (uint32, error) {
+	size, err := br.Read(2)
+	if err != nil {
+		return 0, err
+	}
+	value, err := br.Read(uint(size+1) * 8)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(value), nil
+}
+
+// readPBG3String は PBG3 形式のヌル終端文字列 (8ビット単位) を読み込みます
+func readPBG3String(br *crypto.BitReader, maxLen int) (string, error) {
+	var result []byte
+	for i := 0; i < maxLen; i++ {
+		c, err := br.Read(8)
+		if err != nil {
+			return string(result), err
+		}
+		if c == 0 {
+			return string(result), nil
+		}
+		result = append(result, byte(c))
+	}
+	return string(result), errors.New("entry name too long")
+}
+
+// Open はアーカイブファイルを開きます (PBG3形式)
+func (a *RemiliaArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	a.file = file
+
+	// エラー時にクリーンアップするためのフラグ
+	success := false
+	defer func() {
+		if !success {
+			a.file.Close()
+			a.file = nil
+		}
+	}()
+
+	// ファイルサイズを取得
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	fileSize := fileInfo.Size()
+
+	// マジックナンバーを確認
+	var magic uint32
+	if err := binary.Read(a.file, binary.LittleEndian, &magic); err != nil {
+		return false, fmt.Errorf("failed to read magic number: %w", err)
+	}
+	if magic != RemiliaMagic {
+		return false, errors.New("invalid magic number: not PBG3")
+	}
+
+	// ヘッダ情報を読み込み (ビット単位の可変長整数)
+	headerReader := crypto.NewBitReader(a.file)
+	entryCount, err := readPBG3Uint32(headerReader)
+	if err != nil {
+		return false, fmt.Errorf("failed to read entry count: %w", err)
+	}
+	listOffset, err := readPBG3Uint32(headerReader)
+	if err != nil {
+		return false, fmt.Errorf("failed to read list offset: %w", err)
+	}
+
+	// ヘッダ情報の検証
+	if entryCount == 0 {
+		return false, errors.New("invalid entry count")
+	}
+	if int64(listOffset) >= fileSize {
+		return false, fmt.Errorf("invalid list offset %d >= filesize %d", listOffset, fileSize)
+	}
+
+	// エントリリストを読み込み (ファイル末尾まで)
+	if _, err := a.file.Seek(int64(listOffset), io.SeekStart); err != nil {
+		return false, fmt.Errorf("failed to seek to entry list: %w", err)
+	}
+	listData := make([]byte, fileSize-int64(listOffset))
+	if _, err := io.ReadFull(a.file, listData); err != nil {
+		return false, fmt.Errorf("failed to read entry list: %w", err)
+	}
+
+	// エントリリストをパース
+	listReader := crypto.NewBitReader(bytes.NewReader(listData))
+	a.entries = make([]RemiliaEntry, 0, min(entryCount, uint32(len(listData))))
+
+	for i := uint32(0); i < entryCount; i++ {
+		var entry RemiliaEntry
+
+		// 先頭2つの値は未使用
+		for j := 0; j < 2; j++ {
+			if _, err := readPBG3Uint32(listReader); err != nil {
+				return false, fmt.Errorf("failed to read header fields for entry %d: %w", i, err)
+			}
+		}
+		if entry.Checksum, err = readPBG3Uint32(listReader); err != nil {
+			return false, fmt.Errorf("failed to read checksum for entry %d: %w", i, err)
+		}
+		if entry.Offset, err = readPBG3Uint32(listReader); err != nil {
+			return false, fmt.Errorf("failed to read offset for entry %d: %w", i, err)
+		}
+		if entry.Size, err = readPBG3Uint32(listReader); err != nil {
+			return false, fmt.Errorf("failed to read size for entry %d: %w", i, err)
+		}
+		if entry.Name, err = readPBG3String(listReader, remiliaMaxNameLen); err != nil {
+			return false, fmt.Errorf("failed to read name for entry %d: %w", i, err)
+		}
+
+		// エントリデータの検証
+		if entry.Name == "" {
+			return false, fmt.Errorf("empty name for entry %d", i)
+		}
+		if entry.Offset > listOffset {
+			return false, fmt.Errorf("invalid entry offset %d for entry %d ('%s') (list offset %d)", entry.Offset, i, entry.Name, listOffset)
+		}
+
+		entry.parent = a
+		a.entries = append(a.entries, entry)
+	}
+
+	// 各エントリの圧縮サイズを計算 (次のエントリのoffset - 現在のoffset)
+	for i := range a.entries {
+		next := listOffset
+		if i < len(a.entries)-1 {
+			next = a.entries[i+1].Offset
+		}
+		if next < a.entries[i].Offset {
+			return false, fmt.Errorf("entry offsets are not in ascending order at entry %d ('%s')", i, a.entries[i].Name)
+		}
+		a.entries[i].ZSize = next - a.entries[i].Offset
+	}
+
+	success = true
+	return true, nil
+}
+
+// EnumFirst は最初のエントリに移動します
+func (a *RemiliaArchive) EnumFirst() bool {
+	if len(a.entries) == 0 {
+		return false
+	}
+	a.curIndex = 0
+	return true
+}
+
+// EnumNext は次のエントリに移動します
+func (a *RemiliaArchive) EnumNext() bool {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries)-1 {
+		return false
+	}
+	a.curIndex++
+	return true
+}
+
+// GetEntryName は現在のエントリ名を取得します
+func (a *RemiliaArchive) GetEntryName() string {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return ""
+	}
+	return a.entries[a.curIndex].Name
+}
+
+// GetOriginalSize は元のサイズを取得します
+func (a *RemiliaArchive) GetOriginalSize() uint32 {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return 0
+	}
+	return a.entries[a.curIndex].Size
+}
+
+// GetCompressedSize は圧縮後のサイズを取得します
+func (a *RemiliaArchive) GetCompressedSize() uint32 {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return 0
+	}
+	return a.entries[a.curIndex].ZSize
+}
+
+// GetEntry は現在のエントリを取得します
+func (a *RemiliaArchive) GetEntry() PBGArchiveEntry {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return nil
+	}
+	return &a.entries[a.curIndex]
+}
+
+// Extract は現在のエントリを抽出します
+func (a *RemiliaArchive) Extract(w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+	if a.curIndex < 0 || a.curIndex >= len(a.entries) {
+		return false
+	}
+	return a.ExtractEntry(&a.entries[a.curIndex], w, callback, user)
+}
+
+// ExtractEntry は指定されたエントリを抽出します
+func (a *RemiliaArchive) ExtractEntry(entry *RemiliaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
+	if callback != nil {
+		if !callback(entry.GetEntryName(), user) {
+			return false
+		}
+		if !callback(" extracting...", user) {
+			return false
+		}
+	}
+
+	// 圧縮データを読み込み (ReadAt はファイル位置を共有しないため、複数のエントリを並行して抽出できる)
+	compressedData := make([]byte, entry.ZSize)
+	if _, err := a.file.ReadAt(compressedData, int64(entry.Offset)); err != nil {
+		return false
+	}
+
+	// チェックサムを検証 (圧縮データのバイト総和)
+	var checksum uint32
+	for _, b := range compressedData {
+		checksum += uint32(b)
+	}
+	if checksum != entry.Checksum {
+		if callback != nil {
+			callback(fmt.Sprintf("checksum mismatch: 0x%x != 0x%x\r\n", checksum, entry.Checksum), user)
+		}
+		return false
+	}
+
+	// LZSS展開 (展開後のサイズを検証してから書き出す)
+	decompressed := bytes.NewBuffer(make([]byte, 0, entry.Size))
+	if err := crypto.UNLZSS(bytes.NewReader(compressedData), decompressed); err != nil {
+		if callback != nil {
+			callback(fmt.Sprintf("failed to decompress: %v\r\n", err), user)
+		}
+		return false
+	}
+	if decompressed.Len() != int(entry.Size) {
+		if callback != nil {
+			callback(fmt.Sprintf("size mismatch: %d != %d\r\n", decompressed.Len(), entry.Size), user)
+		}
+		return false
+	}
+	if _, err := w.Write(decompressed.Bytes()); err != nil {
+		return false
+	}
+
+	if callback != nil {
+		if !callback("finished.\r\n", user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExtractAll は全てのエントリを抽出します
+func (a *RemiliaArchive) ExtractAll(callback func(string, interface{}) bool, user interface{}) bool {
+	if !a.EnumFirst() {
+		return true // Empty archive is success
+	}
+
+	for {
+		if !a.Extract(io.Discard, callback, user) {
+			return false
+		}
+		if !a.EnumNext() {
+			break
+		}
+	}
+
+	return true
+}
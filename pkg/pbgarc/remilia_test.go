@@ -0,0 +1,252 @@
+package pbgarc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// bitWriter はテスト用の MSB ファーストなビットライター
+type bitWriter struct {
+	buf   []byte
+	cur   byte
+	count uint
+}
+
+func (w *bitWriter) write(value uint32, numBits uint) {
+	for i := int(numBits) - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte((value>>uint(i))&1)
+		w.count++
+		if w.count == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.count = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) writePBG3Uint32(value uint32) {
+	size := uint32(3)
+	switch {
+	case value <= 0xff:
+		size = 0
+	case value <= 0xffff:
+		size = 1
+	case value <= 0xffffff:
+		size = 2
+	}
+	w.write(size, 2)
+	w.write(value, uint(size+1)*8)
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.count > 0 {
+		return append(w.buf, w.cur<<(8-w.count))
+	}
+	return w.buf
+}
+
+// lzssLiteral はデータを全てリテラルとして LZSS 形式にエンコードします
+func lzssLiteral(data []byte) []byte {
+	w := &bitWriter{}
+	for _, b := range data {
+		w.write(1, 1)
+		w.write(uint32(b), 8)
+	}
+	w.write(0, 1)
+	w.write(0, 13)
+	return w.bytes()
+}
+
+// buildPBG3Archive はテスト用の PBG3 アーカイブを構築します
+func buildPBG3Archive(t *testing.T, files map[string][]byte, names []string, corrupt bool) string {
+	t.Helper()
+
+	header := &bitWriter{}
+	body := &bytes.Buffer{}
+	list := &bitWriter{}
+	offset := uint32(16)
+	for _, name := range names {
+		comp := lzssLiteral(files[name])
+		var sum uint32
+		for _, b := range comp {
+			sum += uint32(b)
+		}
+		if corrupt {
+			sum++
+		}
+		list.writePBG3Uint32(0)
+		list.writePBG3Uint32(0)
+		list.writePBG3Uint32(sum)
+		list.writePBG3Uint32(offset)
+		list.writePBG3Uint32(uint32(len(files[name])))
+		for _, c := range []byte(name) {
+			list.write(uint32(c), 8)
+		}
+		list.write(0, 8)
+		body.Write(comp)
+		offset += uint32(len(comp))
+	}
+	header.writePBG3Uint32(uint32(len(names)))
+	header.writePBG3Uint32(offset)
+
+	data := make([]byte, 16)
+	copy(data, "PBG3")
+	copy(data[4:], header.bytes())
+	data = append(data, body.Bytes()...)
+	data = append(data, list.bytes()...)
+
+	path := filepath.Join(t.TempDir(), "th06.dat")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	return path
+}
+
+func TestRemiliaArchive_OpenAndExtract(t *testing.T) {
+	files := map[string][]byte{
+		"ecldata1.ecl": []byte("ECL test data"),
+		"msg1.dat":     bytes.Repeat([]byte{0x82, 0xa0}, 300),
+	}
+	names := []string{"ecldata1.ecl", "msg1.dat"}
+	path := buildPBG3Archive(t, files, names, false)
+
+	archive := NewRemiliaArchive()
+	ok, err := archive.Open(path)
+	if !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+
+	if !archive.EnumFirst() {
+		t.Fatal("EnumFirst() returned false")
+	}
+	for i, name := range names {
+		if got := archive.GetEntryName(); got != name {
+			t.Errorf("GetEntryName() = %q, want %q", got, name)
+		}
+		if got := archive.GetOriginalSize(); got != uint32(len(files[name])) {
+			t.Errorf("GetOriginalSize() = %d, want %d", got, len(files[name]))
+		}
+		var out bytes.Buffer
+		if !archive.Extract(&out, nil, nil) {
+			t.Fatalf("Extract(%s) failed", name)
+		}
+		if !bytes.Equal(out.Bytes(), files[name]) {
+			t.Errorf("Extract(%s) = %q, want %q", name, out.Bytes(), files[name])
+		}
+		if next := archive.EnumNext(); next != (i < len(names)-1) {
+			t.Errorf("EnumNext() = %v at entry %d", next, i)
+		}
+	}
+}
+
+func TestRemiliaArchive_ConcurrentExtract(t *testing.T) {
+	files := make(map[string][]byte)
+	var names []string
+	for i := 0; i < 16; i++ {
+		name := fmt.Sprintf("data%02d.bin", i)
+		files[name] = bytes.Repeat([]byte{byte(i)}, 8<<10+i)
+		names = append(names, name)
+	}
+	path := buildPBG3Archive(t, files, names, false)
+
+	archive := NewRemiliaArchive()
+	if ok, err := archive.Open(path); !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+
+	var entries []PBGArchiveEntry
+	for do := archive.EnumFirst(); do; do = archive.EnumNext() {
+		entries = append(entries, archive.GetEntry())
+	}
+
+	// 同じアーカイブのエントリを並行して抽出しても内容が混ざらないこと
+	var wg sync.WaitGroup
+	for round := 0; round < 4; round++ {
+		for _, entry := range entries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var out bytes.Buffer
+				name := entry.GetEntryName()
+				if !entry.Extract(&out, nil, nil) {
+					t.Errorf("Extract(%s) failed", name)
+					return
+				}
+				if !bytes.Equal(out.Bytes(), files[name]) {
+					t.Errorf("Extract(%s) returned data of another entry", name)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestRemiliaArchive_ChecksumMismatch(t *testing.T) {
+	files := map[string][]byte{"a.txt": []byte("hello")}
+	path := buildPBG3Archive(t, files, []string{"a.txt"}, true)
+
+	archive := NewRemiliaArchive()
+	if ok, err := archive.Open(path); !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+
+	archive.EnumFirst()
+	if archive.Extract(&bytes.Buffer{}, nil, nil) {
+		t.Error("Extract() should fail on checksum mismatch")
+	}
+}
+
+func TestRemiliaArchive_SizeMismatch(t *testing.T) {
+	files := map[string][]byte{"a.txt": []byte("hello")}
+	path := buildPBG3Archive(t, files, []string{"a.txt"}, false)
+
+	archive := NewRemiliaArchive()
+	if ok, err := archive.Open(path); !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+
+	// 宣言サイズを展開後の長さと食い違わせる
+	archive.entries[0].Size++
+
+	archive.EnumFirst()
+	out := &bytes.Buffer{}
+	if archive.Extract(out, nil, nil) {
+		t.Error("Extract() should fail on size mismatch")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Extract() wrote %d bytes on size mismatch", out.Len())
+	}
+}
+
+func TestRemiliaArchive_OpenInvalidMagic(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "invalid.dat")
+	if err := os.WriteFile(tmpFile, make([]byte, 32), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	archive := NewRemiliaArchive()
+	if _, err := archive.Open(tmpFile); err == nil {
+		t.Error("Open() should return error for invalid magic number")
+	}
+}
+
+func TestRemiliaArchive_EnumBeforeOpen(t *testing.T) {
+	archive := NewRemiliaArchive()
+
+	if archive.EnumFirst() {
+		t.Error("EnumFirst() should return false before Open()")
+	}
+	if entry := archive.GetEntry(); entry != nil {
+		t.Error("GetEntry() should return nil before Open()")
+	}
+	if err := archive.Close(); err != nil {
+		t.Errorf("Close() on unopened archive returned error: %v", err)
+	}
+}
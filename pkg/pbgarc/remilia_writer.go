@@ -0,0 +1,141 @@
+package pbgarc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/shiroemons/go-brightmoon/pkg/crypto"
+)
+
+// remiliaHeaderSize はマジックナンバーとヘッダ (エントリ数・ファイル一覧の位置) の領域の大きさ
+const remiliaHeaderSize = 16
+
+// RemiliaWriter は PBG3 形式 (東方紅魔郷) のアーカイブを作成します
+// エントリのデータは追加した順に LZSS で圧縮して書き込み、Close でファイル一覧とヘッダを書き込みます
+type RemiliaWriter struct {
+	w       io.WriteSeeker
+	entries []RemiliaEntry
+	offset  uint32 // 次のエントリのデータを書き込む位置
+	closed  bool
+}
+
+// NewRemiliaWriter は w の先頭からアーカイブを書き込む RemiliaWriter を作成します
+// ヘッダは Close で書き込むため、先頭の領域は空けておきます
+func NewRemiliaWriter(w io.WriteSeeker) (*RemiliaWriter, error) {
+	if _, err := w.Write(make([]byte, remiliaHeaderSize)); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	return &RemiliaWriter{w: w, offset: remiliaHeaderSize}, nil
+}
+
+// Add はエントリを追加します
+// 名前は空でなく、NUL を含まない 255 バイト以下である必要があります
+func (rw *RemiliaWriter) Add(name string, data []byte) error {
+	if rw.closed {
+		return errors.New("archive writer is closed")
+	}
+	if name == "" || strings.IndexByte(name, 0) >= 0 {
+		return fmt.Errorf("invalid entry name %q", name)
+	}
+	if len(name) > remiliaMaxNameLen {
+		return fmt.Errorf("entry name too long: %s (%d bytes, max %d)", name, len(name), remiliaMaxNameLen)
+	}
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("entry too large: %s (%d bytes)", name, len(data))
+	}
+
+	compressed := &bytes.Buffer{}
+	if err := crypto.LZSS(data, compressed); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", name, err)
+	}
+	if uint64(rw.offset)+uint64(compressed.Len()) > math.MaxUint32 {
+		return fmt.Errorf("archive too large: %s does not fit", name)
+	}
+
+	// チェックサムは圧縮データのバイト総和
+	var checksum uint32
+	for _, b := range compressed.Bytes() {
+		checksum += uint32(b)
+	}
+	if _, err := rw.w.Write(compressed.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	rw.entries = append(rw.entries, RemiliaEntry{
+		Offset:   rw.offset,
+		Size:     uint32(len(data)),
+		ZSize:    uint32(compressed.Len()),
+		Checksum: checksum,
+		Name:     name,
+	})
+	rw.offset += uint32(compressed.Len())
+	return nil
+}
+
+// Close はファイル一覧とヘッダを書き込みます (w は閉じません)
+// エントリが1つもない場合は PBG3 形式として読み込めないため、エラーを返します
+func (rw *RemiliaWriter) Close() error {
+	if rw.closed {
+		return nil
+	}
+	rw.closed = true
+	if len(rw.entries) == 0 {
+		return errors.New("archive has no entries")
+	}
+
+	// ファイル一覧 (先頭2つの値は未使用)
+	list := &bytes.Buffer{}
+	bw := crypto.NewBitWriter(list)
+	for _, e := range rw.entries {
+		for _, v := range []uint32{0, 0, e.Checksum, e.Offset, e.Size} {
+			writePBG3Uint32(bw, v)
+		}
+		for _, c := range []byte(e.Name) {
+			bw.Write(uint32(c), 8)
+		}
+		bw.Write(0, 8)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if _, err := rw.w.Write(list.Bytes()); err != nil {
+		return fmt.Errorf("failed to write entry list: %w", err)
+	}
+
+	// ヘッダ (マジックナンバー、エントリ数、ファイル一覧の位置)
+	header := &bytes.Buffer{}
+	binary.Write(header, binary.LittleEndian, uint32(RemiliaMagic))
+	bw = crypto.NewBitWriter(header)
+	writePBG3Uint32(bw, uint32(len(rw.entries)))
+	writePBG3Uint32(bw, rw.offset)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if _, err := rw.w.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to header: %w", err)
+	}
+	if _, err := rw.w.Write(header.Bytes()); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	return nil
+}
+
+// writePBG3Uint32 は PBG3 形式の可変長整数を書き込みます (readPBG3Uint32 の逆)
+func writePBG3Uint32(bw *crypto.BitWriter, value uint32) {
+	size := uint32(3)
+	switch {
+	case value <= 0xff:
+		size = 0
+	case value <= 0xffff:
+		size = 1
+	case value <= 0xffffff:
+		size = 2
+	}
+	bw.Write(size, 2)
+	bw.Write(value, uint(size+1)*8)
+}
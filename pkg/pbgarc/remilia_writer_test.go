@@ -0,0 +1,108 @@
+package pbgarc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemiliaWriter_RoundTrip(t *testing.T) {
+	names := []string{"ecldata1.ecl", "music/thbgm.fmt", "empty.txt", "large.bin"}
+	files := map[string][]byte{
+		"ecldata1.ecl":    []byte("ECL test data"),
+		"music/thbgm.fmt": bytes.Repeat([]byte("th06_01.wav\x00"), 50),
+		"empty.txt":       {},
+		"large.bin":       bytes.Repeat([]byte{0x00, 0x01, 0x02, 0xff}, 5000),
+	}
+
+	path := filepath.Join(t.TempDir(), "th06.dat")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewRemiliaWriter(f)
+	if err != nil {
+		t.Fatalf("NewRemiliaWriter() error = %v", err)
+	}
+	for _, name := range names {
+		if err := rw.Add(name, files[name]); err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := NewRemiliaArchive()
+	if ok, err := archive.Open(path); !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+
+	if !archive.EnumFirst() {
+		t.Fatal("EnumFirst() = false")
+	}
+	for i, name := range names {
+		if got := archive.GetEntryName(); got != name {
+			t.Errorf("entry %d name = %q, want %q", i, got, name)
+		}
+		out := &bytes.Buffer{}
+		if !archive.Extract(out, nil, nil) {
+			t.Errorf("Extract(%s) failed", name)
+		} else if !bytes.Equal(out.Bytes(), files[name]) {
+			t.Errorf("Extract(%s) = %d bytes, want %d", name, out.Len(), len(files[name]))
+		}
+		if next := archive.EnumNext(); next != (i < len(names)-1) {
+			t.Errorf("EnumNext() = %v at entry %d", next, i)
+		}
+	}
+}
+
+func TestRemiliaWriter_Errors(t *testing.T) {
+	newWriter := func(t *testing.T) *RemiliaWriter {
+		t.Helper()
+		f, err := os.Create(filepath.Join(t.TempDir(), "th06.dat"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		rw, err := NewRemiliaWriter(f)
+		if err != nil {
+			t.Fatalf("NewRemiliaWriter() error = %v", err)
+		}
+		return rw
+	}
+
+	t.Run("不正なエントリ名", func(t *testing.T) {
+		rw := newWriter(t)
+		for _, name := range []string{"", "a\x00b", strings.Repeat("a", remiliaMaxNameLen+1)} {
+			if err := rw.Add(name, []byte("data")); err == nil {
+				t.Errorf("Add(%q) should fail", name)
+			}
+		}
+	})
+
+	t.Run("エントリがない", func(t *testing.T) {
+		if err := newWriter(t).Close(); err == nil {
+			t.Error("Close() without entries should fail")
+		}
+	})
+
+	t.Run("閉じた後の追加", func(t *testing.T) {
+		rw := newWriter(t)
+		if err := rw.Add("a.txt", []byte("a")); err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.Add("b.txt", []byte("b")); err == nil {
+			t.Error("Add() after Close() should fail")
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package pbgarc
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 安全でないエントリ名のエラー
+var (
+	ErrEmptyEntryName    = errors.New("entry name is empty")
+	ErrAbsoluteEntryName = errors.New("entry name is an absolute path")
+	ErrEntryNameTraverse = errors.New("entry name escapes the output directory")
+	ErrInvalidEntryName  = errors.New("entry name contains invalid characters")
+)
+
+// SanitizeEntryName はアーカイブ内のエントリ名を出力先ディレクトリ内の安全な相対パス ('/' 区切り) に変換します
+// 区切り文字 '\' は '/' に正規化し、空の要素や "." は取り除きます
+// 絶対パス、ドライブレター付きのパス、".." を含むパス、NUL 文字を含む名前はエラーになります
+func SanitizeEntryName(name string) (string, error) {
+	if strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEntryName, name)
+	}
+
+	normalized := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(normalized, "/") {
+		return "", fmt.Errorf("%w: %q", ErrAbsoluteEntryName, name)
+	}
+	if len(normalized) >= 2 && normalized[1] == ':' && isASCIILetter(normalized[0]) {
+		return "", fmt.Errorf("%w: %q", ErrAbsoluteEntryName, name)
+	}
+
+	parts := make([]string, 0, strings.Count(normalized, "/")+1)
+	for _, part := range strings.Split(normalized, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("%w: %q", ErrEntryNameTraverse, name)
+		}
+		if strings.Contains(part, ":") {
+			// NTFS の代替データストリームやドライブ指定になり得る
+			return "", fmt.Errorf("%w: %q", ErrInvalidEntryName, name)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("%w: %q", ErrEmptyEntryName, name)
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// JoinEntryPath はエントリ名を安全な相対パスに変換して outDir と結合します
+// 結合後のパスが outDir の外を指す場合はエラーになります
+func JoinEntryPath(outDir, name string) (string, error) {
+	safe, err := SanitizeEntryName(name)
+	if err != nil {
+		return "", err
+	}
+	joined := filepath.Join(outDir, filepath.FromSlash(safe))
+	rel, err := filepath.Rel(outDir, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("%w: %q", ErrEntryNameTraverse, name)
+	}
+	return joined, nil
+}
+
+// EntryNameSet は出力済みのエントリ名を記録し、大文字小文字のみが異なる名前の衝突を回避します
+// 大文字小文字を区別しないファイルシステムでは、衝突した名前のファイルが上書きされてしまうためです
+type EntryNameSet struct {
+	seen map[string]bool
+}
+
+// NewEntryNameSet は空の EntryNameSet を作成します
+func NewEntryNameSet() *EntryNameSet {
+	return &EntryNameSet{seen: make(map[string]bool)}
+}
+
+// Add は安全な相対パスを登録し、実際に使用する名前を返します
+// 既に大文字小文字を無視して同じ名前が登録されている場合は、拡張子の前に "~N" を付けた名前に変更し renamed に true を返します
+func (s *EntryNameSet) Add(name string) (unique string, renamed bool) {
+	if !s.seen[strings.ToLower(name)] {
+		s.seen[strings.ToLower(name)] = true
+		return name, false
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s~%d%s", base, i, ext)
+		if !s.seen[strings.ToLower(candidate)] {
+			s.seen[strings.ToLower(candidate)] = true
+			return candidate, true
+		}
+	}
+}
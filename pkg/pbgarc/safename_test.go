@@ -0,0 +1,143 @@
+package pbgarc
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeEntryName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"通常のファイル名", "bgm/th08_01.wav", "bgm/th08_01.wav", nil},
+		{"バックスラッシュ区切り", "data\\system\\title.nut", "data/system/title.nut", nil},
+		{"カレントディレクトリと重複区切り", "./data//a.anm", "data/a.anm", nil},
+		{"日本語のファイル名", "曲/紅魔郷.txt", "曲/紅魔郷.txt", nil},
+		{"親ディレクトリへの移動", "../../.bashrc", "", ErrEntryNameTraverse},
+		{"途中の親ディレクトリ", "data/../../etc/passwd", "", ErrEntryNameTraverse},
+		{"バックスラッシュでの親ディレクトリ", "..\\..\\autoexec.bat", "", ErrEntryNameTraverse},
+		{"絶対パス", "/etc/passwd", "", ErrAbsoluteEntryName},
+		{"UNC パス", "\\\\server\\share\\a.txt", "", ErrAbsoluteEntryName},
+		{"ドライブレター", "C:\\Windows\\system32\\a.dll", "", ErrAbsoluteEntryName},
+		{"相対ドライブレター", "c:a.txt", "", ErrAbsoluteEntryName},
+		{"代替データストリーム", "data/a.txt:stream", "", ErrInvalidEntryName},
+		{"NUL 文字", "a\x00.txt", "", ErrInvalidEntryName},
+		{"空", "", "", ErrEmptyEntryName},
+		{"区切り文字のみ", "./", "", ErrEmptyEntryName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeEntryName(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("SanitizeEntryName(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SanitizeEntryName(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("SanitizeEntryName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinEntryPath(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "out")
+
+	got, err := JoinEntryPath(outDir, "bgm\\th06_01.wav")
+	if err != nil {
+		t.Fatalf("JoinEntryPath() error = %v", err)
+	}
+	if want := filepath.Join(outDir, "bgm", "th06_01.wav"); got != want {
+		t.Errorf("JoinEntryPath() = %q, want %q", got, want)
+	}
+
+	if _, err := JoinEntryPath(outDir, "../escape.txt"); !errors.Is(err, ErrEntryNameTraverse) {
+		t.Errorf("JoinEntryPath() error = %v, want %v", err, ErrEntryNameTraverse)
+	}
+}
+
+func TestEntryNameSet_Add(t *testing.T) {
+	set := NewEntryNameSet()
+	tests := []struct {
+		input       string
+		want        string
+		wantRenamed bool
+	}{
+		{"bgm/a.wav", "bgm/a.wav", false},
+		{"BGM/A.WAV", "BGM/A~1.WAV", true},
+		{"bgm/A.wav", "bgm/A~2.wav", true},
+		{"bgm/a~1.wav", "bgm/a~1~1.wav", true},
+		{"bgm/b.wav", "bgm/b.wav", false},
+		{"readme", "readme", false},
+		{"README", "README~1", true},
+	}
+	for _, tt := range tests {
+		got, renamed := set.Add(tt.input)
+		if got != tt.want || renamed != tt.wantRenamed {
+			t.Errorf("Add(%q) = (%q, %v), want (%q, %v)", tt.input, got, renamed, tt.want, tt.wantRenamed)
+		}
+	}
+}
+
+// 悪意のあるエントリ名を含むアーカイブを読み込み、全エントリが出力先に収まるか拒否されることを確認する
+func TestSanitizeEntryName_MaliciousArchive(t *testing.T) {
+	names := []string{
+		"../../.bashrc",
+		"/etc/passwd",
+		"C:\\Windows\\win.ini",
+		"data\\..\\..\\evil.dll",
+		"data\\ok.txt",
+		"DATA/OK.TXT",
+	}
+	files := make(map[string][]byte, len(names))
+	for _, name := range names {
+		files[name] = []byte(name)
+	}
+	path := buildPBG3Archive(t, files, names, false)
+
+	archive := NewRemiliaArchive()
+	if ok, err := archive.Open(path); !ok || err != nil {
+		t.Fatalf("Open() = %v, %v", ok, err)
+	}
+	defer archive.Close()
+
+	outDir := t.TempDir()
+	set := NewEntryNameSet()
+	var accepted []string
+	for do := archive.EnumFirst(); do; do = archive.EnumNext() {
+		safe, err := SanitizeEntryName(archive.GetEntryName())
+		if err != nil {
+			continue
+		}
+		safe, _ = set.Add(safe)
+		joined, err := JoinEntryPath(outDir, safe)
+		if err != nil {
+			t.Errorf("JoinEntryPath(%q) error = %v", safe, err)
+			continue
+		}
+		if rel, err := filepath.Rel(outDir, joined); err != nil || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+			t.Errorf("%q escapes the output directory: %q", archive.GetEntryName(), joined)
+		}
+		accepted = append(accepted, safe)
+	}
+
+	want := []string{"data/ok.txt", "DATA/OK~1.TXT"}
+	if len(accepted) != len(want) {
+		t.Fatalf("accepted = %v, want %v", accepted, want)
+	}
+	for i := range want {
+		if accepted[i] != want[i] {
+			t.Errorf("accepted[%d] = %q, want %q", i, accepted[i], want[i])
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 )
 
 // SuicaEntry はSuicaアーカイブ内のエントリを表します
@@ -41,16 +42,19 @@ func (e *SuicaEntry) Extract(w io.Writer, callback func(string, interface{}) boo
 
 // SuicaArchive はSuicaアーカイブを表します
 type SuicaArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []SuicaEntry
 	curIndex int
 }
 
 // NewSuicaArchive は新しいSuicaArchiveを作成します
-func NewSuicaArchive() *SuicaArchive {
+func NewSuicaArchive(opts ...Option) *SuicaArchive {
 	return &SuicaArchive{
-		entries:  make([]SuicaEntry, 0),
-		curIndex: -1,
+		archiveLogger: newArchiveLogger("Suica", opts),
+		entries:       make([]SuicaEntry, 0),
+		curIndex:      -1,
 	}
 }
 
@@ -65,7 +69,9 @@ func (a *SuicaArchive) Close() error {
 }
 
 // Open はアーカイブファイルを開きます
-func (a *SuicaArchive) Open(filename string) (bool, error) {
+func (a *SuicaArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -227,7 +233,12 @@ func (a *SuicaArchive) Extract(w io.Writer, callback func(string, interface{}) b
 }
 
 // ExtractEntry は指定されたエントリを抽出します
-func (a *SuicaArchive) ExtractEntry(entry *SuicaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *SuicaArchive) ExtractEntry(entry *SuicaEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -237,10 +248,8 @@ func (a *SuicaArchive) ExtractEntry(entry *SuicaEntry, w io.Writer, callback fun
 		}
 	}
 
-	// ファイルポインタを移動
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		return false
-	}
+	// エントリの範囲だけを読む (ファイル位置を共有しないため、複数のエントリを並行して抽出できる)
+	data := io.NewSectionReader(a.file, int64(entry.Offset), int64(entry.Size))
 
 	// バッファサイズ
 	bufSize := uint32(1024)
@@ -258,7 +267,7 @@ func (a *SuicaArchive) ExtractEntry(entry *SuicaEntry, w io.Writer, callback fun
 			readSize = remaining
 		}
 
-		if _, err := io.ReadFull(a.file, buffer[:readSize]); err != nil {
+		if _, err := io.ReadFull(data, buffer[:readSize]); err != nil {
 			return false
 		}
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/shiroemons/go-brightmoon/pkg/crypto"
 )
@@ -50,16 +51,19 @@ func (e *YukariEntry) Extract(w io.Writer, callback func(string, interface{}) bo
 
 // YukariArchive はYukari(PBG4)アーカイブを表します
 type YukariArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []YukariEntry
 	curIndex int
 }
 
 // NewYukariArchive は新しいYukariArchiveを作成します
-func NewYukariArchive() *YukariArchive {
+func NewYukariArchive(opts ...Option) *YukariArchive {
 	return &YukariArchive{
-		entries:  make([]YukariEntry, 0),
-		curIndex: -1,
+		archiveLogger: newArchiveLogger("Yukari", opts),
+		entries:       make([]YukariEntry, 0),
+		curIndex:      -1,
 	}
 }
 
@@ -74,7 +78,9 @@ func (a *YukariArchive) Close() error {
 }
 
 // Open はアーカイブファイルを開きます (PBG4形式)
-func (a *YukariArchive) Open(filename string) (bool, error) {
+func (a *YukariArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -282,7 +288,12 @@ func (a *YukariArchive) Extract(w io.Writer, callback func(string, interface{})
 }
 
 // ExtractEntry は指定されたエントリを抽出します
-func (a *YukariArchive) ExtractEntry(entry *YukariEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *YukariArchive) ExtractEntry(entry *YukariEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -292,14 +303,9 @@ func (a *YukariArchive) ExtractEntry(entry *YukariEntry, w io.Writer, callback f
 		}
 	}
 
-	// ファイルポインタを移動
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		return false
-	}
-
-	// 圧縮データを読み込み
+	// 圧縮データを読み込み (ReadAt はファイル位置を共有しないため、複数のエントリを並行して抽出できる)
 	compressedData := make([]byte, entry.ZSize)
-	if _, err := io.ReadFull(a.file, compressedData); err != nil {
+	if _, err := a.file.ReadAt(compressedData, int64(entry.Offset)); err != nil {
 		return false
 	}
 
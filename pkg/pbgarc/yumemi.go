@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/shiroemons/go-brightmoon/pkg/crypto"
 )
@@ -51,16 +52,19 @@ func (e *YumemiEntry) Extract(w io.Writer, callback func(string, interface{}) bo
 
 // YumemiArchive はYumemiアーカイブを表します
 type YumemiArchive struct {
+	archiveLogger
+
 	file     *os.File
 	entries  []YumemiEntry
 	curIndex int
 }
 
 // NewYumemiArchive は新しいYumemiArchiveを作成します
-func NewYumemiArchive() *YumemiArchive {
+func NewYumemiArchive(opts ...Option) *YumemiArchive {
 	return &YumemiArchive{
-		entries:  make([]YumemiEntry, 0),
-		curIndex: -1,
+		archiveLogger: newArchiveLogger("Yumemi", opts),
+		entries:       make([]YumemiEntry, 0),
+		curIndex:      -1,
 	}
 }
 
@@ -118,7 +122,9 @@ func validateName(nameBytes []byte) (string, bool) {
 }
 
 // Open はアーカイブファイルを開きます (C++版のロジックに合わせて修正)
-func (a *YumemiArchive) Open(filename string) (bool, error) {
+func (a *YumemiArchive) Open(filename string) (opened bool, err error) {
+	defer func() { a.logOpen(filename, len(a.entries), opened, err) }()
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return false, err
@@ -333,7 +339,12 @@ func (a *YumemiArchive) Extract(w io.Writer, callback func(string, interface{})
 }
 
 // ExtractEntry は指定されたエントリを抽出します
-func (a *YumemiArchive) ExtractEntry(entry *YumemiEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) bool {
+func (a *YumemiArchive) ExtractEntry(entry *YumemiEntry, w io.Writer, callback func(string, interface{}) bool, user interface{}) (extracted bool) {
+	start := time.Now()
+	defer func() {
+		a.logExtract(entry.GetEntryName(), int64(entry.Offset), entry.GetOriginalSize(), start, extracted)
+	}()
+
 	if callback != nil {
 		if !callback(entry.GetEntryName(), user) {
 			return false
@@ -343,14 +354,9 @@ func (a *YumemiArchive) ExtractEntry(entry *YumemiEntry, w io.Writer, callback f
 		}
 	}
 
-	// ファイルポインタを移動
-	if _, err := a.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
-		return false
-	}
-
-	// データを読み込み
+	// データを読み込み (ReadAt はファイル位置を共有しないため、複数のエントリを並行して抽出できる)
 	data := make([]byte, entry.CompSize)
-	if _, err := io.ReadFull(a.file, data); err != nil {
+	if _, err := a.file.ReadAt(data, int64(entry.Offset)); err != nil {
 		return false
 	}
 